@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -11,6 +13,7 @@ import (
 	"github.com/RedShawn258/FinTrack/backend/internal/config"
 	"github.com/RedShawn258/FinTrack/backend/internal/db"
 	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/middlewares"
 	"github.com/RedShawn258/FinTrack/backend/internal/routes"
 )
 
@@ -53,8 +56,26 @@ func main() {
 		logger.Error("Failed to recalculate budgets on startup", zap.Error(err))
 	}
 
-	// Set up Gin router
-	r := gin.Default()
+	// Materialize recurring transactions and open recurring budget periods in the background
+	recurringInterval, err := time.ParseDuration(cfg.RecurringInterval)
+	if err != nil || recurringInterval <= 0 {
+		logger.Warn("Invalid RECURRING_WORKER_INTERVAL, using 1h", zap.String("value", cfg.RecurringInterval))
+		recurringInterval = time.Hour
+	}
+	go handlers.RunRecurringWorker(context.Background(), recurringInterval, logger)
+
+	// Push notifications to open streams. Running more than one replica needs a StreamBackend they share
+	handlers.UseNotificationHub(handlers.NewNotificationHub(handlers.NewLocalStreamBackend(), logger))
+
+	// Tell users about unusual new transactions
+	handlers.OnAnomaly(handlers.NotifyAnomalies)
+
+	// Award points and badges for what users do
+	handlers.OnGamificationEvent(handlers.AwardPoints)
+
+	// Set up Gin router. The request log leaves out query strings, which can carry a stream's token
+	r := gin.New()
+	r.Use(middlewares.RequestLogger(gin.DefaultWriter), gin.Recovery())
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:3001"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
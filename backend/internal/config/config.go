@@ -17,6 +17,9 @@ type Config struct {
 	DBName     string
 	JWTSecret  string
 	ServerPort string
+
+	// How often the recurring transaction worker runs, as a Go duration (e.g. "1h")
+	RecurringInterval string
 }
 
 // LoadConfig loads environment variables into the Config struct.
@@ -33,6 +36,8 @@ func LoadConfig() (*Config, error) {
 		DBName:     getEnv("DB_NAME", "fintrack"),
 		JWTSecret:  getEnv("JWT_SECRET", "your-secret-key"),
 		ServerPort: getEnv("PORT", "8080"),
+
+		RecurringInterval: getEnv("RECURRING_WORKER_INTERVAL", "1h"),
 	}
 
 	return config, nil
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"encoding/json"
+
 	"go.uber.org/zap"
 
 	"github.com/RedShawn258/FinTrack/backend/internal/models"
@@ -20,7 +22,13 @@ func RunMigrations(logger *zap.Logger) error {
 		&models.User{},
 		&models.Category{},
 		&models.Budget{},
+		&models.BudgetAlert{},
 		&models.Transaction{},
+		&models.RecurringTransaction{},
+		&models.CategoryRule{},
+		&models.Scenario{},
+		&models.ScenarioChange{},
+		&models.Notification{},
 		&models.Badge{},
 		&models.UserBadge{},
 		&models.UserPoints{},
@@ -38,6 +46,66 @@ func RunMigrations(logger *zap.Logger) error {
 	return nil
 }
 
+// defaultBadges are the badges seeded into an empty database.
+var defaultBadges = []models.Badge{
+	{
+		Name:        "Budget Beginner",
+		Description: "Created your first budget",
+		ImageURL:    "/images/badges/budget_beginner.png",
+		Category:    "budgeting",
+		Threshold:   10,
+		Rule:        json.RawMessage(`{"stat": "budget_count", "op": ">=", "value": 1}`),
+	},
+	{
+		Name:        "Tracking Pro",
+		Description: "Tracked expenses for 7 consecutive days",
+		ImageURL:    "/images/badges/tracking_pro.png",
+		Category:    "consistency",
+		Threshold:   50,
+		Rule:        json.RawMessage(`{"stat": "tracking_streak", "op": ">=", "value": 7}`),
+	},
+	{
+		Name:        "Savings Star",
+		Description: "Saved 10% of your income",
+		ImageURL:    "/images/badges/savings_star.png",
+		Category:    "savings",
+		Threshold:   100,
+		Rule:        json.RawMessage(`{"stat": "savings_rate", "op": ">=", "value": 10}`),
+	},
+	{
+		Name:        "Budget Master",
+		Description: "Stayed under budget for 3 consecutive months",
+		ImageURL:    "/images/badges/budget_master.png",
+		Category:    "budgeting",
+		Threshold:   150,
+		Rule:        json.RawMessage(`{"stat": "months_under_budget", "op": ">=", "value": 3}`),
+	},
+	{
+		Name:        "Finance Ninja",
+		Description: "Created budgets in all essential categories",
+		ImageURL:    "/images/badges/finance_ninja.png",
+		Category:    "budgeting",
+		Threshold:   200,
+		Rule:        json.RawMessage(`{"stat": "categories_budgeted", "op": ">=", "value": 5}`),
+	},
+	{
+		Name:        "Expense Tracker",
+		Description: "Logged 100 transactions",
+		ImageURL:    "/images/badges/expense_tracker.png",
+		Category:    "tracking",
+		Threshold:   250,
+		Rule:        json.RawMessage(`{"stat": "transaction_count", "op": ">=", "value": 100}`),
+	},
+	{
+		Name:        "Financial Wizard",
+		Description: "Reached 500 total points",
+		ImageURL:    "/images/badges/financial_wizard.png",
+		Category:    "achievement",
+		Threshold:   500,
+		Rule:        json.RawMessage(`{"stat": "total_points", "op": ">=", "value": 500}`),
+	},
+}
+
 // seedDefaultBadges adds the default badges to the database if they don't exist
 func seedDefaultBadges(logger *zap.Logger) {
 	var count int64
@@ -45,64 +113,20 @@ func seedDefaultBadges(logger *zap.Logger) {
 
 	// Only seed if no badges exist
 	if count == 0 {
-		badges := []models.Badge{
-			{
-				Name:        "Budget Beginner",
-				Description: "Created your first budget",
-				ImageURL:    "/images/badges/budget_beginner.png",
-				Category:    "budgeting",
-				Threshold:   10,
-			},
-			{
-				Name:        "Tracking Pro",
-				Description: "Tracked expenses for 7 consecutive days",
-				ImageURL:    "/images/badges/tracking_pro.png",
-				Category:    "consistency",
-				Threshold:   50,
-			},
-			{
-				Name:        "Savings Star",
-				Description: "Saved 10% of your income",
-				ImageURL:    "/images/badges/savings_star.png",
-				Category:    "savings",
-				Threshold:   100,
-			},
-			{
-				Name:        "Budget Master",
-				Description: "Stayed under budget for 3 consecutive months",
-				ImageURL:    "/images/badges/budget_master.png",
-				Category:    "budgeting",
-				Threshold:   150,
-			},
-			{
-				Name:        "Finance Ninja",
-				Description: "Created budgets in all essential categories",
-				ImageURL:    "/images/badges/finance_ninja.png",
-				Category:    "budgeting",
-				Threshold:   200,
-			},
-			{
-				Name:        "Expense Tracker",
-				Description: "Logged 100 transactions",
-				ImageURL:    "/images/badges/expense_tracker.png",
-				Category:    "tracking",
-				Threshold:   250,
-			},
-			{
-				Name:        "Financial Wizard",
-				Description: "Reached 500 total points",
-				ImageURL:    "/images/badges/financial_wizard.png",
-				Category:    "achievement",
-				Threshold:   500,
-			},
-		}
-
-		for _, badge := range badges {
+		for _, badge := range defaultBadges {
 			if err := DB.Create(&badge).Error; err != nil {
 				logger.Error("Failed to seed badge", zap.Error(err), zap.String("badge", badge.Name))
 			}
 		}
 
 		logger.Info("Seeded default badges")
+		return
+	}
+
+	// Default badges seeded before badges had rules were awarded on points alone; give them their rule
+	for _, badge := range defaultBadges {
+		if err := DB.Model(&models.Badge{}).Where("name = ? AND rule IS NULL", badge.Name).Update("rule", badge.Rule).Error; err != nil {
+			logger.Error("Failed to backfill badge rule", zap.Error(err), zap.String("badge", badge.Name))
+		}
 	}
 }
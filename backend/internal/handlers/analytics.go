@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// analyticsTopN caps the top descriptions and largest transactions lists.
+const analyticsTopN = 10
+
+// comparePeriod compares current, the net spending of the report period, with the net spending in
+// [start, end].
+func comparePeriod(userID uint, current float64, start, end time.Time) (models.PeriodComparison, error) {
+	summary, err := getCashFlowSummary(userID, start, end)
+	if err != nil {
+		return models.PeriodComparison{}, err
+	}
+
+	comparison := models.PeriodComparison{
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		NetExpenses: summary.NetExpenses,
+		Change:      roundCents(current - summary.NetExpenses),
+	}
+	if summary.NetExpenses != 0 {
+		percent := roundCents((current - summary.NetExpenses) / summary.NetExpenses * 100)
+		comparison.ChangePercent = &percent
+	}
+	return comparison, nil
+}
+
+// categorySpending sums the net spending per category in [start, end], largest first.
+func categorySpending(userID uint, start, end time.Time, netExpenses float64) ([]models.CategorySpending, error) {
+	var rows []struct {
+		CategoryID   *uint
+		Amount       float64
+		Transactions int
+	}
+	err := db.DB.Model(&models.Transaction{}).
+		Select("category_id, "+netExpenseSumSQL+" as amount, COUNT(*) as transactions").
+		Where("user_id = ? AND type IN ? AND transaction_date >= ? AND transaction_date <= ?",
+			userID, []string{models.TransactionTypeExpense, models.TransactionTypeRefund}, start, end).
+		Group("category_id").
+		Order("amount DESC").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return []models.CategorySpending{}, err
+	}
+
+	names, err := loadCategoryNames(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	spending := make([]models.CategorySpending, 0, len(rows))
+	for _, r := range rows {
+		item := models.CategorySpending{
+			CategoryID:   r.CategoryID,
+			CategoryName: "Uncategorized",
+			Amount:       roundCents(r.Amount),
+			Transactions: r.Transactions,
+		}
+		if r.CategoryID != nil {
+			item.CategoryName = categoryName(names, *r.CategoryID)
+		}
+		if netExpenses != 0 {
+			item.Share = roundCents(r.Amount / netExpenses * 100)
+		}
+		spending = append(spending, item)
+	}
+	return spending, nil
+}
+
+// topDescriptions sums the net spending per description in [start, end] and returns the largest.
+func topDescriptions(userID uint, start, end time.Time) ([]models.DescriptionSpending, error) {
+	descriptions := []models.DescriptionSpending{}
+	err := db.DB.Model(&models.Transaction{}).
+		Select("description, "+netExpenseSumSQL+" as amount, COUNT(*) as transactions").
+		Where("user_id = ? AND type IN ? AND transaction_date >= ? AND transaction_date <= ? AND description <> ''",
+			userID, []string{models.TransactionTypeExpense, models.TransactionTypeRefund}, start, end).
+		Group("description").
+		Order("amount DESC").
+		Limit(analyticsTopN).
+		Scan(&descriptions).Error
+	for i := range descriptions {
+		descriptions[i].Amount = roundCents(descriptions[i].Amount)
+	}
+	return descriptions, err
+}
+
+// dailySpending returns the net spending of every day in [start, end], days without spending included.
+func dailySpending(userID uint, start, end time.Time) ([]models.DailySpending, error) {
+	var rows []struct {
+		TransactionDate time.Time
+		Amount          float64
+	}
+	err := db.DB.Model(&models.Transaction{}).
+		Select("transaction_date, "+netExpenseSumSQL+" as amount").
+		Where("user_id = ? AND type IN ? AND transaction_date >= ? AND transaction_date <= ?",
+			userID, []string{models.TransactionTypeExpense, models.TransactionTypeRefund}, start, end).
+		Group("transaction_date").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make(map[string]float64, len(rows))
+	for _, r := range rows {
+		amounts[r.TransactionDate.Format("2006-01-02")] += r.Amount
+	}
+	days := make([]models.DailySpending, 0, daysBetween(start, end)+1)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		days = append(days, models.DailySpending{Date: date, Amount: roundCents(amounts[date])})
+	}
+	return days, nil
+}
+
+// budgetUtilization reports how much of each budget overlapping [start, end] has been spent, from the
+// remaining amounts the budget recalculation keeps up to date.
+func budgetUtilization(userID uint, start, end time.Time) ([]models.BudgetUtilization, error) {
+	var budgets []models.Budget
+	err := db.DB.Where("user_id = ? AND start_date <= ? AND end_date >= ?", userID, end, start).
+		Order("start_date, id").
+		Find(&budgets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	utilization := make([]models.BudgetUtilization, 0, len(budgets))
+	for _, b := range budgets {
+		limit := roundCents(b.LimitAmount + b.RolloverAmount)
+		item := models.BudgetUtilization{
+			BudgetID:   b.ID,
+			CategoryID: b.CategoryID,
+			StartDate:  b.StartDate.Format("2006-01-02"),
+			EndDate:    b.EndDate.Format("2006-01-02"),
+			Limit:      limit,
+			Spent:      roundCents(limit - b.RemainingAmount),
+			Remaining:  roundCents(b.RemainingAmount),
+		}
+		if limit > 0 {
+			item.Utilization = roundCents(item.Spent / limit * 100)
+		}
+		utilization = append(utilization, item)
+	}
+	return utilization, nil
+}
+
+// buildAnalyticsReport computes the spending analytics of [start, end]. Every figure is aggregated in SQL;
+// only the largest transactions and the overlapping budgets are loaded as rows.
+func buildAnalyticsReport(userID uint, start, end time.Time) (models.AnalyticsReport, error) {
+	report := models.AnalyticsReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Days:      daysBetween(start, end) + 1,
+	}
+
+	summary, err := getCashFlowSummary(userID, start, end)
+	if err != nil {
+		return report, err
+	}
+	report.Summary = summary
+	report.AverageDailySpend = roundCents(summary.NetExpenses / float64(report.Days))
+
+	if report.Categories, err = categorySpending(userID, start, end, summary.NetExpenses); err != nil {
+		return report, err
+	}
+
+	// The same dates a month and a year earlier
+	report.MonthOverMonth, err = comparePeriod(userID, summary.NetExpenses, addMonthsClamped(start, -1), addMonthsClamped(end, -1))
+	if err != nil {
+		return report, err
+	}
+	report.YearOverYear, err = comparePeriod(userID, summary.NetExpenses, addMonthsClamped(start, -12), addMonthsClamped(end, -12))
+	if err != nil {
+		return report, err
+	}
+
+	if report.TopDescriptions, err = topDescriptions(userID, start, end); err != nil {
+		return report, err
+	}
+	if report.Daily, err = dailySpending(userID, start, end); err != nil {
+		return report, err
+	}
+	if report.BudgetUtilization, err = budgetUtilization(userID, start, end); err != nil {
+		return report, err
+	}
+
+	report.LargestTransactions = []models.Transaction{}
+	err = db.DB.Where("user_id = ? AND type = ? AND transaction_date >= ? AND transaction_date <= ?",
+		userID, models.TransactionTypeExpense, start, end).
+		Order("amount DESC, transaction_date DESC").
+		Limit(analyticsTopN).
+		Find(&report.LargestTransactions).Error
+	return report, err
+}
+
+// analyticsRange reads the optional startDate and endDate query params (YYYY-MM-DD). They default to the
+// last defaultMonths calendar months up to today, the current one included.
+func analyticsRange(c *gin.Context, defaultMonths int) (time.Time, time.Time, error) {
+	end := dateOnly(time.Now())
+	start := time.Date(end.Year(), end.Month()-time.Month(defaultMonths-1), 1, 0, 0, 0, 0, end.Location())
+	if raw := c.Query("startDate"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return start, end, errors.New("invalid start date")
+		}
+		start = dateOnly(parsed)
+	}
+	if raw := c.Query("endDate"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return start, end, errors.New("invalid end date")
+		}
+		end = dateOnly(parsed)
+	}
+	if end.Before(start) {
+		return start, end, errors.New("end date must be after start date")
+	}
+	return start, end, nil
+}
+
+// AnalyticsHandler reports the user's spending over a date range: per category, compared with the month
+// and year before, by description, per day, against budgets, and the largest transactions. Optional
+// startDate and endDate query params (YYYY-MM-DD) default to the current month so far.
+func AnalyticsHandler(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	start, end, err := analyticsRange(c, 1)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if db.DB == nil {
+		log.Error("Database connection not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	report, err := buildAnalyticsReport(userID, start, end)
+	if err != nil {
+		log.Error("Failed to compute analytics", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not compute analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
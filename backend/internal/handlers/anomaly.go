@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+const (
+	// anomalyHistoryMonths is how far before the checked period the history it is compared with reaches.
+	anomalyHistoryMonths = 12
+	// anomalyMinHistory is how many earlier transactions a category (or the user, for first-time
+	// purchases) needs before anything is flagged against it.
+	anomalyMinHistory = 5
+	// anomalyMinMonths is how many earlier months a category needs before its months are scored.
+	anomalyMinMonths = 3
+	// anomalyScoreThreshold is the robust z-score above which an amount is an outlier (Iglewicz and Hoaglin).
+	anomalyScoreThreshold = 3.5
+	// anomalyLargePercentile is the share of the user's earlier expenses a first-time purchase has to exceed.
+	anomalyLargePercentile = 0.95
+)
+
+// median returns the middle of values, 0 when there are none. values is not modified.
+func median(values []float64) float64 {
+	return percentile(values, 0.5)
+}
+
+// percentile returns the p-quantile of values, interpolating between neighbours. values is not modified.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// robustScore returns how many robust standard deviations value lies above the median of history, and that
+// median. The spread is 1.4826 times the median absolute deviation, floored at a tenth of the median (and at
+// 1) so that a history of identical amounts still tolerates small changes.
+func robustScore(value float64, history []float64) (float64, float64) {
+	med := median(history)
+	deviations := make([]float64, len(history))
+	for i, v := range history {
+		deviations[i] = math.Abs(v - med)
+	}
+	spread := math.Max(1.4826*median(deviations), math.Max(math.Abs(med)/10, 1))
+	return (value - med) / spread, med
+}
+
+// anomalyDescription normalizes a description for comparison.
+func anomalyDescription(tx models.Transaction) string {
+	return strings.ToLower(strings.TrimSpace(tx.Description))
+}
+
+// anomalyCategoryKey identifies a transaction's category, 0 for uncategorized.
+func anomalyCategoryKey(tx models.Transaction) uint {
+	if tx.CategoryID == nil {
+		return 0
+	}
+	return *tx.CategoryID
+}
+
+// transactionLabel names a transaction in anomaly messages.
+func transactionLabel(tx models.Transaction) string {
+	if desc := strings.TrimSpace(tx.Description); desc != "" {
+		return desc
+	}
+	return "Transaction"
+}
+
+// detectAnomalies flags what is unusual about the spending dated in [start, end]. transactions are the
+// user's expenses and refunds from anomalyHistoryMonths before start's month through end; everything before
+// a transaction is its history. Four kinds are found, in this order:
+//   - outlier: an expense whose robust z-score against earlier expenses in its category exceeds the threshold
+//   - first_large_purchase: an expense from a description never seen before, larger than 95% of earlier expenses
+//   - duplicate_charge: expenses on the same day with the same amount and description
+//   - category_month: a month whose net spending in a category scores above the threshold against its
+//     earlier months
+func detectAnomalies(transactions []models.Transaction, start, end time.Time) []models.Anomaly {
+	sorted := append([]models.Transaction(nil), transactions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].TransactionDate.Equal(sorted[j].TransactionDate) {
+			return sorted[i].TransactionDate.Before(sorted[j].TransactionDate)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	inPeriod := func(tx models.Transaction) bool {
+		date := dateOnly(tx.TransactionDate)
+		return !date.Before(start) && !date.After(end)
+	}
+
+	var outliers, firstLarge []models.Anomaly
+	categoryAmounts := make(map[uint][]float64)
+	var allAmounts []float64
+	seen := make(map[string]bool)
+	for _, tx := range sorted {
+		if tx.Type != models.TransactionTypeExpense {
+			continue
+		}
+		key, desc := anomalyCategoryKey(tx), anomalyDescription(tx)
+
+		if inPeriod(tx) {
+			if history := categoryAmounts[key]; len(history) >= anomalyMinHistory {
+				if score, med := robustScore(tx.Amount, history); score > anomalyScoreThreshold {
+					outliers = append(outliers, models.Anomaly{
+						Kind:           models.AnomalyKindOutlier,
+						TransactionIDs: []uint{tx.ID},
+						CategoryID:     tx.CategoryID,
+						Date:           tx.TransactionDate.Format("2006-01-02"),
+						Amount:         roundCents(tx.Amount),
+						Expected:       roundCents(med),
+						Score:          roundCents(score),
+						Message: fmt.Sprintf("%s: %.2f is far above the usual %.2f for this category",
+							transactionLabel(tx), tx.Amount, med),
+					})
+				}
+			}
+			if desc != "" && !seen[desc] && len(allAmounts) >= anomalyMinHistory &&
+				tx.Amount > percentile(allAmounts, anomalyLargePercentile) {
+				firstLarge = append(firstLarge, models.Anomaly{
+					Kind:           models.AnomalyKindFirstLargePurchase,
+					TransactionIDs: []uint{tx.ID},
+					CategoryID:     tx.CategoryID,
+					Date:           tx.TransactionDate.Format("2006-01-02"),
+					Amount:         roundCents(tx.Amount),
+					Expected:       roundCents(median(allAmounts)),
+					Message:        fmt.Sprintf("%s: first purchase here, and a large one at %.2f", transactionLabel(tx), tx.Amount),
+				})
+			}
+		}
+
+		categoryAmounts[key] = append(categoryAmounts[key], tx.Amount)
+		allAmounts = append(allAmounts, tx.Amount)
+		if desc != "" {
+			seen[desc] = true
+		}
+	}
+
+	anomalies := append(outliers, firstLarge...)
+	anomalies = append(anomalies, duplicateCharges(sorted, inPeriod)...)
+	return append(anomalies, categoryMonthAnomalies(sorted, start, end)...)
+}
+
+// duplicateCharges groups the expenses accepted by inPeriod that share a day, amount and description.
+// transactions must be in date order.
+func duplicateCharges(transactions []models.Transaction, inPeriod func(models.Transaction) bool) []models.Anomaly {
+	type chargeKey struct {
+		date  string
+		cents int64
+		desc  string
+	}
+	groups := make(map[chargeKey][]models.Transaction)
+	var order []chargeKey
+	for _, tx := range transactions {
+		if tx.Type != models.TransactionTypeExpense || !inPeriod(tx) {
+			continue
+		}
+		key := chargeKey{tx.TransactionDate.Format("2006-01-02"), int64(math.Round(tx.Amount * 100)), anomalyDescription(tx)}
+		if len(groups[key]) == 0 {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], tx)
+	}
+
+	var anomalies []models.Anomaly
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		ids := make([]uint, len(group))
+		for i, tx := range group {
+			ids[i] = tx.ID
+		}
+		anomalies = append(anomalies, models.Anomaly{
+			Kind:           models.AnomalyKindDuplicate,
+			TransactionIDs: ids,
+			CategoryID:     group[0].CategoryID,
+			Date:           key.date,
+			Amount:         roundCents(group[0].Amount),
+			Expected:       roundCents(group[0].Amount),
+			Message: fmt.Sprintf("%s: charged %d times on %s for %.2f",
+				transactionLabel(group[0]), len(group), key.date, group[0].Amount),
+		})
+	}
+	return anomalies
+}
+
+// categoryMonthAnomalies scores each category's net spending in the months of [start, end] against its
+// earlier months. Categories are taken in ascending ID order, uncategorized first.
+func categoryMonthAnomalies(transactions []models.Transaction, start, end time.Time) []models.Anomaly {
+	firstMonth := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+	historyStart := firstMonth.AddDate(0, -anomalyHistoryMonths, 0)
+	periodMonths := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month()) + 1
+	months := anomalyHistoryMonths + periodMonths
+
+	// Only categories spent in during the period are scored
+	var keys []uint
+	found := make(map[uint]bool)
+	for _, tx := range transactions {
+		date := dateOnly(tx.TransactionDate)
+		if date.Before(start) || date.After(end) || found[anomalyCategoryKey(tx)] {
+			continue
+		}
+		found[anomalyCategoryKey(tx)] = true
+		keys = append(keys, anomalyCategoryKey(tx))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var anomalies []models.Anomaly
+	for _, key := range keys {
+		series := monthlySeries(transactions, historyStart, months, func(tx models.Transaction) bool {
+			return anomalyCategoryKey(tx) == key
+		})
+		// The series starts at the category's first month with spending
+		offset := months - len(series)
+		for i := anomalyHistoryMonths; i < months; i++ {
+			history := series[:max(i-offset, 0)]
+			if len(history) < anomalyMinMonths {
+				continue
+			}
+			amount := series[i-offset]
+			score, med := robustScore(amount, history)
+			if score <= anomalyScoreThreshold {
+				continue
+			}
+			var categoryID *uint
+			if key != 0 {
+				id := key
+				categoryID = &id
+			}
+			month := historyStart.AddDate(0, i, 0).Format("2006-01")
+			anomalies = append(anomalies, models.Anomaly{
+				Kind:       models.AnomalyKindCategoryMonth,
+				CategoryID: categoryID,
+				Month:      month,
+				Amount:     roundCents(amount),
+				Expected:   roundCents(med),
+				Score:      roundCents(score),
+				Message:    fmt.Sprintf("Spending of %.2f in %s is far above this category's usual %.2f a month", amount, month, med),
+			})
+		}
+	}
+	return anomalies
+}
+
+// loadAnomalies detects the anomalies of the user's spending in [start, end].
+func loadAnomalies(userID uint, start, end time.Time) ([]models.Anomaly, error) {
+	historyStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location()).AddDate(0, -anomalyHistoryMonths, 0)
+	transactions, err := loadSpendingHistory(userID, nil, historyStart, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	return detectAnomalies(transactions, start, end), nil
+}
+
+// AnomalyListener receives the anomalies a newly created transaction raises. The notification system
+// registers one to tell the user.
+type AnomalyListener func(userID uint, anomalies []models.Anomaly, log *zap.Logger)
+
+var anomalyListeners []AnomalyListener
+
+// OnAnomaly registers listener for the anomalies of new transactions. Register listeners at startup.
+func OnAnomaly(listener AnomalyListener) {
+	anomalyListeners = append(anomalyListeners, listener)
+}
+
+// anomalyChecks bounds how many transaction anomaly checks run at once; each loads a year of history.
+var anomalyChecks = make(chan struct{}, 4)
+
+// reportTransactionAnomalies checks a newly created transaction against the user's history in the
+// background and passes the anomalies involving it to the listeners. Nothing is checked while no one listens.
+func reportTransactionAnomalies(tx models.Transaction, log *zap.Logger) {
+	if len(anomalyListeners) == 0 || tx.Type != models.TransactionTypeExpense {
+		return
+	}
+
+	// Scoring loads the user's spending history; the request that created the transaction shouldn't wait on it
+	go func() {
+		anomalyChecks <- struct{}{}
+		defer func() { <-anomalyChecks }()
+		checkTransactionAnomalies(tx, log)
+	}()
+}
+
+// checkTransactionAnomalies passes the anomalies involving tx to the listeners.
+func checkTransactionAnomalies(tx models.Transaction, log *zap.Logger) {
+	date := dateOnly(tx.TransactionDate)
+	anomalies, err := loadAnomalies(tx.UserID, date, date)
+	if err != nil {
+		log.Warn("Failed to check transaction for anomalies", zap.Error(err))
+		return
+	}
+
+	var found []models.Anomaly
+	for _, anomaly := range anomalies {
+		for _, id := range anomaly.TransactionIDs {
+			if id == tx.ID {
+				found = append(found, anomaly)
+				break
+			}
+		}
+	}
+	if len(found) == 0 {
+		return
+	}
+	for _, listener := range anomalyListeners {
+		listener(tx.UserID, found, log)
+	}
+}
+
+// GetSpendingAnomalies lists what is unusual about the user's spending between the optional startDate and
+// endDate query params (YYYY-MM-DD, default the current month so far): outlier transactions and
+// category-months, first-time large purchases and duplicate-looking charges.
+func GetSpendingAnomalies(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	start, end, err := analyticsRange(c, 1)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if db.DB == nil {
+		log.Error("Database connection not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	anomalies, err := loadAnomalies(userID, start, end)
+	if err != nil {
+		log.Error("Failed to detect anomalies", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not detect anomalies"})
+		return
+	}
+	if anomalies == nil {
+		anomalies = []models.Anomaly{}
+	}
+
+	c.JSON(http.StatusOK, models.AnomalyReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Anomalies: anomalies,
+	})
+}
+
+// Test helper functions - exports private functions for testing
+// These are only used by tests and do not affect normal operation
+
+// TestableDetectAnomalies is a test-friendly version of detectAnomalies
+func TestableDetectAnomalies(transactions []models.Transaction, start, end time.Time) []models.Anomaly {
+	return detectAnomalies(transactions, start, end)
+}
@@ -182,6 +182,8 @@ func ResetPasswordHandler(c *gin.Context) {
 	}
 
 	log.Info("User password reset successfully", zap.Uint("userID", user.ID))
+	notify(log, user.ID, models.NotificationTypeSecurity, models.NotificationSeverityWarning, "Your password was changed",
+		"Your FinTrack password was just reset. If this wasn't you, reset it again and check your account.", nil)
 	c.JSON(http.StatusOK, gin.H{"message": "Password reset successful"})
 }
 
@@ -363,6 +365,11 @@ type UpdateProfileRequest struct {
 	Theme                string `json:"theme,omitempty"`
 }
 
+// profileComplete reports whether the user has filled in their name and phone number.
+func profileComplete(user models.User) bool {
+	return user.FirstName != "" && user.LastName != "" && user.PhoneNumber != ""
+}
+
 // UpdateProfileHandler updates the user profile
 func UpdateProfileHandler(c *gin.Context) {
 	logger := c.MustGet("logger").(*zap.Logger)
@@ -415,6 +422,15 @@ func UpdateProfileHandler(c *gin.Context) {
 		return
 	}
 
+	if profileComplete(user) {
+		publishGamificationEvent(models.GamificationEvent{
+			UserID: userID,
+			Type:   models.EventProfileCompleted,
+			Key:    "profile",
+			Reason: "Completed your profile",
+		}, logger)
+	}
+
 	profile := models.ProfileResponse{
 		Username:             user.Username,
 		Email:                user.Email,
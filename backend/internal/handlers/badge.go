@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// BadgeRequest is a badge of the catalog as admins create or replace it. Rule is a BadgeRule; without
+// one the badge is awarded at Threshold total points.
+type BadgeRequest struct {
+	Name        string          `json:"name" binding:"required,max=50"`
+	Description string          `json:"description" binding:"required,max=255"`
+	ImageURL    string          `json:"imageUrl" binding:"max=255"`
+	Category    string          `json:"category" binding:"required,max=50"`
+	Threshold   int             `json:"threshold" binding:"gte=0"`
+	Rule        json.RawMessage `json:"rule"`
+}
+
+// bindBadgeRequest binds the request body and checks its rule, answering 400 when either is invalid.
+func bindBadgeRequest(c *gin.Context, log *zap.Logger) (BadgeRequest, bool) {
+	var req BadgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid badge data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+
+	if len(req.Rule) == 0 || string(req.Rule) == "null" {
+		req.Rule = nil
+		return req, true
+	}
+	if _, err := ParseBadgeRule(req.Rule); err != nil {
+		log.Warn("Invalid badge rule", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid badge rule: " + err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+// badgeNameTaken reports whether a badge other than id already has name.
+func badgeNameTaken(name string, id uint) (bool, error) {
+	var clash int64
+	err := db.DB.Model(&models.Badge{}).Where("name = ? AND id <> ?", name, id).Count(&clash).Error
+	return clash > 0, err
+}
+
+// CreateBadge adds a badge to the catalog (admin only). Users who already meet its rule are awarded it
+// with their next gamification event.
+func CreateBadge(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+
+	req, ok := bindBadgeRequest(c, log)
+	if !ok {
+		return
+	}
+
+	taken, err := badgeNameTaken(req.Name, 0)
+	if err != nil {
+		log.Error("Database error checking badge name", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "A badge with this name already exists"})
+		return
+	}
+
+	badge := models.Badge{
+		Name:        req.Name,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+		Category:    req.Category,
+		Threshold:   req.Threshold,
+		Rule:        req.Rule,
+	}
+	if err := db.DB.Create(&badge).Error; err != nil {
+		log.Error("Failed to create badge", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create badge"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Badge created successfully", "badge": badge})
+}
+
+// UpdateBadge replaces a badge of the catalog (admin only). Badges already awarded are kept, even if the
+// holder no longer meets the new rule.
+func UpdateBadge(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+
+	var badge models.Badge
+	if err := db.DB.First(&badge, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Badge not found"})
+			return
+		}
+		log.Error("Failed to fetch badge", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	req, ok := bindBadgeRequest(c, log)
+	if !ok {
+		return
+	}
+
+	taken, err := badgeNameTaken(req.Name, badge.ID)
+	if err != nil {
+		log.Error("Database error checking badge name", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "A badge with this name already exists"})
+		return
+	}
+
+	badge.Name = req.Name
+	badge.Description = req.Description
+	badge.ImageURL = req.ImageURL
+	badge.Category = req.Category
+	badge.Threshold = req.Threshold
+	badge.Rule = req.Rule
+	if err := db.DB.Save(&badge).Error; err != nil {
+		log.Error("Failed to update badge", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update badge"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Badge updated successfully", "badge": badge})
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// maxBadgeRuleDepth bounds the nesting of all/any in a badge rule.
+const maxBadgeRuleDepth = 5
+
+// badgeRuleOps compares a statistic with a rule's value.
+var badgeRuleOps = map[string]func(stat, value float64) bool{
+	">=": func(stat, value float64) bool { return stat >= value },
+	">":  func(stat, value float64) bool { return stat > value },
+	"<=": func(stat, value float64) bool { return stat <= value },
+	"<":  func(stat, value float64) bool { return stat < value },
+	"==": func(stat, value float64) bool { return stat == value },
+}
+
+// badgeStatNames are the statistics a rule may compare.
+var badgeStatNames = map[string]bool{
+	models.BadgeStatTotalPoints:        true,
+	models.BadgeStatTransactionCount:   true,
+	models.BadgeStatBudgetCount:        true,
+	models.BadgeStatCategoriesBudgeted: true,
+	models.BadgeStatTrackingStreak:     true,
+	models.BadgeStatMonthsUnderBudget:  true,
+	models.BadgeStatSavingsRate:        true,
+}
+
+// validateBadgeRule checks that rule is either a comparison of a known statistic or a non-empty all/any.
+func validateBadgeRule(rule models.BadgeRule, depth int) error {
+	if depth > maxBadgeRuleDepth {
+		return fmt.Errorf("rule nests deeper than %d levels", maxBadgeRuleDepth)
+	}
+
+	kinds := 0
+	if rule.Stat != "" {
+		kinds++
+	}
+	if rule.All != nil {
+		kinds++
+	}
+	if rule.Any != nil {
+		kinds++
+	}
+	if kinds != 1 {
+		return fmt.Errorf("rule must have exactly one of stat, all or any")
+	}
+
+	if rule.Stat != "" {
+		if !badgeStatNames[rule.Stat] {
+			return fmt.Errorf("unknown statistic %q", rule.Stat)
+		}
+		if badgeRuleOps[rule.Op] == nil {
+			return fmt.Errorf("unknown operator %q", rule.Op)
+		}
+		return nil
+	}
+
+	nested := rule.All
+	if rule.Any != nil {
+		nested = rule.Any
+	}
+	if len(nested) == 0 {
+		return fmt.Errorf("all and any need at least one rule")
+	}
+	for _, r := range nested {
+		if err := validateBadgeRule(r, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseBadgeRule decodes and validates a badge rule stored as JSON.
+func ParseBadgeRule(raw json.RawMessage) (models.BadgeRule, error) {
+	var rule models.BadgeRule
+	if err := json.Unmarshal(raw, &rule); err != nil {
+		return rule, fmt.Errorf("invalid rule JSON: %w", err)
+	}
+	return rule, validateBadgeRule(rule, 1)
+}
+
+// badgeRule returns the rule badge is awarded on: its own, or reaching its points threshold without one.
+func badgeRule(badge models.Badge) (models.BadgeRule, error) {
+	if len(badge.Rule) == 0 || string(badge.Rule) == "null" {
+		return models.BadgeRule{Stat: models.BadgeStatTotalPoints, Op: ">=", Value: float64(badge.Threshold)}, nil
+	}
+	return ParseBadgeRule(badge.Rule)
+}
+
+// evaluateBadgeRule reports whether rule holds for the statistics. all and any stop at the first rule
+// deciding them, so statistics are only loaded when needed.
+func evaluateBadgeRule(rule models.BadgeRule, stat func(name string) (float64, error)) (bool, error) {
+	switch {
+	case rule.All != nil:
+		for _, r := range rule.All {
+			ok, err := evaluateBadgeRule(r, stat)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case rule.Any != nil:
+		for _, r := range rule.Any {
+			ok, err := evaluateBadgeRule(r, stat)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+
+	value, err := stat(rule.Stat)
+	if err != nil {
+		return false, err
+	}
+	return badgeRuleOps[rule.Op](value, rule.Value), nil
+}
+
+// badgeStats loads the statistics of one user as rules ask for them, each at most once.
+type badgeStats struct {
+	userID uint
+	today  time.Time
+	values map[string]float64
+}
+
+func newBadgeStats(userID uint, asOf time.Time) *badgeStats {
+	return &badgeStats{userID: userID, today: dateOnly(asOf), values: make(map[string]float64)}
+}
+
+// get returns statistic name of the user.
+func (s *badgeStats) get(name string) (float64, error) {
+	if value, ok := s.values[name]; ok {
+		return value, nil
+	}
+
+	var value float64
+	var err error
+	switch name {
+	case models.BadgeStatTotalPoints:
+		err = db.DB.Model(&models.UserPoints{}).Select("COALESCE(SUM(points), 0)").Where("user_id = ?", s.userID).Scan(&value).Error
+	case models.BadgeStatTransactionCount:
+		var count int64
+		err = db.DB.Model(&models.Transaction{}).Where("user_id = ?", s.userID).Count(&count).Error
+		value = float64(count)
+	case models.BadgeStatBudgetCount:
+		var count int64
+		err = db.DB.Model(&models.Budget{}).Where("user_id = ?", s.userID).Count(&count).Error
+		value = float64(count)
+	case models.BadgeStatCategoriesBudgeted:
+		var count int64
+		err = db.DB.Model(&models.Budget{}).Where("user_id = ? AND category_id IS NOT NULL", s.userID).
+			Distinct("category_id").Count(&count).Error
+		value = float64(count)
+	case models.BadgeStatTrackingStreak:
+		var days []time.Time
+		err = db.DB.Model(&models.Transaction{}).
+			Where("user_id = ? AND type = ?", s.userID, models.TransactionTypeExpense).
+			Distinct().Order("transaction_date").Pluck("transaction_date", &days).Error
+		value = float64(longestDailyStreak(days))
+	case models.BadgeStatMonthsUnderBudget:
+		var budgets []models.Budget
+		err = db.DB.Select("end_date", "remaining_amount").
+			Where("user_id = ? AND end_date < ?", s.userID, s.today).Find(&budgets).Error
+		value = float64(longestMonthsUnderBudget(budgets))
+	case models.BadgeStatSavingsRate:
+		lastMonth := time.Date(s.today.Year(), s.today.Month()-1, 1, 0, 0, 0, 0, s.today.Location())
+		var summary models.CashFlowSummary
+		summary, err = getCashFlowSummary(s.userID, lastMonth, lastMonth.AddDate(0, 1, -1))
+		if rate := savingsRate(summary); rate != nil {
+			value = *rate
+		}
+	default:
+		return 0, fmt.Errorf("unknown statistic %q", name)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	s.values[name] = value
+	return value, nil
+}
+
+// longestDailyStreak returns the longest run of consecutive days among days, given in ascending order.
+func longestDailyStreak(days []time.Time) int {
+	longest, current := 0, 0
+	var previous time.Time
+	for i, day := range days {
+		day = dateOnly(day)
+		switch {
+		case i > 0 && day.Equal(previous):
+			continue
+		case i > 0 && day.Equal(previous.AddDate(0, 0, 1)):
+			current++
+		default:
+			current = 1
+		}
+		previous = day
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+// longestMonthsUnderBudget returns the longest run of consecutive calendar months in which budgets ended and
+// all of them ended under their limit.
+func longestMonthsUnderBudget(budgets []models.Budget) int {
+	// Month index => whether every budget ending in it stayed under its limit
+	months := make(map[int]bool)
+	first, last := 0, 0
+	for i, budget := range budgets {
+		month := budget.EndDate.Year()*12 + int(budget.EndDate.Month()) - 1
+		under, seen := months[month]
+		months[month] = (under || !seen) && budget.RemainingAmount >= 0
+		if i == 0 || month < first {
+			first = month
+		}
+		if i == 0 || month > last {
+			last = month
+		}
+	}
+
+	longest, current := 0, 0
+	for month := first; len(budgets) > 0 && month <= last; month++ {
+		if months[month] {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
+
+// Test helper functions - exports private functions for testing
+// These are only used by tests and do not affect normal operation
+
+// TestableEvaluateBadgeRule is a test-friendly version of evaluateBadgeRule, reading statistics from stats
+func TestableEvaluateBadgeRule(rule models.BadgeRule, stats map[string]float64) (bool, error) {
+	return evaluateBadgeRule(rule, func(name string) (float64, error) {
+		value, ok := stats[name]
+		if !ok {
+			return 0, fmt.Errorf("statistic %q not loaded", name)
+		}
+		return value, nil
+	})
+}
+
+// TestableLongestDailyStreak is a test-friendly version of longestDailyStreak
+func TestableLongestDailyStreak(days []time.Time) int {
+	return longestDailyStreak(days)
+}
+
+// TestableLongestMonthsUnderBudget is a test-friendly version of longestMonthsUnderBudget
+func TestableLongestMonthsUnderBudget(budgets []models.Budget) int {
+	return longestMonthsUnderBudget(budgets)
+}
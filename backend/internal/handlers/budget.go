@@ -17,11 +17,18 @@ type CreateBudgetRequest struct {
 	CategoryID  *uint   `json:"categoryId"` // nullable => global if null
 	LimitAmount float64 `json:"limitAmount" binding:"required,gt=0"`
 	StartDate   string  `json:"startDate" binding:"required"`
-	EndDate     string  `json:"endDate" binding:"required"`
+	EndDate     string  `json:"endDate" binding:"required"`                                           // follows from StartDate and Recurrence for recurring budgets
+	Recurrence  string  `json:"recurrence" binding:"omitempty,oneof=weekly monthly quarterly yearly"` // empty => one-off
+	Rollover    bool    `json:"rollover"`                                                             // carry leftover/overspend into the next period
+
+	AlertPercent *float64 `json:"alertPercent" binding:"omitempty,gt=0,lt=1000"` // extra alert threshold in percent of the limit
 }
 
-// recalcBudgetRemaining sums all transactions in the budget's date range & category
-// and sets remaining_amount = limit_amount - total_spent
+// netExpenseSumSQL sums spending only: expenses add, refunds subtract, income and transfers are ignored.
+const netExpenseSumSQL = "COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0)"
+
+// recalcBudgetRemaining sums all expense transactions (net of refunds) in the budget's date range & category
+// and sets remaining_amount = limit_amount + rollover_amount - total_spent
 func recalcBudgetRemaining(budget *models.Budget, log *zap.Logger) error {
 	var sumResult struct {
 		Total float64
@@ -29,29 +36,60 @@ func recalcBudgetRemaining(budget *models.Budget, log *zap.Logger) error {
 
 	//query to sum transactions in [start_date, end_date] for partcular user & specific category
 	query := db.DB.Table("transactions").
-		Select("COALESCE(SUM(amount), 0) as total").
+		Select(netExpenseSumSQL+" as total").
 		Where("user_id = ? AND transaction_date >= ? AND transaction_date <= ? AND deleted_at IS NULL",
 			budget.UserID, budget.StartDate, budget.EndDate)
 
-	if budget.CategoryID != nil {
-		query = query.Where("category_id = ?", *budget.CategoryID)
-	} else {
-		query = query.Where("category_id IS NULL")
-	}
-
-	if err := query.Scan(&sumResult).Error; err != nil {
+	if err := budgetScope(query, budget).Scan(&sumResult).Error; err != nil {
 		log.Error("Failed to sum transactions for budget recalc", zap.Error(err))
 		return err
 	}
 
-	budget.RemainingAmount = budget.LimitAmount - sumResult.Total
+	budget.RemainingAmount = budget.LimitAmount + budget.RolloverAmount - sumResult.Total
 	if err := db.DB.Save(&budget).Error; err != nil {
 		log.Error("Failed to update budget remaining_amount", zap.Error(err))
 		return err
 	}
+	alertBudgetThresholds(budget, log)
+
+	// A closed period that rolls over feeds the next one; keep the carried amount in sync
+	if budget.Rollover && budget.NextPeriodOpened {
+		return propagateRollover(budget, log)
+	}
 	return nil
 }
 
+// budgetScope limits query to the spending budget covers. A budget on a parent category also covers
+// spending in all of its subcategories; a global budget covers uncategorized spending.
+func budgetScope(query *gorm.DB, budget *models.Budget) *gorm.DB {
+	if budget.CategoryID != nil {
+		return query.Where("category_id IN ("+categorySubtreeSQL+")", *budget.CategoryID)
+	}
+	return query.Where("category_id IS NULL")
+}
+
+// propagateRollover copies budget's remaining amount into the next period of its series and recalculates it.
+func propagateRollover(budget *models.Budget, log *zap.Logger) error {
+	seriesID := budget.ID
+	if budget.SeriesID != nil {
+		seriesID = *budget.SeriesID
+	}
+
+	var next models.Budget
+	if err := db.DB.Where("series_id = ? AND period_index = ?", seriesID, budget.PeriodIndex+1).First(&next).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		log.Error("Failed to find next budget period for rollover", zap.Error(err))
+		return err
+	}
+	if next.RolloverAmount == budget.RemainingAmount {
+		return nil
+	}
+	next.RolloverAmount = budget.RemainingAmount
+	return recalcBudgetRemaining(&next, log)
+}
+
 // CreateBudget either overwrites an existing budget if (user_id, category_id, start_date, end_date)
 // matches, or creates a new record. Then we recalc remaining_amount from transactions.
 func CreateBudget(c *gin.Context) {
@@ -85,6 +123,10 @@ func CreateBudget(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be after start date"})
 		return
 	}
+	// The first period of a recurring budget ends on its recurrence boundary, so the next one follows without a gap
+	if req.Recurrence != "" {
+		_, end = budgetPeriodBounds(start, req.Recurrence, 0)
+	}
 
 	// Check for an existing budget
 	var existing models.Budget
@@ -93,11 +135,15 @@ func CreateBudget(c *gin.Context) {
 
 	if findErr == nil {
 		// Overwrite existing record
+		before := existing
 		existing.LimitAmount = req.LimitAmount
 		existing.StartDate = start
 		existing.EndDate = end
+		existing.Recurrence = req.Recurrence
+		existing.Rollover = req.Rollover
+		existing.AlertPercent = req.AlertPercent
 
-		if err := db.DB.Save(&existing).Error; err != nil {
+		if err := saveEditedBudget(before, &existing); err != nil {
 			log.Error("Failed to overwrite existing budget", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not overwrite budget"})
 			return
@@ -116,11 +162,14 @@ func CreateBudget(c *gin.Context) {
 	if errors.Is(findErr, gorm.ErrRecordNotFound) {
 		// Create new budget
 		newBudget := models.Budget{
-			UserID:      userID,
-			CategoryID:  req.CategoryID,
-			LimitAmount: req.LimitAmount,
-			StartDate:   start,
-			EndDate:     end,
+			UserID:       userID,
+			CategoryID:   req.CategoryID,
+			LimitAmount:  req.LimitAmount,
+			StartDate:    start,
+			EndDate:      end,
+			Recurrence:   req.Recurrence,
+			Rollover:     req.Rollover,
+			AlertPercent: req.AlertPercent,
 		}
 		if err := db.DB.Create(&newBudget).Error; err != nil {
 			log.Error("Failed to create new budget", zap.Error(err))
@@ -131,6 +180,12 @@ func CreateBudget(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalc budget"})
 			return
 		}
+		publishGamificationEvent(models.GamificationEvent{
+			UserID: userID,
+			Type:   models.EventBudgetCreated,
+			Key:    budgetEventKey(newBudget.ID),
+			Reason: "Created a budget",
+		}, log)
 		c.JSON(http.StatusCreated, gin.H{
 			"message": "Budget created successfully",
 			"budget":  newBudget,
@@ -197,12 +252,31 @@ func UpdateBudget(c *gin.Context) {
 		return
 	}
 
+	before := existing
 	existing.CategoryID = req.CategoryID
 	existing.LimitAmount = req.LimitAmount
 	existing.StartDate = start
 	existing.EndDate = end
+	existing.Recurrence = req.Recurrence
+	existing.Rollover = req.Rollover
+	existing.AlertPercent = req.AlertPercent
+
+	// A recurring period ends where the series schedule says, so the next period follows without a gap
+	if existing.Recurrence != "" {
+		periodEnd, err := recurringPeriodEnd(&existing)
+		if err != nil {
+			log.Error("Failed to look up budget series", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update budget"})
+			return
+		}
+		if periodEnd.Before(existing.StartDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Start date must fall within the budget's period"})
+			return
+		}
+		existing.EndDate = periodEnd
+	}
 
-	if err := db.DB.Save(&existing).Error; err != nil {
+	if err := saveEditedBudget(before, &existing); err != nil {
 		log.Error("Failed to update budget", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update budget"})
 		return
@@ -225,11 +299,24 @@ func DeleteBudget(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
 	budgetID := c.Param("id")
 
-	if err := db.DB.Where("id = ? AND user_id = ?", budgetID, userID).Delete(&models.Budget{}).Error; err != nil {
-		log.Error("Failed to delete budget", zap.Error(err))
+	var budget models.Budget
+	if err := db.DB.Where("id = ? AND user_id = ?", budgetID, userID).First(&budget).Error; err != nil {
+		log.Warn("Budget not found or unauthorized", zap.Error(err))
 		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found or could not be deleted"})
 		return
 	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&budget).Error; err != nil {
+			return err
+		}
+		return revokeGamificationPoints(tx, userID, budgetEventKey(budget.ID))
+	})
+	if err != nil {
+		log.Error("Failed to delete budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete budget"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted successfully"})
 }
 
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+	"github.com/RedShawn258/FinTrack/backend/internal/utils"
+)
+
+// budgetAlertThresholds are the shares of its limit, in percent, at which every budget alerts.
+var budgetAlertThresholds = []float64{50, 80, 100}
+
+// sendAlertEmail delivers budget alert emails; tests replace it.
+var sendAlertEmail = utils.SendMail
+
+// budgetSpending returns the budget's limit (rollover included) and what has been spent against it.
+func budgetSpending(budget *models.Budget) (limit, spent float64) {
+	limit = budget.LimitAmount + budget.RolloverAmount
+	return limit, limit - budget.RemainingAmount
+}
+
+// reachedBudgetThresholds returns the alert thresholds of budget its spending has reached, lowest first.
+func reachedBudgetThresholds(budget *models.Budget) []float64 {
+	limit, spent := budgetSpending(budget)
+	if limit <= 0 {
+		return nil
+	}
+
+	thresholds := append([]float64{}, budgetAlertThresholds...)
+	if budget.AlertPercent != nil {
+		thresholds = append(thresholds, *budget.AlertPercent)
+	}
+	sort.Float64s(thresholds)
+
+	var reached []float64
+	for i, threshold := range thresholds {
+		if i > 0 && threshold == thresholds[i-1] {
+			continue
+		}
+		if roundCents(spent) >= roundCents(limit*threshold/100) {
+			reached = append(reached, threshold)
+		}
+	}
+	return reached
+}
+
+// alertBudgetThresholds alerts the user when budget's spending has reached thresholds it hadn't alerted yet.
+// Each threshold of a period is recorded before alerting, so recalculating never alerts it again. Reaching
+// several at once sends a single alert, for the highest. Only periods still running alert: recalculating
+// one that has ended is bookkeeping, not news. Failures are only logged.
+func alertBudgetThresholds(budget *models.Budget, log *zap.Logger) {
+	if budget.EndDate.Before(dateOnly(time.Now())) {
+		return
+	}
+	reached := reachedBudgetThresholds(budget)
+	if len(reached) == 0 {
+		return
+	}
+
+	var alerted []float64
+	if err := db.DB.Model(&models.BudgetAlert{}).Where("budget_id = ?", budget.ID).Pluck("threshold", &alerted).Error; err != nil {
+		log.Warn("Failed to load budget alerts", zap.Uint("budgetID", budget.ID), zap.Error(err))
+		return
+	}
+	done := make(map[float64]bool, len(alerted))
+	for _, threshold := range alerted {
+		done[threshold] = true
+	}
+
+	// The unique index on (budget, threshold) keeps concurrent recalculations from both claiming a threshold
+	highest := 0.0
+	for _, threshold := range reached {
+		if done[threshold] {
+			continue
+		}
+		result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.BudgetAlert{BudgetID: budget.ID, Threshold: threshold})
+		if result.Error != nil {
+			log.Warn("Failed to record budget alert", zap.Uint("budgetID", budget.ID), zap.Error(result.Error))
+			return
+		}
+		if result.RowsAffected > 0 {
+			highest = threshold
+		}
+	}
+	if highest == 0 {
+		return
+	}
+	sendBudgetAlert(budget, highest, log)
+}
+
+// budgetAlertTermsChanged reports whether an edit changed what the budget's alerts measure: its limit, period,
+// category or custom threshold.
+func budgetAlertTermsChanged(before, after *models.Budget) bool {
+	samePercent := before.AlertPercent == nil && after.AlertPercent == nil ||
+		before.AlertPercent != nil && after.AlertPercent != nil && *before.AlertPercent == *after.AlertPercent
+	sameCategory := before.CategoryID == nil && after.CategoryID == nil ||
+		before.CategoryID != nil && after.CategoryID != nil && *before.CategoryID == *after.CategoryID
+	return before.LimitAmount != after.LimitAmount || !before.StartDate.Equal(after.StartDate) ||
+		!before.EndDate.Equal(after.EndDate) || !samePercent || !sameCategory
+}
+
+// saveEditedBudget saves budget, edited from before. When the edit changed what its alerts measure, the
+// thresholds alerted so far no longer apply; they are cleared in the same transaction so spending alerts
+// afresh against the new terms.
+func saveEditedBudget(before models.Budget, budget *models.Budget) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(budget).Error; err != nil {
+			return err
+		}
+		if !budgetAlertTermsChanged(&before, budget) {
+			return nil
+		}
+		return tx.Where("budget_id = ?", budget.ID).Delete(&models.BudgetAlert{}).Error
+	})
+}
+
+// sendBudgetAlert posts the alert for threshold to the inbox and, when the user has notifications enabled,
+// emails it.
+func sendBudgetAlert(budget *models.Budget, threshold float64, log *zap.Logger) {
+	name := "Overall"
+	if budget.CategoryID != nil {
+		var category models.Category
+		if err := db.DB.Select("name").First(&category, *budget.CategoryID).Error; err == nil {
+			name = category.Name
+		}
+	}
+
+	var user models.User
+	if err := db.DB.Select("id", "email", "currency", "notifications_enabled").First(&user, budget.UserID).Error; err != nil {
+		log.Warn("Failed to load user for budget alert", zap.Uint("userID", budget.UserID), zap.Error(err))
+		return
+	}
+
+	limit, spent := budgetSpending(budget)
+	title := fmt.Sprintf("%s budget at %g%%", name, threshold)
+	if threshold == 100 {
+		title = fmt.Sprintf("%s budget used up", name)
+	}
+	severity := models.NotificationSeverityInfo
+	switch {
+	case threshold >= 100:
+		severity = models.NotificationSeverityCritical
+	case threshold >= 80:
+		severity = models.NotificationSeverityWarning
+	}
+	message := fmt.Sprintf("You have spent %.2f %s of your %.2f %s %s budget for %s to %s.",
+		roundCents(spent), user.Currency, roundCents(limit), user.Currency, name,
+		budget.StartDate.Format("Jan 2"), budget.EndDate.Format("Jan 2, 2006"))
+
+	notify(log, budget.UserID, models.NotificationTypeBudget, severity, title, message, map[string]interface{}{
+		"budgetId":  budget.ID,
+		"threshold": threshold,
+		"spent":     roundCents(spent),
+		"limit":     roundCents(limit),
+	})
+
+	if !user.NotificationsEnabled || user.Email == "" {
+		return
+	}
+	// SMTP is slow; the recalculation that crossed the threshold shouldn't wait on it
+	go func() {
+		body := fmt.Sprintf("<p>%s</p><p>Open FinTrack to review your spending.</p>", html.EscapeString(message))
+		if err := sendAlertEmail(user.Email, "FinTrack: "+title, body); err != nil {
+			log.Warn("Failed to email budget alert", zap.Uint("userID", user.ID), zap.Error(err))
+		}
+	}()
+}
+
+// Test helper functions - exports private functions for testing
+// These are only used by tests and do not affect normal operation
+
+// TestableReachedBudgetThresholds is a test-friendly version of reachedBudgetThresholds
+func TestableReachedBudgetThresholds(budget models.Budget) []float64 {
+	return reachedBudgetThresholds(&budget)
+}
+
+// TestableAlertBudgetThresholds is a test-friendly version of alertBudgetThresholds
+func TestableAlertBudgetThresholds(budget models.Budget, log *zap.Logger) {
+	alertBudgetThresholds(&budget, log)
+}
+
+// TestableSetAlertMailer replaces the budget alert mailer, returning a function restoring the real one
+func TestableSetAlertMailer(send func(to, subject, body string) error) func() {
+	original := sendAlertEmail
+	sendAlertEmail = send
+	return func() { sendAlertEmail = original }
+}
+
+// TestableBudgetAlertTermsChanged is a test-friendly version of budgetAlertTermsChanged
+func TestableBudgetAlertTermsChanged(before, after models.Budget) bool {
+	return budgetAlertTermsChanged(&before, &after)
+}
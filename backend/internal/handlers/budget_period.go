@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// budgetPeriodStart returns the start of period k of a recurring budget whose first period starts on anchor.
+// Periods are computed from the anchor so month-end starts never drift.
+func budgetPeriodStart(anchor time.Time, recurrence string, k int) time.Time {
+	switch recurrence {
+	case models.BudgetRecurrenceWeekly:
+		return anchor.AddDate(0, 0, 7*k)
+	case models.BudgetRecurrenceQuarterly:
+		return addMonthsClamped(anchor, 3*k)
+	case models.BudgetRecurrenceYearly:
+		return addMonthsClamped(anchor, 12*k)
+	default:
+		return addMonthsClamped(anchor, k)
+	}
+}
+
+// budgetPeriodBounds returns the inclusive [start, end] dates of period k.
+func budgetPeriodBounds(anchor time.Time, recurrence string, k int) (time.Time, time.Time) {
+	start := budgetPeriodStart(anchor, recurrence, k)
+	end := budgetPeriodStart(anchor, recurrence, k+1).AddDate(0, 0, -1)
+	return start, end
+}
+
+// seriesAnchor returns the start date of the first period in prev's series.
+func seriesAnchor(prev *models.Budget) (time.Time, error) {
+	if prev.SeriesID == nil {
+		return prev.StartDate, nil
+	}
+	var root models.Budget
+	if err := db.DB.Unscoped().Select("start_date").First(&root, *prev.SeriesID).Error; err != nil {
+		return time.Time{}, err
+	}
+	return root.StartDate, nil
+}
+
+// recurringPeriodEnd returns the end date the series schedule gives budget's period.
+func recurringPeriodEnd(budget *models.Budget) (time.Time, error) {
+	anchor, err := seriesAnchor(budget)
+	if err != nil {
+		return time.Time{}, err
+	}
+	_, end := budgetPeriodBounds(anchor, budget.Recurrence, budget.PeriodIndex)
+	return end, nil
+}
+
+// openNextBudgetPeriod closes prev and creates the period after it, carrying prev's remaining amount
+// over when Rollover is set. It is idempotent: if the next period already exists it is returned as is,
+// even when it was deleted. A deleted period is skipped over rather than ending the series: the period
+// after it is still opened, with nothing carried over.
+func openNextBudgetPeriod(prev *models.Budget, log *zap.Logger) (*models.Budget, error) {
+	deleted := prev.DeletedAt.Valid
+
+	// Settle the closing period first so the carried amount is current
+	if !deleted {
+		if err := recalcBudgetRemaining(prev, log); err != nil {
+			return nil, err
+		}
+	}
+
+	anchor, err := seriesAnchor(prev)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesID := prev.ID
+	if prev.SeriesID != nil {
+		seriesID = *prev.SeriesID
+	}
+	nextIndex := prev.PeriodIndex + 1
+	start, end := budgetPeriodBounds(anchor, prev.Recurrence, nextIndex)
+
+	var next models.Budget
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var locked models.Budget
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, prev.ID).Error; err != nil {
+			return err
+		}
+		if locked.NextPeriodOpened {
+			return tx.Unscoped().Where("series_id = ? AND period_index = ?", seriesID, nextIndex).First(&next).Error
+		}
+
+		next = models.Budget{
+			UserID:       locked.UserID,
+			CategoryID:   locked.CategoryID,
+			LimitAmount:  locked.LimitAmount,
+			StartDate:    start,
+			EndDate:      end,
+			Recurrence:   locked.Recurrence,
+			Rollover:     locked.Rollover,
+			SeriesID:     &seriesID,
+			PeriodIndex:  nextIndex,
+			AlertPercent: locked.AlertPercent,
+		}
+		if locked.Rollover && !deleted {
+			next.RolloverAmount = prev.RemainingAmount
+		}
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&locked).Update("next_period_opened", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	prev.NextPeriodOpened = true
+
+	if next.DeletedAt.Valid {
+		return &next, nil
+	}
+	if !deleted && prev.RemainingAmount >= 0 {
+		publishGamificationEvent(models.GamificationEvent{
+			UserID: prev.UserID,
+			Type:   models.EventBudgetPeriodClosedUnderLimit,
+			Key:    fmt.Sprintf("budget_period:%d", prev.ID),
+			Reason: "Stayed under budget",
+		}, log)
+	}
+
+	if err := recalcBudgetRemaining(&next, log); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// OpenDueBudgetPeriods opens the next period of every recurring budget whose current period ended before asOf.
+// If the server was down for several periods, each missed period is opened in turn so rollover still chains.
+// Deleted periods count too, so deleting the current period does not end the series; setting its
+// recurrence to none does. It returns the number of periods opened.
+func OpenDueBudgetPeriods(asOf time.Time, logger *zap.Logger) (int, error) {
+	today := dateOnly(asOf)
+
+	var due []models.Budget
+	if err := db.DB.Unscoped().Where("recurrence <> '' AND next_period_opened = ? AND end_date < ?", false, today).Find(&due).Error; err != nil {
+		logger.Error("Failed to fetch budgets due for a new period", zap.Error(err))
+		return 0, err
+	}
+
+	opened := 0
+	for i := range due {
+		current := &due[i]
+		for current.EndDate.Before(today) {
+			next, err := openNextBudgetPeriod(current, logger)
+			if err != nil {
+				logger.Error("Failed to open next budget period",
+					zap.Error(err),
+					zap.Uint("budgetID", current.ID),
+					zap.Uint("userID", current.UserID))
+				break
+			}
+			opened++
+			current = next
+		}
+	}
+	return opened, nil
+}
+
+// GetBudgetHistory lists every period of the recurring budget series that :id belongs to, oldest first.
+func GetBudgetHistory(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+	budgetID := c.Param("id")
+
+	var budget models.Budget
+	if err := db.DB.Where("id = ? AND user_id = ?", budgetID, userID).First(&budget).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+			return
+		}
+		log.Error("Failed to fetch budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	rootID := budget.ID
+	if budget.SeriesID != nil {
+		rootID = *budget.SeriesID
+	}
+
+	var periods []models.Budget
+	if err := db.DB.Where("user_id = ? AND (id = ? OR series_id = ?)", userID, rootID, rootID).
+		Order("period_index").Find(&periods).Error; err != nil {
+		log.Error("Failed to fetch budget history", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch budget history"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budgets": periods})
+}
+
+// TestableBudgetPeriodBounds is a test-friendly version of budgetPeriodBounds
+func TestableBudgetPeriodBounds(anchor time.Time, recurrence string, k int) (time.Time, time.Time) {
+	return budgetPeriodBounds(anchor, recurrence, k)
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// budgetBaselineMonths is how many whole months before a budget period feed its baseline forecast.
+const budgetBaselineMonths = 12
+
+// averageMonthDays converts a monthly forecast into a daily rate.
+const averageMonthDays = 365.25 / 12
+
+// daysBetween returns the number of calendar days from a to b, both dates.
+func daysBetween(a, b time.Time) int {
+	return int(math.Round(dateOnly(b).Sub(dateOnly(a)).Hours() / 24))
+}
+
+// roundCents rounds an amount to 2 decimal places.
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// pendingOccurrences returns the dates of the occurrences of rt not booked yet that fall in [from, to].
+func pendingOccurrences(rt models.RecurringTransaction, from, to time.Time) []time.Time {
+	var dates []time.Time
+	for n := rt.OccurrencesGenerated; rt.Count == nil || n < *rt.Count; n++ {
+		date := occurrenceAt(rt.StartDate, rt.Frequency, rt.Interval, n)
+		if date.After(to) || (rt.EndDate != nil && date.After(*rt.EndDate)) {
+			break
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// projectBudget predicts where budget stands at the end of its period, as of the date asOf.
+// transactions is the spending in the budget's scope from budgetBaselineMonths before the period up to
+// asOf; recurring holds the active recurring transactions in scope.
+//
+// The rest of the period is projected day by day: discretionary spending at a rate that blends the
+// velocity so far with a forecast of earlier months (weighted by how much of the period has passed),
+// plus every recurring item still to come on its own date.
+func projectBudget(budget models.Budget, transactions []models.Transaction, recurring []models.RecurringTransaction, asOf time.Time) models.BudgetProjection {
+	start, end := dateOnly(budget.StartDate), dateOnly(budget.EndDate)
+	totalDays := daysBetween(start, end) + 1
+	elapsed := daysBetween(start, asOf) + 1
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if elapsed > totalDays {
+		elapsed = totalDays
+	}
+
+	projection := models.BudgetProjection{
+		BudgetID:          budget.ID,
+		CategoryID:        budget.CategoryID,
+		StartDate:         start.Format("2006-01-02"),
+		EndDate:           end.Format("2006-01-02"),
+		AsOf:              dateOnly(asOf).Format("2006-01-02"),
+		DaysElapsed:       elapsed,
+		DaysTotal:         totalDays,
+		Limit:             budget.LimitAmount + budget.RolloverAmount,
+		UpcomingRecurring: []models.ProjectedRecurringItem{},
+	}
+
+	// Spending per day so far. Recurring bills come at their own pace, so they don't count toward velocity.
+	daily := make([]float64, totalDays)
+	var history []models.Transaction
+	var discretionary float64
+	for _, tx := range transactions {
+		day := daysBetween(start, tx.TransactionDate)
+		if day < 0 {
+			history = append(history, tx)
+			continue
+		}
+		if day >= elapsed {
+			continue
+		}
+		amount := expenseAmount(tx)
+		daily[day] += amount
+		projection.Spent += amount
+		if tx.RecurringTransactionID == nil {
+			discretionary += amount
+		}
+	}
+
+	baselineStart := time.Date(start.Year(), start.Month()-budgetBaselineMonths, 1, 0, 0, 0, 0, start.Location())
+	baseline := monthlySeries(history, baselineStart, budgetBaselineMonths, func(tx models.Transaction) bool {
+		return tx.RecurringTransactionID == nil
+	})
+	weight := 1.0
+	if len(baseline) > 0 {
+		fit := runForecast("", baseline, 1)
+		projection.BaselineModel = fit.Model
+		projection.BaselineDailyRate = fit.Forecast[0] / averageMonthDays
+		weight = float64(elapsed) / float64(totalDays)
+	}
+	if elapsed > 0 {
+		projection.DailyVelocity = discretionary / float64(elapsed)
+	}
+	projection.ProjectedDailyRate = weight*projection.DailyVelocity + (1-weight)*projection.BaselineDailyRate
+
+	// Recurring items still to come. One that is already due but not booked yet is expected tomorrow.
+	if elapsed < totalDays {
+		for _, rt := range recurring {
+			amount := expenseAmount(models.Transaction{Type: rt.Type, Amount: rt.Amount})
+			for _, date := range pendingOccurrences(rt, start, end) {
+				day := daysBetween(start, date)
+				if day < elapsed {
+					day = elapsed
+				}
+				daily[day] += amount
+				projection.UpcomingRecurring = append(projection.UpcomingRecurring, models.ProjectedRecurringItem{
+					RecurringTransactionID: rt.ID,
+					Date:                   date.Format("2006-01-02"),
+					Amount:                 amount,
+					Description:            rt.Description,
+				})
+			}
+		}
+	}
+
+	var cumulative float64
+	for day := range daily {
+		cumulative += daily[day]
+		if day >= elapsed {
+			cumulative += projection.ProjectedDailyRate
+		}
+		if projection.ExceedDate == nil && roundCents(cumulative) > roundCents(projection.Limit) {
+			date := start.AddDate(0, 0, day).Format("2006-01-02")
+			projection.ExceedDate = &date
+		}
+	}
+
+	projection.Spent = roundCents(projection.Spent)
+	projection.DailyVelocity = roundCents(projection.DailyVelocity)
+	projection.BaselineDailyRate = roundCents(projection.BaselineDailyRate)
+	projection.ProjectedDailyRate = roundCents(projection.ProjectedDailyRate)
+	projection.ProjectedSpend = roundCents(cumulative)
+	projection.ProjectedRemaining = roundCents(projection.Limit - cumulative)
+	projection.Overrun = math.Max(-projection.ProjectedRemaining, 0)
+
+	switch {
+	case projection.Spent > projection.Limit:
+		projection.Status = models.BudgetStatusExceeded
+	case projection.ExceedDate != nil:
+		projection.Status = models.BudgetStatusAtRisk
+	default:
+		projection.Status = models.BudgetStatusOnTrack
+	}
+	return projection
+}
+
+// loadBudgetProjection loads the spending and recurring transactions budget covers and projects it as of asOf.
+func loadBudgetProjection(budget models.Budget, asOf time.Time) (models.BudgetProjection, error) {
+	start, end := dateOnly(budget.StartDate), dateOnly(budget.EndDate)
+	until := asOf
+	if until.After(end) {
+		until = end
+	}
+	spendingTypes := []string{models.TransactionTypeExpense, models.TransactionTypeRefund}
+
+	var transactions []models.Transaction
+	query := budgetScope(db.DB.Where("user_id = ? AND type IN ?", budget.UserID, spendingTypes), &budget)
+	baselineStart := time.Date(start.Year(), start.Month()-budgetBaselineMonths, 1, 0, 0, 0, 0, start.Location())
+	if err := query.Where("transaction_date >= ? AND transaction_date <= ?", baselineStart, until).
+		Find(&transactions).Error; err != nil {
+		return models.BudgetProjection{}, err
+	}
+
+	// A finished period has nothing left to come
+	var recurring []models.RecurringTransaction
+	if asOf.Before(end) {
+		query = budgetScope(db.DB.Where("user_id = ? AND active = ? AND type IN ?", budget.UserID, true, spendingTypes), &budget)
+		if err := query.Find(&recurring).Error; err != nil {
+			return models.BudgetProjection{}, err
+		}
+	}
+
+	return projectBudget(budget, transactions, recurring, asOf), nil
+}
+
+// projectionDate reads the optional asOf query parameter (YYYY-MM-DD), defaulting to today.
+func projectionDate(c *gin.Context) (time.Time, error) {
+	value := c.Query("asOf")
+	if value == "" {
+		return dateOnly(time.Now()), nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return dateOnly(parsed), nil
+}
+
+// GetBudgetProjection predicts whether budget :id will be exceeded before its period ends, when and by how much.
+func GetBudgetProjection(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+	budgetID := c.Param("id")
+
+	asOf, err := projectionDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asOf date"})
+		return
+	}
+
+	var budget models.Budget
+	if err := db.DB.Where("id = ? AND user_id = ?", budgetID, userID).First(&budget).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+			return
+		}
+		log.Error("Failed to fetch budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	projection, err := loadBudgetProjection(budget, asOf)
+	if err != nil {
+		log.Error("Failed to project budget", zap.Error(err), zap.Uint("budgetID", budget.ID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not project budget"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"projection": projection})
+}
+
+// GetBudgetProjections projects every budget whose period includes the asOf date (default today).
+func GetBudgetProjections(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	asOf, err := projectionDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asOf date"})
+		return
+	}
+
+	var budgets []models.Budget
+	if err := db.DB.Where("user_id = ? AND start_date <= ? AND end_date >= ?", userID, asOf, asOf).
+		Order("id").Find(&budgets).Error; err != nil {
+		log.Error("Failed to fetch active budgets", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch budgets"})
+		return
+	}
+
+	projections := []models.BudgetProjection{}
+	for _, budget := range budgets {
+		projection, err := loadBudgetProjection(budget, asOf)
+		if err != nil {
+			log.Error("Failed to project budget", zap.Error(err), zap.Uint("budgetID", budget.ID))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not project budgets"})
+			return
+		}
+		projections = append(projections, projection)
+	}
+	c.JSON(http.StatusOK, gin.H{"projections": projections})
+}
+
+// Test helper functions - exports private functions for testing
+
+// TestableProjectBudget is a test-friendly version of projectBudget
+func TestableProjectBudget(budget models.Budget, transactions []models.Transaction, recurring []models.RecurringTransaction, asOf time.Time) models.BudgetProjection {
+	return projectBudget(budget, transactions, recurring, asOf)
+}
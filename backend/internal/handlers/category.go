@@ -14,10 +14,130 @@ import (
 )
 
 type CategoryRequest struct {
-	Name string `json:"name" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	ParentID *uint  `json:"parentId"` // null => top-level
+}
+
+// CategoryNode is a category with its subcategories, as returned by GetCategories?tree=true.
+type CategoryNode struct {
+	models.Category
+	Children []*CategoryNode
+}
+
+// categorySubtreeSQL selects the id of a category and of every category below it.
+const categorySubtreeSQL = "WITH RECURSIVE subtree AS (SELECT id FROM categories WHERE id = ? " +
+	"UNION ALL SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id) SELECT id FROM subtree"
+
+// categoryAncestorsSQL selects the id of a category and of every category above it.
+const categoryAncestorsSQL = "WITH RECURSIVE ancestors AS (SELECT id, parent_id FROM categories WHERE id = ? " +
+	"UNION ALL SELECT c.id, c.parent_id FROM categories c JOIN ancestors ON c.id = ancestors.parent_id) SELECT id FROM ancestors"
+
+var (
+	errCategoryCycle     = errors.New("a category cannot be placed under itself or one of its subcategories")
+	errCategoryMergeSelf = errors.New("a category cannot be merged into itself")
+)
+
+// loadCategoryParents maps each of the user's categories to its parent.
+func loadCategoryParents(userID uint) (map[uint]*uint, error) {
+	var categories []models.Category
+	if err := db.DB.Unscoped().Select("id", "parent_id").Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	parents := make(map[uint]*uint, len(categories))
+	for _, cat := range categories {
+		parents[cat.ID] = cat.ParentID
+	}
+	return parents, nil
+}
+
+// categoryWithin reports whether id is ancestor or one of its descendants.
+func categoryWithin(id, ancestor uint, parents map[uint]*uint) bool {
+	seen := make(map[uint]bool)
+	for current := &id; current != nil && !seen[*current]; current = parents[*current] {
+		if *current == ancestor {
+			return true
+		}
+		seen[*current] = true
+	}
+	return false
+}
+
+// validateCategoryParent checks that parentID is one of the user's categories and that making it
+// the parent of categoryID (0 for a new category) would not create a cycle.
+func validateCategoryParent(userID, categoryID, parentID uint) error {
+	var parent models.Category
+	if err := db.DB.Where("id = ? AND user_id = ?", parentID, userID).First(&parent).Error; err != nil {
+		return err
+	}
+	if categoryID == 0 {
+		return nil
+	}
+	if parentID == categoryID {
+		return errCategoryCycle
+	}
+
+	parents, err := loadCategoryParents(userID)
+	if err != nil {
+		return err
+	}
+	if categoryWithin(parentID, categoryID, parents) {
+		return errCategoryCycle
+	}
+	return nil
+}
+
+// buildCategoryTree nests categories under their parents. Categories whose parent is missing
+// (e.g. deleted) are returned as roots.
+func buildCategoryTree(categories []models.Category) []*CategoryNode {
+	nodes := make(map[uint]*CategoryNode, len(categories))
+	for _, cat := range categories {
+		nodes[cat.ID] = &CategoryNode{Category: cat, Children: []*CategoryNode{}}
+	}
+
+	roots := []*CategoryNode{}
+	for _, cat := range categories {
+		node := nodes[cat.ID]
+		if cat.ParentID != nil {
+			if parent, ok := nodes[*cat.ParentID]; ok && parent != node {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// respondCategoryParentError maps a validateCategoryParent failure to a response.
+func respondCategoryParentError(c *gin.Context, log *zap.Logger, err error) {
+	switch {
+	case errors.Is(err, errCategoryCycle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+	default:
+		log.Error("Failed to check parent category", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	}
+}
+
+// categoryParentChanged reports whether moving a category from parent before to after changes its place.
+func categoryParentChanged(before, after *uint) bool {
+	return (before == nil) != (after == nil) || (before != nil && *before != *after)
+}
+
+// recalcMovedCategory recalculates the budgets whose spending a moved category counted towards, before
+// (oldAncestors, the category included) and after the move.
+func recalcMovedCategory(userID, categoryID uint, oldAncestors []uint, log *zap.Logger) {
+	newAncestors, err := categoryAncestorIDs(categoryID)
+	if err != nil {
+		log.Error("Failed to load category ancestors", zap.Error(err))
+	}
+	recalcCategoryBudgets(userID, append(oldAncestors, newAncestors...), log)
 }
 
 // CreateCategory: Overwrite if (user_id, name) already exists; else create new.
+// An existing category keeps its parent unless a new parentId is given.
 func CreateCategory(c *gin.Context) {
 	logger, _ := c.Get("logger")
 	log := logger.(*zap.Logger)
@@ -36,12 +156,32 @@ func CreateCategory(c *gin.Context) {
 	err := db.DB.Where("user_id = ? AND name = ?", userID, name).First(&existing).Error
 	if err == nil {
 		// Overwrite existing
+		var affected []uint
+		moved := false
+		if req.ParentID != nil {
+			if err := validateCategoryParent(userID, existing.ID, *req.ParentID); err != nil {
+				respondCategoryParentError(c, log, err)
+				return
+			}
+			if categoryParentChanged(existing.ParentID, req.ParentID) {
+				moved = true
+				if affected, err = categoryAncestorIDs(existing.ID); err != nil {
+					log.Error("Failed to load category ancestors", zap.Error(err))
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+					return
+				}
+			}
+			existing.ParentID = req.ParentID
+		}
 		existing.Name = name
 		if saveErr := db.DB.Save(&existing).Error; saveErr != nil {
 			log.Error("Failed to update category", zap.Error(saveErr))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update category"})
 			return
 		}
+		if moved {
+			recalcMovedCategory(userID, existing.ID, affected, log)
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"message":  "Category already exists, overwriting.",
 			"category": existing,
@@ -50,9 +190,16 @@ func CreateCategory(c *gin.Context) {
 	}
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// Create new
+		if req.ParentID != nil {
+			if err := validateCategoryParent(userID, 0, *req.ParentID); err != nil {
+				respondCategoryParentError(c, log, err)
+				return
+			}
+		}
 		newCat := models.Category{
-			UserID: userID,
-			Name:   name,
+			UserID:   userID,
+			Name:     name,
+			ParentID: req.ParentID,
 		}
 		if createErr := db.DB.Create(&newCat).Error; createErr != nil {
 			log.Error("Failed to create category", zap.Error(createErr))
@@ -70,6 +217,7 @@ func CreateCategory(c *gin.Context) {
 	c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 }
 
+// GetCategories lists the user's categories, or with tree=true nests them under their parents.
 func GetCategories(c *gin.Context) {
 	logger, _ := c.Get("logger")
 	log := logger.(*zap.Logger)
@@ -81,19 +229,343 @@ func GetCategories(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch categories"})
 		return
 	}
+	if c.Query("tree") == "true" {
+		c.JSON(http.StatusOK, gin.H{"categories": buildCategoryTree(categories)})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"categories": categories})
 }
 
+// UpdateCategoryRequest renames a category and optionally moves it. parentId 0 moves it to the top level;
+// omitting parentId keeps the current parent.
+type UpdateCategoryRequest struct {
+	Name     string `json:"name" binding:"required"`
+	ParentID *uint  `json:"parentId"`
+}
+
+// MergeCategoryRequest names the category that absorbs the merged one.
+type MergeCategoryRequest struct {
+	TargetID uint `json:"targetId" binding:"required"`
+}
+
+// categoryReferences counts what still points at a category.
+type categoryReferences struct {
+	Transactions  int64 `json:"transactions"`
+	Budgets       int64 `json:"budgets"`
+	Recurring     int64 `json:"recurringTransactions"`
+	Subcategories int64 `json:"subcategories"`
+}
+
+func (r categoryReferences) any() bool {
+	return r.Transactions+r.Budgets+r.Recurring+r.Subcategories > 0
+}
+
+// countCategoryReferences counts the live transactions, budgets, recurring transactions and subcategories using a category.
+func countCategoryReferences(categoryID uint) (categoryReferences, error) {
+	var refs categoryReferences
+	err := db.DB.Raw(`SELECT
+		(SELECT COUNT(*) FROM transactions WHERE category_id = ? AND deleted_at IS NULL) AS transactions,
+		(SELECT COUNT(*) FROM budgets WHERE category_id = ? AND deleted_at IS NULL) AS budgets,
+		(SELECT COUNT(*) FROM recurring_transactions WHERE category_id = ? AND deleted_at IS NULL) AS recurring,
+		(SELECT COUNT(*) FROM categories WHERE parent_id = ? AND deleted_at IS NULL) AS subcategories`,
+		categoryID, categoryID, categoryID, categoryID).Scan(&refs).Error
+	return refs, err
+}
+
+// categoryAncestorIDs returns the id of a category and of every category above it.
+func categoryAncestorIDs(categoryID uint) ([]uint, error) {
+	var ids []uint
+	err := db.DB.Raw(categoryAncestorsSQL, categoryID).Scan(&ids).Error
+	return ids, err
+}
+
+// recalcCategoryBudgets recalculates every budget of the user on the given categories.
+func recalcCategoryBudgets(userID uint, categoryIDs []uint, log *zap.Logger) {
+	if len(categoryIDs) == 0 {
+		return
+	}
+	var budgets []models.Budget
+	if err := db.DB.Where("user_id = ? AND category_id IN ?", userID, categoryIDs).Find(&budgets).Error; err != nil {
+		log.Error("Failed to find budgets for category recalc", zap.Error(err))
+		return
+	}
+	for i := range budgets {
+		recalcBudgetRemaining(&budgets[i], log)
+	}
+}
+
+// findUserCategory loads one of the user's categories by id.
+func findUserCategory(userID uint, id interface{}) (models.Category, error) {
+	var category models.Category
+	err := db.DB.Where("id = ? AND user_id = ?", id, userID).First(&category).Error
+	return category, err
+}
+
+// mergeCategory moves every transaction, recurring transaction, rule, budget and subcategory of source to target
+// and deletes source, all in one DB transaction. A source budget covering exactly the same period as a
+// target budget is folded into it. Budgets whose totals may have changed are recalculated afterwards.
+func mergeCategory(userID uint, source, target models.Category, log *zap.Logger) error {
+	if source.ID == target.ID {
+		return errCategoryMergeSelf
+	}
+	parents, err := loadCategoryParents(userID)
+	if err != nil {
+		return err
+	}
+	if categoryWithin(target.ID, source.ID, parents) {
+		return errCategoryCycle
+	}
+
+	// Budgets on either category's parents count source's spending before or after the move
+	affected, err := categoryAncestorIDs(source.ID)
+	if err != nil {
+		return err
+	}
+	targetAncestors, err := categoryAncestorIDs(target.ID)
+	if err != nil {
+		return err
+	}
+	affected = append(affected, targetAncestors...)
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		// Deleted rows are moved too so nothing is left pointing at the removed category
+		if err := tx.Unscoped().Model(&models.Transaction{}).
+			Where("user_id = ? AND category_id = ?", userID, source.ID).
+			Update("category_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.RecurringTransaction{}).
+			Where("user_id = ? AND category_id = ?", userID, source.ID).
+			Update("category_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.CategoryRule{}).
+			Where("user_id = ? AND category_id = ?", userID, source.ID).
+			Update("category_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		var sourceBudgets []models.Budget
+		if err := tx.Where("user_id = ? AND category_id = ?", userID, source.ID).Find(&sourceBudgets).Error; err != nil {
+			return err
+		}
+		for _, budget := range sourceBudgets {
+			var existing models.Budget
+			err := tx.Where("user_id = ? AND category_id = ? AND start_date = ? AND end_date = ?",
+				userID, target.ID, budget.StartDate, budget.EndDate).First(&existing).Error
+			switch {
+			case err == nil:
+				if err := tx.Model(&existing).Updates(map[string]interface{}{
+					"limit_amount":    existing.LimitAmount + budget.LimitAmount,
+					"rollover_amount": existing.RolloverAmount + budget.RolloverAmount,
+				}).Error; err != nil {
+					return err
+				}
+				if err := tx.Delete(&budget).Error; err != nil {
+					return err
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Model(&budget).Update("category_id", target.ID).Error; err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+		}
+		if err := tx.Unscoped().Model(&models.Budget{}).
+			Where("user_id = ? AND category_id = ? AND deleted_at IS NOT NULL", userID, source.ID).
+			Update("category_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&models.Category{}).
+			Where("user_id = ? AND parent_id = ?", userID, source.ID).
+			Update("parent_id", target.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	recalcCategoryBudgets(userID, affected, log)
+	return nil
+}
+
+// respondMergeError maps a mergeCategory failure to a response.
+func respondMergeError(c *gin.Context, log *zap.Logger, err error) {
+	switch {
+	case errors.Is(err, errCategoryCycle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be merged into one of its subcategories"})
+		return
+	case errors.Is(err, errCategoryMergeSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be merged into itself"})
+		return
+	}
+	log.Error("Failed to merge category", zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not merge category"})
+}
+
+// UpdateCategory renames a category and optionally moves it under another parent. Moving a category
+// changes which parent budgets cover its spending, so those are recalculated.
+func UpdateCategory(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	category, err := findUserCategory(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid category update data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	var clash int64
+	if err := db.DB.Model(&models.Category{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, category.ID).
+		Count(&clash).Error; err != nil {
+		log.Error("Database error checking category name", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if clash > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A category with this name already exists"})
+		return
+	}
+
+	var affected []uint
+	moved := false
+	if req.ParentID != nil {
+		var newParent *uint
+		if *req.ParentID != 0 {
+			if err := validateCategoryParent(userID, category.ID, *req.ParentID); err != nil {
+				respondCategoryParentError(c, log, err)
+				return
+			}
+			newParent = req.ParentID
+		}
+		if categoryParentChanged(category.ParentID, newParent) {
+			moved = true
+			if affected, err = categoryAncestorIDs(category.ID); err != nil {
+				log.Error("Failed to load category ancestors", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+		}
+		category.ParentID = newParent
+	}
+
+	category.Name = name
+	if err := db.DB.Model(&category).Select("name", "parent_id").Updates(&category).Error; err != nil {
+		log.Error("Failed to update category", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update category"})
+		return
+	}
+
+	if moved {
+		recalcMovedCategory(userID, category.ID, affected, log)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Category updated successfully",
+		"category": category,
+	})
+}
+
+// MergeCategory moves everything in category :id into targetId and deletes :id.
+func MergeCategory(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	source, err := findUserCategory(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var req MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid category merge data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, err := findUserCategory(userID, req.TargetID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Target category not found"})
+		return
+	}
+
+	if err := mergeCategory(userID, source, target, log); err != nil {
+		respondMergeError(c, log, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Category merged successfully",
+		"category": target,
+	})
+}
+
+// DeleteCategory deletes a category. With reassignTo, its transactions, budgets and subcategories move
+// to that category first (as in MergeCategory); without it, a category that is still in use is refused.
 func DeleteCategory(c *gin.Context) {
 	logger, _ := c.Get("logger")
 	log := logger.(*zap.Logger)
 	userID := c.MustGet("userID").(uint)
-	categoryID := c.Param("id")
 
-	if err := db.DB.Where("id = ? AND user_id = ?", categoryID, userID).Delete(&models.Category{}).Error; err != nil {
-		log.Error("Failed to delete category", zap.Error(err))
+	category, err := findUserCategory(userID, c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or could not be deleted"})
 		return
 	}
+
+	if reassignTo := c.Query("reassignTo"); reassignTo != "" {
+		target, err := findUserCategory(userID, reassignTo)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category to reassign to not found"})
+			return
+		}
+		if err := mergeCategory(userID, category, target, log); err != nil {
+			respondMergeError(c, log, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+		return
+	}
+
+	refs, err := countCategoryReferences(category.ID)
+	if err != nil {
+		log.Error("Failed to count category references", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if refs.any() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "Category is still in use; pass reassignTo to move its data to another category",
+			"references": refs,
+		})
+		return
+	}
+
+	// Rules have nothing left to assign once their category is gone
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND category_id = ?", userID, category.ID).Delete(&models.CategoryRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		log.Error("Failed to delete category", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Category not found or could not be deleted"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
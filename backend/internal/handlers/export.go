@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// Export formats accepted by GET /transactions/export
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+	ExportFormatOFX  = "ofx"
+	ExportFormatXLSX = "xlsx"
+)
+
+// exportFlushEvery is how many rows are written between flushes of the response
+const exportFlushEvery = 500
+
+// exportColumns is the header row of the CSV and XLSX exports
+var exportColumns = []string{"Date", "Type", "Amount", "Category", "Description"}
+
+// ExportedTransaction is one transaction as written by the export, with its category name resolved.
+type ExportedTransaction struct {
+	ID          uint    `json:"id"`
+	Date        string  `json:"date"` // YYYY-MM-DD
+	Type        string  `json:"type"`
+	Amount      float64 `json:"amount"`
+	CategoryID  *uint   `json:"categoryId"`
+	Category    string  `json:"category"`
+	Description string  `json:"description"`
+	ExternalID  string  `json:"externalId,omitempty"`
+}
+
+// exportQuery builds the filtered, category-joined query shared by all export formats.
+func exportQuery(userID uint, filters transactionFilters) *gorm.DB {
+	query := db.DB.Model(&models.Transaction{}).
+		Joins("LEFT JOIN categories ON categories.id = transactions.category_id AND categories.deleted_at IS NULL")
+	return applyTransactionFilters(query, userID, filters)
+}
+
+// streamExportRows runs the export query and calls fn for each row without loading the result set into memory.
+func streamExportRows(query *gorm.DB, fn func(ExportedTransaction) error) error {
+	rows, err := query.
+		Select("transactions.id, transactions.transaction_date, transactions.type, transactions.amount, " +
+			"transactions.category_id, categories.name, transactions.description, transactions.external_id").
+		Order("transactions.transaction_date, transactions.id").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			tx          ExportedTransaction
+			date        time.Time
+			categoryID  sql.NullInt64
+			category    sql.NullString
+			description sql.NullString
+			externalID  sql.NullString
+		)
+		if err := rows.Scan(&tx.ID, &date, &tx.Type, &tx.Amount, &categoryID, &category, &description, &externalID); err != nil {
+			return err
+		}
+		tx.Date = date.Format("2006-01-02")
+		if categoryID.Valid {
+			id := uint(categoryID.Int64)
+			tx.CategoryID = &id
+		}
+		tx.Category = category.String
+		tx.Description = description.String
+		tx.ExternalID = externalID.String
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// signedAmount returns the amount as money in (positive) or out (negative) of the account.
+func signedAmount(tx ExportedTransaction) float64 {
+	switch tx.Type {
+	case models.TransactionTypeIncome, models.TransactionTypeRefund:
+		return tx.Amount
+	default:
+		return -tx.Amount
+	}
+}
+
+// csvCell guards text against spreadsheet formula injection: Excel and Sheets run a cell starting with =,
+// +, -, @, tab or carriage return as a formula, so such text gets a leading apostrophe.
+func csvCell(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func writeCSVExport(c *gin.Context, query *gorm.DB) error {
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(exportColumns); err != nil {
+		return err
+	}
+
+	count := 0
+	err := streamExportRows(query, func(tx ExportedTransaction) error {
+		if err := w.Write([]string{tx.Date, tx.Type, strconv.FormatFloat(tx.Amount, 'f', 2, 64), csvCell(tx.Category), csvCell(tx.Description)}); err != nil {
+			return err
+		}
+		if count++; count%exportFlushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeJSONExport(c *gin.Context, query *gorm.DB) error {
+	w := bufio.NewWriter(c.Writer)
+	if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+
+	count := 0
+	err := streamExportRows(query, func(tx ExportedTransaction) error {
+		if count > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		data, err := json.Marshal(tx)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if count++; count%exportFlushEvery == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := w.WriteString("]"); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// escapeOFX escapes text for an OFX SGML element.
+func escapeOFX(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", " ", "\n", " ").Replace(s)
+}
+
+func writeOFXExport(c *gin.Context, query *gorm.DB) error {
+	// BANKTRANLIST has to open with the statement range, so it is read before streaming the rows
+	var bounds struct {
+		FirstDate sql.NullTime
+		LastDate  sql.NullTime
+	}
+	if err := query.Session(&gorm.Session{}).
+		Select("MIN(transactions.transaction_date) AS first_date, MAX(transactions.transaction_date) AS last_date").
+		Scan(&bounds).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	start, end := now, now
+	if bounds.FirstDate.Valid {
+		start = bounds.FirstDate.Time
+	}
+	if bounds.LastDate.Valid {
+		end = bounds.LastDate.Time
+	}
+
+	w := bufio.NewWriter(c.Writer)
+	fmt.Fprint(w, "OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:USASCII\r\n"+
+		"CHARSET:1252\r\nCOMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n")
+	fmt.Fprintf(w, "<OFX>\r\n<SIGNONMSGSRSV1>\r\n<SONRS>\r\n<STATUS>\r\n<CODE>0\r\n<SEVERITY>INFO\r\n</STATUS>\r\n"+
+		"<DTSERVER>%s\r\n<LANGUAGE>ENG\r\n</SONRS>\r\n</SIGNONMSGSRSV1>\r\n", now.Format("20060102150405"))
+	fmt.Fprintf(w, "<BANKMSGSRSV1>\r\n<STMTTRNRS>\r\n<TRNUID>0\r\n<STATUS>\r\n<CODE>0\r\n<SEVERITY>INFO\r\n</STATUS>\r\n"+
+		"<STMTRS>\r\n<CURDEF>USD\r\n<BANKACCTFROM>\r\n<BANKID>FINTRACK\r\n<ACCTID>FINTRACK\r\n<ACCTTYPE>CHECKING\r\n</BANKACCTFROM>\r\n"+
+		"<BANKTRANLIST>\r\n<DTSTART>%s\r\n<DTEND>%s\r\n", start.Format("20060102"), end.Format("20060102"))
+
+	count := 0
+	err := streamExportRows(query, func(tx ExportedTransaction) error {
+		trnType := "DEBIT"
+		switch tx.Type {
+		case models.TransactionTypeIncome, models.TransactionTypeRefund:
+			trnType = "CREDIT"
+		case models.TransactionTypeTransfer:
+			trnType = "XFER"
+		}
+		// Imported rows keep their bank FITID so the file can be re-imported without duplicates
+		fitID := tx.ExternalID
+		if fitID == "" {
+			fitID = fmt.Sprintf("FT%d", tx.ID)
+		}
+		// NAME is limited to 32 characters; the full description goes in MEMO
+		name := []rune(tx.Description)
+		if len(name) > 32 {
+			name = name[:32]
+		}
+
+		fmt.Fprintf(w, "<STMTTRN>\r\n<TRNTYPE>%s\r\n<DTPOSTED>%s\r\n<TRNAMT>%.2f\r\n<FITID>%s\r\n<NAME>%s\r\n",
+			trnType, strings.ReplaceAll(tx.Date, "-", ""), signedAmount(tx), escapeOFX(fitID), escapeOFX(string(name)))
+		if tx.Category != "" || len(name) < len([]rune(tx.Description)) {
+			memo := tx.Description
+			if tx.Category != "" {
+				memo = tx.Category + ": " + memo
+			}
+			fmt.Fprintf(w, "<MEMO>%s\r\n", escapeOFX(memo))
+		}
+		fmt.Fprint(w, "</STMTTRN>\r\n")
+
+		if count++; count%exportFlushEvery == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, "</BANKTRANLIST>\r\n</STMTRS>\r\n</STMTTRNRS>\r\n</BANKMSGSRSV1>\r\n</OFX>\r\n")
+	return w.Flush()
+}
+
+func writeXLSXExport(c *gin.Context, query *gorm.DB) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Transactions"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	// The stream writer spills to a temp file once the sheet grows, so large exports stay out of memory
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 14})
+	if err != nil {
+		return err
+	}
+	amountStyle, err := f.NewStyle(&excelize.Style{NumFmt: 4})
+	if err != nil {
+		return err
+	}
+
+	if err := sw.SetColWidth(1, 1, 12); err != nil {
+		return err
+	}
+	if err := sw.SetColWidth(5, 5, 40); err != nil {
+		return err
+	}
+	header := make([]interface{}, len(exportColumns))
+	for i, name := range exportColumns {
+		header[i] = name
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	row := 2
+	if err := streamExportRows(query, func(tx ExportedTransaction) error {
+		date, _ := time.Parse("2006-01-02", tx.Date)
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		row++
+		return sw.SetRow(cell, []interface{}{
+			excelize.Cell{StyleID: dateStyle, Value: date},
+			tx.Type,
+			excelize.Cell{StyleID: amountStyle, Value: tx.Amount},
+			tx.Category,
+			tx.Description,
+		})
+	}); err != nil {
+		return err
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(c.Writer)
+}
+
+// ExportTransactions writes the user's transactions as a CSV, JSON, OFX or XLSX download. It accepts the
+// same filters as GetTransactions and streams rows from the database rather than loading the whole result set.
+func ExportTransactions(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	format := strings.ToLower(c.DefaultQuery("format", ExportFormatCSV))
+
+	var contentType string
+	var write func(*gin.Context, *gorm.DB) error
+	switch format {
+	case ExportFormatCSV:
+		contentType, write = "text/csv; charset=utf-8", writeCSVExport
+	case ExportFormatJSON:
+		contentType, write = "application/json; charset=utf-8", writeJSONExport
+	case ExportFormatOFX:
+		contentType, write = "application/x-ofx", writeOFXExport
+	case ExportFormatXLSX:
+		contentType, write = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", writeXLSXExport
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of csv, json, ofx, xlsx"})
+		return
+	}
+
+	filters, err := parseTransactionFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("transactions-%s.%s", time.Now().Format("2006-01-02"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	if err := write(c, exportQuery(userID, filters)); err != nil {
+		log.Error("Failed to export transactions", zap.String("format", format), zap.Error(err))
+		// Once rows have started streaming the status is already sent and the download is just cut short
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not export transactions"})
+		}
+		c.Abort()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -31,11 +32,18 @@ func ForecastExpensesHandler(c *gin.Context) {
 		return
 	}
 
-	// Default startDate to beginning of current month if not provided
-	startDate := time.Now().UTC().AddDate(0, 0, -time.Now().UTC().Day()+1)
+	// Default startDate to beginning of current month if not provided. Forecasts run in whole months.
+	startDate := time.Now().UTC()
 	if req.StartDate != nil {
 		startDate = *req.StartDate
 	}
+	startDate = time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location())
+
+	levels := append([]float64(nil), req.ConfidenceLevels...)
+	if len(levels) == 0 {
+		levels = append(levels, defaultConfidenceLevels...)
+	}
+	sort.Float64s(levels)
 
 	// Use the global DB connection
 	if db.DB == nil {
@@ -44,174 +52,191 @@ func ForecastExpensesHandler(c *gin.Context) {
 		return
 	}
 
-	// Get user's transaction history
-	var transactions []models.Transaction
-	query := db.DB.Where("user_id = ?", userID)
-
-	// Filter by category if provided
-	if req.CategoryID != nil {
-		query = query.Where("category_id = ?", *req.CategoryID)
-	}
-
-	// Get transactions from the last 6 months for forecasting
-	sixMonthsAgo := startDate.AddDate(0, -6, 0)
-	if err := query.Where("transaction_date >= ?", sixMonthsAgo).Find(&transactions).Error; err != nil {
+	// Only whole months before the forecast starts are history
+	historyStart := startDate.AddDate(0, -forecastHistoryMonths, 0)
+	transactions, err := loadSpendingHistory(userID, req.CategoryID, historyStart, startDate)
+	if err != nil {
 		logger.Error("Failed to retrieve transactions", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transaction history"})
 		return
 	}
 
 	// Get categories for reporting
-	var categories []models.Category
-	if err := db.DB.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+	categoryMap, err := loadCategoryNames(userID)
+	if err != nil {
 		logger.Error("Failed to retrieve categories", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
 		return
 	}
 
-	// Create a map of category ID to name
-	categoryMap := make(map[uint]string)
-	for _, cat := range categories {
-		categoryMap[cat.ID] = cat.Name
-	}
-
 	// Calculate forecasts
-	totalForecast := calculateTotalForecast(transactions, startDate, req.MonthsAhead)
-	categoryForecasts := calculateCategoryForecasts(transactions, categoryMap, startDate, req.MonthsAhead)
+	history := monthlySeries(transactions, historyStart, forecastHistoryMonths, nil)
+	totalForecast, model := calculateTotalForecast(history, startDate, req.MonthsAhead, req.Model, levels)
+	categoryForecasts := calculateCategoryForecasts(transactions, categoryMap, historyStart, startDate, req.MonthsAhead, req.Model, levels)
 
 	response := models.ForecastResponse{
 		TotalForecast:     totalForecast,
+		Model:             model,
+		ConfidenceLevels:  levels,
 		CategoryForecasts: categoryForecasts,
-		Message:           fmt.Sprintf("Forecast generated for %d months based on your last 6 months of transactions", req.MonthsAhead),
+		Message: fmt.Sprintf("Forecast generated for %d months from %d months of transaction history using the %s model",
+			req.MonthsAhead, len(history), model),
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// calculateTotalForecast generates an overall expense forecast
-func calculateTotalForecast(transactions []models.Transaction, startDate time.Time, monthsAhead int) []models.ForecastPoint {
-	// Group transactions by month
-	monthlyTotals := make(map[string]float64)
+// forecastHistoryMonths is how far back forecasts look. The seasonal model needs two full years.
+const forecastHistoryMonths = 36
 
-	for _, tx := range transactions {
-		month := tx.TransactionDate.Format("2006-01")
-		monthlyTotals[month] += tx.Amount
+// loadSpendingHistory returns the user's expenses and refunds dated in [from, to), optionally limited to
+// one category. Income and transfers don't count toward spending.
+func loadSpendingHistory(userID uint, categoryID *uint, from, to time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	query := db.DB.Where("user_id = ? AND type IN ?", userID, []string{models.TransactionTypeExpense, models.TransactionTypeRefund})
+	if categoryID != nil {
+		query = query.Where("category_id = ?", *categoryID)
 	}
+	err := query.Where("transaction_date >= ? AND transaction_date < ?", from, to).Find(&transactions).Error
+	return transactions, err
+}
 
-	// Calculate average monthly spending
-	var totalSpent float64
-	for _, amount := range monthlyTotals {
-		totalSpent += amount
+// loadCategoryNames maps the user's category IDs to their names.
+func loadCategoryNames(userID uint) (map[uint]string, error) {
+	var categories []models.Category
+	if err := db.DB.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+		return nil, err
 	}
-
-	// If we have no transaction history, estimate will be zero
-	averageMonthlySpending := 0.0
-	confidence := 0.5 // Default medium confidence
-
-	if len(monthlyTotals) > 0 {
-		averageMonthlySpending = totalSpent / float64(len(monthlyTotals))
-
-		// Confidence increases with more data points
-		confidence = math.Min(float64(len(monthlyTotals))/6.0, 1.0)
+	names := make(map[uint]string, len(categories))
+	for _, cat := range categories {
+		names[cat.ID] = cat.Name
 	}
+	return names, nil
+}
 
-	// Generate forecast points
-	forecastPoints := make([]models.ForecastPoint, monthsAhead)
-	for i := 0; i < monthsAhead; i++ {
-		forecastDate := startDate.AddDate(0, i, 0)
-		month := forecastDate.Format("2006-01")
-
-		// For future months, add some randomness to the forecast
-		// Variance increases with distance into the future
-		varianceFactor := 1.0 + (float64(i) * 0.02) // 2% more variance per month
-		amount := averageMonthlySpending * (1.0 + (rand() * 0.1 * varianceFactor))
-
-		// Confidence decreases for months further in the future
-		monthConfidence := confidence * math.Pow(0.95, float64(i))
-
-		forecastPoints[i] = models.ForecastPoint{
-			Month:       month,
-			Amount:      math.Round(amount*100) / 100, // Round to 2 decimal places
-			Probability: math.Round(monthConfidence*100) / 100,
+// spendingCategoryIDs returns the IDs of the categories the transactions were spent in, in ascending order.
+func spendingCategoryIDs(transactions []models.Transaction) []uint {
+	var categoryIDs []uint
+	seen := make(map[uint]bool)
+	for _, tx := range transactions {
+		// Skip transactions with no category
+		if tx.CategoryID == nil || seen[*tx.CategoryID] {
+			continue
 		}
+		seen[*tx.CategoryID] = true
+		categoryIDs = append(categoryIDs, *tx.CategoryID)
 	}
+	sort.Slice(categoryIDs, func(i, j int) bool { return categoryIDs[i] < categoryIDs[j] })
+	return categoryIDs
+}
 
-	return forecastPoints
+// categorySeries is monthlySeries limited to one category.
+func categorySeries(transactions []models.Transaction, categoryID uint, start time.Time, months int) []float64 {
+	return monthlySeries(transactions, start, months, func(tx models.Transaction) bool {
+		return tx.CategoryID != nil && *tx.CategoryID == categoryID
+	})
 }
 
-// calculateCategoryForecasts generates category-specific forecasts
-func calculateCategoryForecasts(transactions []models.Transaction, categoryMap map[uint]string, startDate time.Time, monthsAhead int) []models.CategoryForecast {
-	// Group transactions by category and month
-	categoryMonthlyData := make(map[uint]map[string]float64)
+// categoryName returns the name of categoryID, "Unknown" when it is not in names.
+func categoryName(names map[uint]string, categoryID uint) string {
+	if name, exists := names[categoryID]; exists {
+		return name
+	}
+	return "Unknown"
+}
 
+// monthlySeries sums the expenses of the transactions accepted by include (all when nil) per month, for
+// the months from start. The series begins at the first month with a transaction, so a short history is
+// not padded with empty months; later months without spending count as zero.
+func monthlySeries(transactions []models.Transaction, start time.Time, months int, include func(models.Transaction) bool) []float64 {
+	totals := make([]float64, months)
+	first := months
 	for _, tx := range transactions {
-		// Skip transactions with no category
-		if tx.CategoryID == nil {
+		if include != nil && !include(tx) {
 			continue
 		}
-
-		catID := *tx.CategoryID
-		if _, exists := categoryMonthlyData[catID]; !exists {
-			categoryMonthlyData[catID] = make(map[string]float64)
+		i := (tx.TransactionDate.Year()-start.Year())*12 + int(tx.TransactionDate.Month()) - int(start.Month())
+		if i < 0 || i >= months {
+			continue
+		}
+		totals[i] += expenseAmount(tx)
+		if i < first {
+			first = i
 		}
-		month := tx.TransactionDate.Format("2006-01")
-		categoryMonthlyData[catID][month] += tx.Amount
 	}
+	return totals[first:]
+}
 
-	// Generate forecasts for each category
-	var categoryForecasts []models.CategoryForecast
-
-	for catID, monthlyData := range categoryMonthlyData {
-		var categoryTotal float64
-		for _, amount := range monthlyData {
-			categoryTotal += amount
+// defaultConfidenceLevels are the prediction intervals returned when the request names none.
+var defaultConfidenceLevels = []float64{0.8, 0.95}
+
+// predictionIntervals returns an interval around amount for each level, assuming normally distributed
+// forecast errors with standard error stdErr. Spending cannot be negative, so lower bounds stop at zero.
+func predictionIntervals(amount, stdErr float64, levels []float64) []models.PredictionInterval {
+	intervals := make([]models.PredictionInterval, len(levels))
+	for i, level := range levels {
+		margin := math.Sqrt2 * math.Erfinv(level) * stdErr
+		intervals[i] = models.PredictionInterval{
+			Level: level,
+			Lower: math.Round(math.Max(amount-margin, 0)*100) / 100,
+			Upper: math.Round((amount+margin)*100) / 100,
 		}
+	}
+	return intervals
+}
 
-		// Calculate average monthly spending for this category
-		avgCategorySpending := 0.0
-		if len(monthlyData) > 0 {
-			avgCategorySpending = categoryTotal / float64(len(monthlyData))
+// forecastPoints runs the model over history and labels the forecast months from startDate. Each point
+// carries prediction intervals at levels, widening with distance; they are left empty when the history
+// is too short to estimate the model's error.
+func forecastPoints(history []float64, startDate time.Time, monthsAhead int, model string, levels []float64) ([]models.ForecastPoint, string) {
+	fit := runForecast(model, history, monthsAhead)
+
+	points := make([]models.ForecastPoint, monthsAhead)
+	for i, amount := range fit.Forecast {
+		points[i] = models.ForecastPoint{
+			Month:     startDate.AddDate(0, i, 0).Format("2006-01"),
+			Amount:    math.Round(amount*100) / 100, // Round to 2 decimal places
+			Intervals: []models.PredictionInterval{},
 		}
-
-		// Generate forecast points for this category
-		forecastPoints := make([]models.ForecastPoint, monthsAhead)
-		for i := 0; i < monthsAhead; i++ {
-			forecastDate := startDate.AddDate(0, i, 0)
-			month := forecastDate.Format("2006-01")
-
-			// Add some variance to each month's forecast
-			varianceFactor := 1.0 + (float64(i) * 0.03) // 3% more variance per month for categories
-			amount := avgCategorySpending * (1.0 + (rand() * 0.15 * varianceFactor))
-
-			// Confidence calculation - less confidence for categories than overall
-			confidence := math.Min(float64(len(monthlyData))/6.0, 0.9) // Max 90% confidence for categories
-			monthConfidence := confidence * math.Pow(0.9, float64(i))
-
-			forecastPoints[i] = models.ForecastPoint{
-				Month:       month,
-				Amount:      math.Round(amount*100) / 100,
-				Probability: math.Round(monthConfidence*100) / 100,
-			}
+		if fit.StdErrors != nil {
+			points[i].Intervals = predictionIntervals(amount, fit.StdErrors[i], levels)
 		}
+	}
+	return points, fit.Model
+}
 
-		// Get category name from map, default to "Unknown" if not found
-		categoryName := "Unknown"
-		if name, exists := categoryMap[catID]; exists {
-			categoryName = name
-		}
+// calculateTotalForecast generates an overall expense forecast from the monthly history
+func calculateTotalForecast(history []float64, startDate time.Time, monthsAhead int, model string, levels []float64) ([]models.ForecastPoint, string) {
+	return forecastPoints(history, startDate, monthsAhead, model, levels)
+}
+
+// calculateCategoryForecasts generates category-specific forecasts, ordered by category ID
+func calculateCategoryForecasts(transactions []models.Transaction, categoryMap map[uint]string, historyStart, startDate time.Time, monthsAhead int, model string, levels []float64) []models.CategoryForecast {
+	var categoryForecasts []models.CategoryForecast
+	for _, catID := range spendingCategoryIDs(transactions) {
+		history := categorySeries(transactions, catID, historyStart, forecastHistoryMonths)
+		points, modelName := forecastPoints(history, startDate, monthsAhead, model, levels)
 
 		categoryForecasts = append(categoryForecasts, models.CategoryForecast{
 			CategoryID:   catID,
-			CategoryName: categoryName,
-			Forecast:     forecastPoints,
+			CategoryName: categoryName(categoryMap, catID),
+			Model:        modelName,
+			Forecast:     points,
 		})
 	}
 
 	return categoryForecasts
 }
 
-// rand returns a pseudo-random number between -0.5 and 0.5
-// This is a simple deterministic function for demo purposes
-func rand() float64 {
-	return (math.Sin(float64(time.Now().UnixNano()%1000)*0.1) * 0.5)
+// Test helper functions - exports private functions for testing
+
+// TestableRunForecast is a test-friendly version of runForecast
+func TestableRunForecast(model string, history []float64, horizon int) ([]float64, string) {
+	fit := runForecast(model, history, horizon)
+	return fit.Forecast, fit.Model
+}
+
+// TestableForecastStdErrors returns the standard error of each forecast month, nil when unknown
+func TestableForecastStdErrors(model string, history []float64, horizon int) []float64 {
+	return runForecast(model, history, horizon).StdErrors
 }
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// Backtest defaults
+const (
+	defaultBacktestHorizon    = 3
+	defaultBacktestMinHistory = 3
+)
+
+// backtestModels is the order models are reported in, simplest first.
+var backtestModels = []string{
+	models.ForecastModelMean,
+	models.ForecastModelLinearTrend,
+	models.ForecastModelExponentialSmoothing,
+	models.ForecastModelHoltWinters,
+}
+
+// backtestSeries replays history with a rolling origin: every model is fitted to the first t months,
+// for each t from minTrain on, and its forecasts for the following horizon months are compared with
+// what was actually spent. A model is only scored at origins with enough history for it.
+func backtestSeries(history []float64, horizon, minTrain int, modelNames []string) ([]models.BacktestMetrics, []string) {
+	metrics := []models.BacktestMetrics{}
+	bestModels := make([]string, horizon)
+	bestMAE := make([]float64, horizon)
+
+	for _, name := range modelNames {
+		f := forecasters[name]
+		start := minTrain
+		if f.minHistory() > start {
+			start = f.minHistory()
+		}
+
+		count := make([]int, horizon)
+		absErr := make([]float64, horizon)
+		bias := make([]float64, horizon)
+		pctErr := make([]float64, horizon)
+		pctCount := make([]int, horizon)
+		for origin := start; origin < len(history); origin++ {
+			fit := fitForecaster(f, history[:origin], horizon)
+			for h := 0; h < horizon && origin+h < len(history); h++ {
+				actual, forecast := history[origin+h], fit.Forecast[h]
+				count[h]++
+				absErr[h] += math.Abs(forecast - actual)
+				bias[h] += forecast - actual
+				if actual > 0 {
+					pctErr[h] += math.Abs(forecast-actual) / actual
+					pctCount[h]++
+				}
+			}
+		}
+
+		for h := 0; h < horizon; h++ {
+			if count[h] == 0 {
+				continue
+			}
+			m := models.BacktestMetrics{
+				Model:     name,
+				Horizon:   h + 1,
+				Forecasts: count[h],
+				MAE:       math.Round(absErr[h]/float64(count[h])*100) / 100,
+				Bias:      math.Round(bias[h]/float64(count[h])*100) / 100,
+			}
+			if pctCount[h] > 0 {
+				mape := math.Round(pctErr[h]/float64(pctCount[h])*10000) / 100
+				m.MAPE = &mape
+			}
+			metrics = append(metrics, m)
+
+			if bestModels[h] == "" || m.MAE < bestMAE[h] {
+				bestModels[h], bestMAE[h] = name, m.MAE
+			}
+		}
+	}
+	return metrics, bestModels
+}
+
+// BacktestForecastHandler scores the forecast models against the user's own history, for the total
+// and for each category, so defaults can be picked from evidence rather than guesswork.
+func BacktestForecastHandler(c *gin.Context) {
+	logger := c.MustGet("logger").(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var req models.BacktestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid backtest request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if req.Horizon == 0 {
+		req.Horizon = defaultBacktestHorizon
+	}
+	if req.Horizon < 1 || req.Horizon > 12 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Horizon must be between 1 and 12"})
+		return
+	}
+	if req.MinTrainMonths == 0 {
+		req.MinTrainMonths = defaultBacktestMinHistory
+	}
+	if req.MinTrainMonths < 1 || req.MinTrainMonths >= forecastHistoryMonths {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "MinTrainMonths must be between 1 and 35"})
+		return
+	}
+	modelNames := req.Models
+	if len(modelNames) == 0 {
+		modelNames = backtestModels
+	}
+
+	endDate := time.Now().UTC()
+	if req.EndDate != nil {
+		endDate = *req.EndDate
+	}
+	endDate = time.Date(endDate.Year(), endDate.Month(), 1, 0, 0, 0, 0, endDate.Location())
+
+	if db.DB == nil {
+		logger.Error("Database connection not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	historyStart := endDate.AddDate(0, -forecastHistoryMonths, 0)
+	transactions, err := loadSpendingHistory(userID, req.CategoryID, historyStart, endDate)
+	if err != nil {
+		logger.Error("Failed to retrieve transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transaction history"})
+		return
+	}
+	categoryMap, err := loadCategoryNames(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve categories", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
+		return
+	}
+
+	history := monthlySeries(transactions, historyStart, forecastHistoryMonths, nil)
+	metrics, best := backtestSeries(history, req.Horizon, req.MinTrainMonths, modelNames)
+	response := models.BacktestResponse{
+		Total:      models.BacktestSeries{Months: len(history), Metrics: metrics, BestModels: best},
+		Categories: []models.BacktestSeries{},
+	}
+
+	for _, catID := range spendingCategoryIDs(transactions) {
+		catID := catID
+		history := categorySeries(transactions, catID, historyStart, forecastHistoryMonths)
+		metrics, best := backtestSeries(history, req.Horizon, req.MinTrainMonths, modelNames)
+		response.Categories = append(response.Categories, models.BacktestSeries{
+			CategoryID:   &catID,
+			CategoryName: categoryName(categoryMap, catID),
+			Months:       len(history),
+			Metrics:      metrics,
+			BestModels:   best,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Test helper functions - exports private functions for testing
+
+// TestableBacktestSeries is a test-friendly version of backtestSeries
+func TestableBacktestSeries(history []float64, horizon, minTrain int, modelNames []string) ([]models.BacktestMetrics, []string) {
+	return backtestSeries(history, horizon, minTrain, modelNames)
+}
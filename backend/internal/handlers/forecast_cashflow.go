@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// Cash-flow projection defaults
+const (
+	defaultCashFlowDays = 30
+	maxCashFlowDays     = 365
+)
+
+// Where the opening balance of a cash-flow projection came from
+const (
+	balanceSourceSupplied     = "supplied"
+	balanceSourceTransactions = "transactions"
+)
+
+// netCashFlowSumSQL sums money in minus money out: income and refunds add, expenses subtract, transfers are ignored.
+const netCashFlowSumSQL = "COALESCE(SUM(CASE WHEN type IN ('income', 'refund') THEN amount WHEN type = 'expense' THEN -amount ELSE 0 END), 0)"
+
+// accountBalance derives the user's balance from every transaction dated before the given day.
+func accountBalance(userID uint, before time.Time) (float64, error) {
+	var result struct {
+		Total float64
+	}
+	err := db.DB.Model(&models.Transaction{}).
+		Select(netCashFlowSumSQL+" as total").
+		Where("user_id = ? AND transaction_date < ?", userID, before).
+		Scan(&result).Error
+	return result.Total, err
+}
+
+// projectCashFlow projects the balance day by day for days days from start. Each day adds the recurring
+// income and subtracts the recurring bills due, plus discretionary spending at the daily share of the month's
+// forecast; history is the spending of whole months before start, of which recurring items are left out.
+// A recurring item already due today but not booked yet is expected on the first day; when start is after
+// today, the items falling before start are booked by then and go into the opening balance instead.
+func projectCashFlow(today, start time.Time, days int, opening, threshold float64, history []models.Transaction, recurring []models.RecurringTransaction) models.CashFlowProjection {
+	start = dateOnly(start)
+	end := start.AddDate(0, 0, days-1)
+	future := start.After(dateOnly(today))
+
+	calendar := make([]models.CashFlowDay, days)
+	for i := range calendar {
+		calendar[i] = models.CashFlowDay{Date: start.AddDate(0, 0, i).Format("2006-01-02"), Items: []models.CashFlowItem{}}
+	}
+
+	for _, rt := range recurring {
+		if rt.Type == models.TransactionTypeTransfer {
+			continue
+		}
+		for _, date := range pendingOccurrences(rt, time.Time{}, end) {
+			day := daysBetween(start, date)
+			if day < 0 && future {
+				if rt.Type == models.TransactionTypeExpense {
+					opening -= rt.Amount
+				} else {
+					opening += rt.Amount
+				}
+				continue
+			}
+			if day < 0 {
+				day = 0
+			}
+			if rt.Type == models.TransactionTypeExpense {
+				calendar[day].Bills += rt.Amount
+			} else {
+				calendar[day].Income += rt.Amount
+			}
+			calendar[day].Items = append(calendar[day].Items, models.CashFlowItem{
+				RecurringTransactionID: rt.ID,
+				Type:                   rt.Type,
+				Amount:                 rt.Amount,
+				Description:            rt.Description,
+			})
+		}
+	}
+
+	projection := models.CashFlowProjection{
+		StartDate:      start.Format("2006-01-02"),
+		Days:           days,
+		OpeningBalance: roundCents(opening),
+		Threshold:      threshold,
+	}
+
+	// Discretionary spending: the forecast of each month touched, spread evenly over its days
+	monthStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month()) + 1
+	series := monthlySeries(history, monthStart.AddDate(0, -forecastHistoryMonths, 0), forecastHistoryMonths, func(tx models.Transaction) bool {
+		return tx.RecurringTransactionID == nil
+	})
+	var monthly []float64
+	if len(series) > 0 {
+		fit := runForecast("", series, months)
+		projection.DiscretionaryModel = fit.Model
+		monthly = fit.Forecast
+	}
+
+	balance := opening
+	for i := range calendar {
+		date := start.AddDate(0, 0, i)
+		if monthly != nil {
+			month := (date.Year()-start.Year())*12 + int(date.Month()) - int(start.Month())
+			daysInMonth := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location()).Day()
+			calendar[i].Discretionary = monthly[month] / float64(daysInMonth)
+		}
+		balance += calendar[i].Income - calendar[i].Bills - calendar[i].Discretionary
+
+		day := &calendar[i]
+		day.Income = roundCents(day.Income)
+		day.Bills = roundCents(day.Bills)
+		day.Discretionary = roundCents(day.Discretionary)
+		day.Balance = roundCents(balance)
+		day.BelowThreshold = day.Balance < threshold
+		if day.BelowThreshold {
+			projection.DaysBelowThreshold++
+			if projection.FirstBelowDate == nil {
+				projection.FirstBelowDate = &day.Date
+			}
+		}
+		if i == 0 || day.Balance < projection.LowestBalance {
+			projection.LowestBalance, projection.LowestBalanceDate = day.Balance, day.Date
+		}
+	}
+
+	projection.ClosingBalance = roundCents(balance)
+	projection.Calendar = calendar
+	return projection
+}
+
+// CashFlowForecastHandler projects the user's balance day by day from an opening balance, recurring income
+// and bills, and forecast discretionary spending. Query parameters, all optional:
+//   - days: length of the projection, 1-365 (default 30)
+//   - startDate: first projected day, YYYY-MM-DD (default today)
+//   - openingBalance: balance before startDate (default: derived from all transactions before it); recurring
+//     items due from today until a future startDate are added to it
+//   - threshold: days ending below this balance are flagged (default 0)
+func CashFlowForecastHandler(c *gin.Context) {
+	logger := c.MustGet("logger").(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	days := defaultCashFlowDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxCashFlowDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = parsed
+	}
+
+	today := dateOnly(time.Now())
+	start := today
+	if raw := c.Query("startDate"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date"})
+			return
+		}
+		start = dateOnly(parsed)
+	}
+
+	var threshold float64
+	if raw := c.Query("threshold"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid threshold"})
+			return
+		}
+		threshold = parsed
+	}
+
+	var opening *float64
+	if raw := c.Query("openingBalance"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid opening balance"})
+			return
+		}
+		opening = &parsed
+	}
+
+	if db.DB == nil {
+		logger.Error("Database connection not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	source := balanceSourceSupplied
+	if opening == nil {
+		balance, err := accountBalance(userID, start)
+		if err != nil {
+			logger.Error("Failed to derive account balance", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to derive opening balance"})
+			return
+		}
+		opening, source = &balance, balanceSourceTransactions
+	}
+
+	monthStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+	history, err := loadSpendingHistory(userID, nil, monthStart.AddDate(0, -forecastHistoryMonths, 0), monthStart)
+	if err != nil {
+		logger.Error("Failed to retrieve transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transaction history"})
+		return
+	}
+
+	var recurring []models.RecurringTransaction
+	if err := db.DB.Where("user_id = ? AND active = ?", userID, true).Find(&recurring).Error; err != nil {
+		logger.Error("Failed to retrieve recurring transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recurring transactions"})
+		return
+	}
+
+	projection := projectCashFlow(today, start, days, *opening, threshold, history, recurring)
+	projection.BalanceSource = source
+	c.JSON(http.StatusOK, projection)
+}
+
+// Test helper functions - exports private functions for testing
+
+// TestableProjectCashFlow is a test-friendly version of projectCashFlow
+func TestableProjectCashFlow(today, start time.Time, days int, opening, threshold float64, history []models.Transaction, recurring []models.RecurringTransaction) models.CashFlowProjection {
+	return projectCashFlow(today, start, days, opening, threshold, history, recurring)
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"math"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// forecastSeasonLength is the season of the Holt-Winters model: spending repeats yearly.
+const forecastSeasonLength = 12
+
+// smoothingGrid holds the parameter values tried when fitting the smoothing models. A fixed grid
+// keeps fitting deterministic: the same history always produces the same forecast.
+var smoothingGrid = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+
+// forecastFit is a fitted model's forecast along with its one-step-ahead errors on the history.
+type forecastFit struct {
+	Model     string    // name of the model that produced the fit
+	Forecast  []float64 // next horizon months
+	Residuals []float64 // actual minus fitted, for the months the model could predict
+	StdErrors []float64 // standard error of each forecast month; nil when the history is too short to tell
+}
+
+// forecaster is a model that can be fitted to a monthly spending series, oldest month first.
+type forecaster interface {
+	name() string
+	minHistory() int
+	fit(history []float64, horizon int) forecastFit
+}
+
+// forecasters lists the available models by name.
+var forecasters = map[string]forecaster{
+	models.ForecastModelMean:                 meanForecaster{},
+	models.ForecastModelLinearTrend:          linearTrendForecaster{},
+	models.ForecastModelExponentialSmoothing: exponentialSmoothingForecaster{},
+	models.ForecastModelHoltWinters:          holtWintersForecaster{season: forecastSeasonLength},
+}
+
+// selectForecaster returns the model named by requested when the history is long enough for it,
+// otherwise the richest model the history supports: a seasonal model needs two full years,
+// smoothing half a year and a trend line three months.
+func selectForecaster(requested string, months int) forecaster {
+	if f, ok := forecasters[requested]; ok && months >= f.minHistory() {
+		return f
+	}
+	for _, name := range []string{
+		models.ForecastModelHoltWinters,
+		models.ForecastModelExponentialSmoothing,
+		models.ForecastModelLinearTrend,
+	} {
+		if f := forecasters[name]; months >= f.minHistory() {
+			return f
+		}
+	}
+	return forecasters[models.ForecastModelMean]
+}
+
+// runForecast fits the selected model to history.
+func runForecast(requested string, history []float64, horizon int) forecastFit {
+	return fitForecaster(selectForecaster(requested, len(history)), history, horizon)
+}
+
+// fitForecaster fits f to history. Spending cannot be negative, so forecasts are floored at zero.
+func fitForecaster(f forecaster, history []float64, horizon int) forecastFit {
+	result := f.fit(history, horizon)
+	result.Model = f.name()
+	for i, v := range result.Forecast {
+		result.Forecast[i] = math.Max(v, 0)
+	}
+	return result
+}
+
+// meanForecaster predicts the average month. It is the fallback for very short histories.
+type meanForecaster struct{}
+
+func (meanForecaster) name() string    { return models.ForecastModelMean }
+func (meanForecaster) minHistory() int { return 0 }
+
+func (meanForecaster) fit(history []float64, horizon int) forecastFit {
+	result := forecastFit{Forecast: make([]float64, horizon)}
+	if len(history) == 0 {
+		return result
+	}
+	var sum float64
+	for i, v := range history {
+		// Each month is predicted by the mean of the months before it
+		if i > 0 {
+			result.Residuals = append(result.Residuals, v-sum/float64(i))
+		}
+		sum += v
+	}
+	mean := sum / float64(len(history))
+	for h := range result.Forecast {
+		result.Forecast[h] = mean
+	}
+	if variance, ok := residualVariance(result.Residuals, 0); ok {
+		result.StdErrors = constantStdErrors(math.Sqrt(variance), horizon)
+	}
+	return result
+}
+
+// linearTrendForecaster extends an ordinary least squares line through the history.
+type linearTrendForecaster struct{}
+
+func (linearTrendForecaster) name() string    { return models.ForecastModelLinearTrend }
+func (linearTrendForecaster) minHistory() int { return 3 }
+
+func (linearTrendForecaster) fit(history []float64, horizon int) forecastFit {
+	n := float64(len(history))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range history {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+
+	result := forecastFit{Forecast: make([]float64, horizon)}
+	for i, y := range history {
+		result.Residuals = append(result.Residuals, y-(intercept+slope*float64(i)))
+	}
+	for h := range result.Forecast {
+		result.Forecast[h] = intercept + slope*(n+float64(h))
+	}
+
+	// The line's own uncertainty grows with the distance from the middle of the history
+	if variance, ok := residualVariance(result.Residuals, 2); ok {
+		meanX := sumX / n
+		sxx := sumXX - n*meanX*meanX
+		result.StdErrors = make([]float64, horizon)
+		for h := range result.StdErrors {
+			dx := n + float64(h) - meanX
+			result.StdErrors[h] = math.Sqrt(variance * (1 + 1/n + dx*dx/sxx))
+		}
+	}
+	return result
+}
+
+// exponentialSmoothingForecaster is Holt's linear method: a smoothed level plus a smoothed trend,
+// so recent months weigh more than older ones. Alpha and beta are picked from smoothingGrid by the
+// lowest one-step-ahead squared error.
+type exponentialSmoothingForecaster struct{}
+
+func (exponentialSmoothingForecaster) name() string    { return models.ForecastModelExponentialSmoothing }
+func (exponentialSmoothingForecaster) minHistory() int { return 6 }
+
+func (exponentialSmoothingForecaster) fit(history []float64, horizon int) forecastFit {
+	// The first two months set the level and trend, so the errors start with the third
+	run := func(alpha, beta float64) (level, trend float64, residuals []float64) {
+		level, trend = history[1], history[1]-history[0]
+		for _, y := range history[2:] {
+			predicted := level + trend
+			residuals = append(residuals, y-predicted)
+			previous := level
+			level = alpha*y + (1-alpha)*predicted
+			trend = beta*(level-previous) + (1-beta)*trend
+		}
+		return level, trend, residuals
+	}
+
+	bestSSE := math.Inf(1)
+	var bestAlpha, bestBeta float64
+	for _, alpha := range smoothingGrid {
+		for _, beta := range smoothingGrid {
+			_, _, residuals := run(alpha, beta)
+			if sse := sumSquares(residuals); sse < bestSSE {
+				bestSSE, bestAlpha, bestBeta = sse, alpha, beta
+			}
+		}
+	}
+
+	level, trend, residuals := run(bestAlpha, bestBeta)
+	result := forecastFit{Forecast: make([]float64, horizon), Residuals: residuals}
+	for h := range result.Forecast {
+		result.Forecast[h] = level + trend*float64(h+1)
+	}
+	if variance, ok := residualVariance(residuals, 2); ok {
+		result.StdErrors = smoothingStdErrors(variance, horizon, func(j int) float64 {
+			return bestAlpha * (1 + float64(j)*bestBeta)
+		})
+	}
+	return result
+}
+
+// holtWintersForecaster is additive Holt-Winters: level, trend and a repeating seasonal offset per
+// month of the season. It is initialised from the first two seasons; alpha, beta and gamma are picked
+// from smoothingGrid by the lowest one-step-ahead squared error.
+type holtWintersForecaster struct {
+	season int
+}
+
+func (holtWintersForecaster) name() string      { return models.ForecastModelHoltWinters }
+func (f holtWintersForecaster) minHistory() int { return 2 * f.season }
+
+func (f holtWintersForecaster) fit(history []float64, horizon int) forecastFit {
+	m := f.season
+	var firstMean, secondMean float64
+	for i := 0; i < m; i++ {
+		firstMean += history[i] / float64(m)
+		secondMean += history[m+i] / float64(m)
+	}
+
+	run := func(alpha, beta, gamma float64) (level, trend float64, seasonal, residuals []float64) {
+		level, trend = firstMean, (secondMean-firstMean)/float64(m)
+		seasonal = make([]float64, m)
+		for i := 0; i < m; i++ {
+			seasonal[i] = history[i] - firstMean
+		}
+		for t := m; t < len(history); t++ {
+			y := history[t]
+			s := seasonal[t%m]
+			residuals = append(residuals, y-(level+trend+s))
+			previous := level
+			level = alpha*(y-s) + (1-alpha)*(level+trend)
+			trend = beta*(level-previous) + (1-beta)*trend
+			seasonal[t%m] = gamma*(y-level) + (1-gamma)*s
+		}
+		return level, trend, seasonal, residuals
+	}
+
+	bestSSE := math.Inf(1)
+	var bestAlpha, bestBeta, bestGamma float64
+	for _, alpha := range smoothingGrid {
+		for _, beta := range smoothingGrid {
+			for _, gamma := range smoothingGrid {
+				_, _, _, residuals := run(alpha, beta, gamma)
+				if sse := sumSquares(residuals); sse < bestSSE {
+					bestSSE, bestAlpha, bestBeta, bestGamma = sse, alpha, beta, gamma
+				}
+			}
+		}
+	}
+
+	level, trend, seasonal, residuals := run(bestAlpha, bestBeta, bestGamma)
+	result := forecastFit{Forecast: make([]float64, horizon), Residuals: residuals}
+	n := len(history)
+	for h := range result.Forecast {
+		result.Forecast[h] = level + trend*float64(h+1) + seasonal[(n+h)%m]
+	}
+	if variance, ok := residualVariance(residuals, 3); ok {
+		result.StdErrors = smoothingStdErrors(variance, horizon, func(j int) float64 {
+			c := bestAlpha * (1 + float64(j)*bestBeta)
+			if j%m == 0 {
+				c += bestGamma * (1 - bestAlpha)
+			}
+			return c
+		})
+	}
+	return result
+}
+
+// residualVariance estimates the variance of the one-step errors, losing one degree of freedom per
+// fitted parameter. ok is false when too few residuals are left to estimate it.
+func residualVariance(residuals []float64, params int) (variance float64, ok bool) {
+	df := len(residuals) - params
+	if df < 1 {
+		return 0, false
+	}
+	return sumSquares(residuals) / float64(df), true
+}
+
+// constantStdErrors repeats stdErr for every forecast month.
+func constantStdErrors(stdErr float64, horizon int) []float64 {
+	errs := make([]float64, horizon)
+	for h := range errs {
+		errs[h] = stdErr
+	}
+	return errs
+}
+
+// smoothingStdErrors returns the h-step standard errors of an additive smoothing model: the one-step
+// variance widened by the weight coef(j) with which the error j steps back still reaches the forecast.
+func smoothingStdErrors(variance float64, horizon int, coef func(j int) float64) []float64 {
+	errs := make([]float64, horizon)
+	spread := 1.0
+	for h := range errs {
+		if h > 0 {
+			c := coef(h)
+			spread += c * c
+		}
+		errs[h] = math.Sqrt(variance * spread)
+	}
+	return errs
+}
+
+// sumSquares returns the sum of the squared values.
+func sumSquares(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v * v
+	}
+	return sum
+}
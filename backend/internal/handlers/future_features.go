@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -24,32 +23,31 @@ func GamificationHandler(c *gin.Context) {
 	}
 
 	// Get user's badges
-	var userBadges []models.UserBadge
+	userBadges := []models.UserBadge{}
 	if err := db.DB.Preload("Badge").Where("user_id = ?", userID).Find(&userBadges).Error; err != nil {
-		// If table doesn't exist yet, return demo data
-		logger.Warn("Failed to retrieve user badges, using demo data", zap.Error(err))
-		c.JSON(http.StatusOK, getDemoGamificationData(userID))
+		logger.Error("Failed to retrieve user badges", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve gamification status"})
 		return
 	}
 
 	// Get user's points
-	var userPoints []models.UserPoints
+	userPoints := []models.UserPoints{}
 	if err := db.DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(10).Find(&userPoints).Error; err != nil {
-		logger.Warn("Failed to retrieve user points, using demo data", zap.Error(err))
-		c.JSON(http.StatusOK, getDemoGamificationData(userID))
+		logger.Error("Failed to retrieve user points", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve gamification status"})
 		return
 	}
 
 	// Calculate total points
 	var totalPoints int
-	if err := db.DB.Model(&models.UserPoints{}).Where("user_id = ?", userID).Select("SUM(points) as total").Scan(&totalPoints).Error; err != nil {
-		logger.Warn("Failed to calculate total points, using demo data", zap.Error(err))
-		c.JSON(http.StatusOK, getDemoGamificationData(userID))
+	if err := db.DB.Model(&models.UserPoints{}).Where("user_id = ?", userID).Select("COALESCE(SUM(points), 0) as total").Scan(&totalPoints).Error; err != nil {
+		logger.Error("Failed to calculate total points", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve gamification status"})
 		return
 	}
 
 	// Get badges user hasn't earned yet
-	var nextBadges []models.Badge
+	nextBadges := []models.Badge{}
 	var earnedBadgeIDs []uint
 	for _, badge := range userBadges {
 		earnedBadgeIDs = append(earnedBadgeIDs, badge.BadgeID)
@@ -164,73 +162,6 @@ func getRecentBadges(allBadges []models.UserBadge) []models.UserBadge {
 	return allBadges[:3]
 }
 
-// getDemoGamificationData returns demo data for the gamification feature
-func getDemoGamificationData(userID uint) models.GamificationSummary {
-	now := time.Now()
-
-	// Demo badges
-	badges := []models.Badge{
-		{ID: 1, Name: "Budget Beginner", Description: "Created your first budget", ImageURL: "/images/badges/budget_beginner.png", Category: "budgeting", Threshold: 10},
-		{ID: 2, Name: "Tracking Pro", Description: "Tracked expenses for 7 consecutive days", ImageURL: "/images/badges/tracking_pro.png", Category: "consistency", Threshold: 50},
-		{ID: 3, Name: "Savings Star", Description: "Saved 10% of your income", ImageURL: "/images/badges/savings_star.png", Category: "savings", Threshold: 100},
-	}
-
-	// Demo user badges
-	userBadges := []models.UserBadge{
-		{ID: 1, UserID: userID, BadgeID: 1, Badge: badges[0], EarnedAt: now.AddDate(0, 0, -30)},
-		{ID: 2, UserID: userID, BadgeID: 2, Badge: badges[1], EarnedAt: now.AddDate(0, 0, -15)},
-	}
-
-	// Demo user points
-	userPoints := []models.UserPoints{
-		{ID: 1, UserID: userID, Points: 10, Reason: "Created first budget", ActivityType: "budget_created", CreatedAt: now.AddDate(0, 0, -30)},
-		{ID: 2, UserID: userID, Points: 5, Reason: "Added 10 transactions", ActivityType: "transaction_added", CreatedAt: now.AddDate(0, 0, -25)},
-		{ID: 3, UserID: userID, Points: 20, Reason: "Completed user profile", ActivityType: "profile_completed", CreatedAt: now.AddDate(0, 0, -20)},
-		{ID: 4, UserID: userID, Points: 15, Reason: "Tracked expenses for a week", ActivityType: "tracking_streak", CreatedAt: now.AddDate(0, 0, -15)},
-		{ID: 5, UserID: userID, Points: 25, Reason: "Stayed under budget", ActivityType: "budget_achieved", CreatedAt: now.AddDate(0, 0, -10)},
-	}
-
-	// Calculate total points from the demo data
-	totalPoints := 0
-	for _, p := range userPoints {
-		totalPoints += p.Points
-	}
-
-	// Demo next badges to earn
-	nextBadges := []models.Badge{
-		{ID: 3, Name: "Savings Star", Description: "Saved 10% of your income", ImageURL: "/images/badges/savings_star.png", Category: "savings", Threshold: 100},
-		{ID: 4, Name: "Budget Master", Description: "Stayed under budget for 3 consecutive months", ImageURL: "/images/badges/budget_master.png", Category: "budgeting", Threshold: 150},
-	}
-
-	// Build the response
-	return models.GamificationSummary{
-		TotalPoints:       totalPoints,
-		RecentBadges:      userBadges,
-		AllBadges:         userBadges,
-		RecentPoints:      userPoints,
-		NextBadges:        nextBadges,
-		Level:             calculateLevel(totalPoints),
-		LevelTitle:        getLevelTitle(calculateLevel(totalPoints)),
-		PointsToNextLevel: calculatePointsToNextLevel(totalPoints),
-	}
-}
-
-// AnalyticsHandler is a stub endpoint for sprint3 advanced analytics.
-func AnalyticsHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"totalExpense": 1234.56,
-		"totalBudget":  2000.00,
-		"analytics":    "Advanced analytics coming soon!",
-	})
-}
-
-// NotificationHandler is a stub endpoint for sprint3 push notifications.
-func NotificationHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"notification": "You have 1 new message. Push notifications coming soon!",
-	})
-}
-
 // Test helper functions - exports private functions for testing
 // These are only used by tests and do not affect normal operation
 
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// gamificationPoints is what one occurrence of each event type earns.
+var gamificationPoints = map[string]int{
+	models.EventTransactionAdded:             5,
+	models.EventBudgetCreated:                10,
+	models.EventBudgetPeriodClosedUnderLimit: 25,
+	models.EventProfileCompleted:             20,
+}
+
+// GamificationSubscriber handles the gamification events the handlers publish. AwardPoints is the points
+// engine.
+type GamificationSubscriber func(event models.GamificationEvent, log *zap.Logger)
+
+var gamificationSubscribers []GamificationSubscriber
+
+// OnGamificationEvent registers subscriber for every gamification event. Register subscribers at startup.
+func OnGamificationEvent(subscriber GamificationSubscriber) {
+	gamificationSubscribers = append(gamificationSubscribers, subscriber)
+}
+
+// publishGamificationEvent hands event to the subscribers in the order they registered.
+func publishGamificationEvent(event models.GamificationEvent, log *zap.Logger) {
+	for _, subscriber := range gamificationSubscribers {
+		subscriber(event, log)
+	}
+}
+
+// AwardPoints is the points engine, a GamificationSubscriber. It credits the points of event and awards the
+// badges whose rules the user now meets, announcing each in the inbox. Failures are only logged.
+func AwardPoints(event models.GamificationEvent, log *zap.Logger) {
+	awarded, err := applyGamificationEvent(event, log)
+	if err != nil {
+		log.Warn("Failed to apply gamification event",
+			zap.Uint("userID", event.UserID), zap.String("type", event.Type), zap.String("key", event.Key), zap.Error(err))
+	}
+	announceBadges(event.UserID, awarded, log)
+}
+
+// applyGamificationEvent credits the points of event and returns the badges it newly awards. It is
+// idempotent: the points entry is keyed by the event, so a replayed event earns nothing, and badges are
+// only ever awarded once.
+func applyGamificationEvent(event models.GamificationEvent, log *zap.Logger) ([]models.UserBadge, error) {
+	points, ok := gamificationPoints[event.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown gamification event %q", event.Type)
+	}
+
+	key := event.Key
+	entry := models.UserPoints{
+		UserID:       event.UserID,
+		Points:       points,
+		Reason:       event.Reason,
+		ActivityType: event.Type,
+		EventKey:     &key,
+	}
+	if err := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error; err != nil {
+		return nil, err
+	}
+
+	// Even a replayed event checks the badges, in case an earlier attempt stopped short of them
+	return awardBadges(event.UserID, log)
+}
+
+// revokeGamificationPoints takes back, within tx, the points the user's event with key earned. Handlers call it
+// when deleting what earned them, so creating and deleting the same thing over and over earns nothing.
+// Badges already awarded are kept.
+func revokeGamificationPoints(tx *gorm.DB, userID uint, key string) error {
+	return tx.Where("user_id = ? AND event_key = ?", userID, key).Delete(&models.UserPoints{}).Error
+}
+
+// budgetEventKey is the key of the points creating budget id earned.
+func budgetEventKey(id uint) string {
+	return fmt.Sprintf("budget:%d", id)
+}
+
+// transactionEventKey is the key of the points adding transaction id earned.
+func transactionEventKey(id uint) string {
+	return fmt.Sprintf("transaction:%d", id)
+}
+
+// awardBadges awards the user every badge they don't hold yet whose rule they meet, lowest threshold first.
+// A badge with an invalid rule is logged and skipped, so one bad definition doesn't hold back the others.
+func awardBadges(userID uint, log *zap.Logger) ([]models.UserBadge, error) {
+	var badges []models.Badge
+	held := db.DB.Model(&models.UserBadge{}).Select("badge_id").Where("user_id = ?", userID)
+	if err := db.DB.Where("id NOT IN (?)", held).Order("threshold").Find(&badges).Error; err != nil {
+		return nil, err
+	}
+
+	stats := newBadgeStats(userID, time.Now())
+	var awarded []models.UserBadge
+	for _, badge := range badges {
+		rule, err := badgeRule(badge)
+		if err != nil {
+			log.Warn("Skipping badge with an invalid rule", zap.Uint("badgeID", badge.ID), zap.String("name", badge.Name), zap.Error(err))
+			continue
+		}
+		met, err := evaluateBadgeRule(rule, stats.get)
+		if err != nil {
+			return awarded, err
+		}
+		if !met {
+			continue
+		}
+
+		userBadge, ok, err := awardBadge(userID, badge)
+		if err != nil {
+			return awarded, err
+		}
+		if ok {
+			awarded = append(awarded, userBadge)
+		}
+	}
+	return awarded, nil
+}
+
+// awardBadge gives the user badge, reporting false when they already held it.
+func awardBadge(userID uint, badge models.Badge) (models.UserBadge, bool, error) {
+	userBadge := models.UserBadge{UserID: userID, BadgeID: badge.ID, EarnedAt: time.Now()}
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&userBadge)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return userBadge, false, result.Error
+	}
+	userBadge.Badge = badge
+	return userBadge, true, nil
+}
+
+// announceBadges posts a notification for each badge the user was just awarded.
+func announceBadges(userID uint, awarded []models.UserBadge, log *zap.Logger) {
+	for _, userBadge := range awarded {
+		notify(log, userID, models.NotificationTypeGamification, models.NotificationSeverityInfo,
+			"Badge earned: "+userBadge.Badge.Name, userBadge.Badge.Description, userBadge.Badge)
+	}
+}
+
+// Test helper functions - exports private functions for testing
+// These are only used by tests and do not affect normal operation
+
+// TestableApplyGamificationEvent is a test-friendly version of applyGamificationEvent
+func TestableApplyGamificationEvent(event models.GamificationEvent, log *zap.Logger) ([]models.UserBadge, error) {
+	return applyGamificationEvent(event, log)
+}
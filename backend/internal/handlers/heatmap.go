@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+const (
+	// heatmapDefaultMonths is how many calendar months a heatmap covers by default.
+	heatmapDefaultMonths = 12
+	// maxHeatmapKeywords caps the keywords of a keyword-grouped heatmap, each of which is a query.
+	maxHeatmapKeywords = 10
+)
+
+// heatmapRow is the net spending of one day (and hour, for weekday_hour), optionally of one category.
+type heatmapRow struct {
+	CategoryID      *uint
+	TransactionDate time.Time
+	Hour            *int // null for transactions without a time of day
+	Amount          float64
+	Transactions    int
+}
+
+// loadHeatmapRows sums the user's spending in [start, end] per day, per hour of the day for weekday_hour
+// and per category when byCategory is set. keyword, when given, limits it to descriptions containing it.
+func loadHeatmapRows(userID uint, view string, start, end time.Time, byCategory bool, keyword string) ([]heatmapRow, error) {
+	columns := []string{"transaction_date"}
+	if view == models.HeatmapViewWeekdayHour {
+		columns = append(columns, "HOUR(occurred_at) as hour")
+	}
+	groups := []string{"transaction_date"}
+	if view == models.HeatmapViewWeekdayHour {
+		groups = append(groups, "hour")
+	}
+	if byCategory {
+		columns = append(columns, "category_id")
+		groups = append(groups, "category_id")
+	}
+
+	query := db.DB.Model(&models.Transaction{}).
+		Select(strings.Join(columns, ", ")+", "+netExpenseSumSQL+" as amount, COUNT(*) as transactions").
+		Where("user_id = ? AND type IN ? AND transaction_date >= ? AND transaction_date <= ?",
+			userID, []string{models.TransactionTypeExpense, models.TransactionTypeRefund}, start, end)
+	if keyword != "" {
+		query = query.Where("description LIKE ?", containsPattern(keyword))
+	}
+
+	var rows []heatmapRow
+	err := query.Group(strings.Join(groups, ", ")).Scan(&rows).Error
+	return rows, err
+}
+
+// heatmapSeries folds rows into the cells of view. Cells come in order: by weekday then hour, by day of the
+// month, or by date.
+func heatmapSeries(view string, rows []heatmapRow) models.HeatmapSeries {
+	type cellKey struct {
+		first, second int
+		date          string
+	}
+	cells := make(map[cellKey]*models.HeatmapCell)
+	var keys []cellKey
+	var series models.HeatmapSeries
+
+	for _, r := range rows {
+		date := dateOnly(r.TransactionDate)
+		var key cellKey
+		switch view {
+		case models.HeatmapViewWeekdayHour:
+			if r.Hour == nil {
+				series.Untimed += r.Transactions
+				continue
+			}
+			key = cellKey{first: int(date.Weekday()), second: *r.Hour}
+		case models.HeatmapViewDayOfMonth:
+			key = cellKey{first: date.Day()}
+		default:
+			key = cellKey{date: date.Format("2006-01-02")}
+		}
+
+		cell := cells[key]
+		if cell == nil {
+			cell = &models.HeatmapCell{Date: key.date}
+			switch view {
+			case models.HeatmapViewWeekdayHour:
+				weekday, hour := key.first, key.second
+				cell.Weekday, cell.Hour = &weekday, &hour
+			case models.HeatmapViewDayOfMonth:
+				cell.Day = key.first
+			}
+			cells[key] = cell
+			keys = append(keys, key)
+		}
+		cell.Amount += r.Amount
+		cell.Transactions += r.Transactions
+		series.Total += r.Amount
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].first != keys[j].first {
+			return keys[i].first < keys[j].first
+		}
+		if keys[i].second != keys[j].second {
+			return keys[i].second < keys[j].second
+		}
+		return keys[i].date < keys[j].date
+	})
+	series.Cells = make([]models.HeatmapCell, 0, len(keys))
+	for _, key := range keys {
+		cell := *cells[key]
+		cell.Amount = roundCents(cell.Amount)
+		series.Cells = append(series.Cells, cell)
+	}
+	series.Total = roundCents(series.Total)
+	return series
+}
+
+// categoryHeatmaps splits rows by category into one series each, largest total first.
+func categoryHeatmaps(view string, rows []heatmapRow, names map[uint]string) []models.HeatmapSeries {
+	byCategory := make(map[uint][]heatmapRow)
+	for _, r := range rows {
+		key := uint(0)
+		if r.CategoryID != nil {
+			key = *r.CategoryID
+		}
+		byCategory[key] = append(byCategory[key], r)
+	}
+
+	all := make([]models.HeatmapSeries, 0, len(byCategory))
+	for key, categoryRows := range byCategory {
+		series := heatmapSeries(view, categoryRows)
+		series.Group = "Uncategorized"
+		if key != 0 {
+			id := key
+			series.CategoryID = &id
+			series.Group = categoryName(names, key)
+		}
+		all = append(all, series)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Total != all[j].Total {
+			return all[i].Total > all[j].Total
+		}
+		return all[i].Group < all[j].Group
+	})
+	return all
+}
+
+// GetSpendingHeatmap aggregates the user's spending for heatmaps. Query parameters:
+//   - view: weekday_hour, day_of_month or calendar (default). weekday_hour only counts transactions
+//     recorded with a time of day
+//   - groupBy: category, or keyword with a comma-separated keywords list matched against descriptions;
+//     without it all spending is one series
+//   - startDate, endDate: YYYY-MM-DD (default the last 12 months, the current one included)
+func GetSpendingHeatmap(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	view := c.DefaultQuery("view", models.HeatmapViewCalendar)
+	switch view {
+	case models.HeatmapViewWeekdayHour, models.HeatmapViewDayOfMonth, models.HeatmapViewCalendar:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view must be one of weekday_hour, day_of_month, calendar"})
+		return
+	}
+
+	groupBy := c.Query("groupBy")
+	var keywords []string
+	switch groupBy {
+	case "", models.HeatmapGroupCategory:
+	case models.HeatmapGroupKeyword:
+		for _, keyword := range strings.Split(c.Query("keywords"), ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				keywords = append(keywords, keyword)
+			}
+		}
+		if len(keywords) == 0 || len(keywords) > maxHeatmapKeywords {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "keyword grouping needs between 1 and 10 keywords"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "groupBy must be category or keyword"})
+		return
+	}
+
+	start, end, err := analyticsRange(c, heatmapDefaultMonths)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if db.DB == nil {
+		log.Error("Database connection not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	heatmap := models.Heatmap{
+		View:      view,
+		GroupBy:   groupBy,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Series:    []models.HeatmapSeries{},
+	}
+
+	switch groupBy {
+	case models.HeatmapGroupCategory:
+		rows, err := loadHeatmapRows(userID, view, start, end, true, "")
+		if err != nil {
+			log.Error("Failed to aggregate heatmap", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not build heatmap"})
+			return
+		}
+		names, err := loadCategoryNames(userID)
+		if err != nil {
+			log.Error("Failed to retrieve categories", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not build heatmap"})
+			return
+		}
+		heatmap.Series = categoryHeatmaps(view, rows, names)
+	case models.HeatmapGroupKeyword:
+		for _, keyword := range keywords {
+			rows, err := loadHeatmapRows(userID, view, start, end, false, keyword)
+			if err != nil {
+				log.Error("Failed to aggregate heatmap", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not build heatmap"})
+				return
+			}
+			series := heatmapSeries(view, rows)
+			series.Group = keyword
+			heatmap.Series = append(heatmap.Series, series)
+		}
+	default:
+		rows, err := loadHeatmapRows(userID, view, start, end, false, "")
+		if err != nil {
+			log.Error("Failed to aggregate heatmap", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not build heatmap"})
+			return
+		}
+		series := heatmapSeries(view, rows)
+		series.Group = "All"
+		heatmap.Series = append(heatmap.Series, series)
+	}
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// Test helper functions - exports private functions for testing
+// These are only used by tests and do not affect normal operation
+
+// TestableHeatmapSeries is a test-friendly version of heatmapSeries, taking one transaction per row with
+// OccurredAt supplying the hour
+func TestableHeatmapSeries(view string, transactions []models.Transaction) models.HeatmapSeries {
+	rows := make([]heatmapRow, len(transactions))
+	for i, tx := range transactions {
+		rows[i] = heatmapRow{CategoryID: tx.CategoryID, TransactionDate: tx.TransactionDate, Amount: expenseAmount(tx), Transactions: 1}
+		if tx.OccurredAt != nil {
+			hour := tx.OccurredAt.Hour()
+			rows[i].Hour = &hour
+		}
+	}
+	return heatmapSeries(view, rows)
+}
@@ -0,0 +1,1041 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// maxImportFileSize caps statement uploads
+const maxImportFileSize = 5 * 1024 * 1024
+
+// duplicateSimilarityThreshold is the minimum description similarity (0-1) for a same-amount row
+// dated within a day of an existing transaction to be flagged as its duplicate.
+const duplicateSimilarityThreshold = 0.6
+
+// Statement formats accepted by the import endpoints. QFX is Quicken's branded OFX.
+const (
+	ImportFormatCSV = "csv"
+	ImportFormatOFX = "ofx"
+	ImportFormatQFX = "qfx"
+	ImportFormatQIF = "qif"
+)
+
+// ImportOptions is the format and CSV column mapping sent alongside an upload (multipart form fields).
+// Format is detected from the file extension when omitted. The column mapping is only used for CSV:
+// columns are given by header name, or by 0-based index when the file has no header row.
+// DateFormat accepts patterns such as "YYYY-MM-DD" or "MM/DD/YYYY" as well as Go layouts, and also
+// applies to QIF dates. SignConvention "negative-expense" (default) treats negative amounts as
+// expenses and positive ones as income; "positive-expense" is the reverse.
+type ImportOptions struct {
+	Format            string `form:"format" binding:"omitempty,oneof=csv ofx qfx qif"`
+	DateColumn        string `form:"dateColumn"`
+	AmountColumn      string `form:"amountColumn"`
+	DescriptionColumn string `form:"descriptionColumn"`
+	CategoryColumn    string `form:"categoryColumn"`
+	DateFormat        string `form:"dateFormat"`       // default YYYY-MM-DD
+	DecimalSeparator  string `form:"decimalSeparator"` // "." (default) or ","
+	Delimiter         string `form:"delimiter"`        // default ","; "tab" for TSV
+	NoHeader          bool   `form:"noHeader"`
+	SignConvention    string `form:"signConvention" binding:"omitempty,oneof=negative-expense positive-expense"`
+}
+
+// ImportRow is one parsed statement row, returned in the preview and sent back to commit it.
+type ImportRow struct {
+	File         string  `json:"file,omitempty"`
+	FileIndex    int     `json:"fileIndex"` // position of File among the uploaded files, which may share a name
+	Line         int     `json:"line"`
+	Date         string  `json:"date"` // YYYY-MM-DD
+	Amount       float64 `json:"amount"`
+	Type         string  `json:"type"`
+	Description  string  `json:"description"`
+	ExternalID   string  `json:"externalId,omitempty"` // OFX FITID, or a derived ID for QIF
+	Account      string  `json:"account,omitempty"`    // OFX ACCTID the ExternalID belongs to
+	CategoryID   *uint   `json:"categoryId,omitempty"`
+	CategoryName string  `json:"categoryName,omitempty"`
+	RuleID       *uint   `json:"ruleId,omitempty"` // category rule that set CategoryID
+	Duplicate    bool    `json:"duplicate"`
+	DuplicateOf  *uint   `json:"duplicateOf,omitempty"`
+	// Index in the preview of an earlier row of the same upload that this row repeats
+	DuplicateOfRow *int   `json:"duplicateOfRow,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// ImportSummary counts the rows of a preview.
+type ImportSummary struct {
+	Total      int `json:"total"`
+	New        int `json:"new"`
+	Duplicates int `json:"duplicates"`
+	Errors     int `json:"errors"`
+}
+
+// ImportFileSummary is the preview summary of one uploaded file.
+type ImportFileSummary struct {
+	File    string        `json:"file"`
+	Format  string        `json:"format"`
+	Summary ImportSummary `json:"summary"`
+}
+
+// ImportFileResult counts what a commit did with the rows of one file.
+type ImportFileResult struct {
+	File      string `json:"file"`
+	FileIndex int    `json:"fileIndex"`
+	Added     int    `json:"added"`
+	Skipped   int    `json:"skipped"`
+	Failed    int    `json:"failed"`
+}
+
+// ImportCommitRequest carries the preview rows the user accepted.
+type ImportCommitRequest struct {
+	Rows []ImportRow `json:"rows" binding:"required,min=1,dive"`
+}
+
+// normalizeDateLayout turns a YYYY/MM/DD style pattern into a Go time layout.
+// Strings that already look like Go layouts are returned unchanged.
+func normalizeDateLayout(format string) string {
+	if format == "" {
+		return "2006-01-02"
+	}
+	if strings.Contains(format, "2006") || strings.Contains(format, "06") && strings.Contains(format, "01") {
+		return format
+	}
+	replacer := strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02", "M", "1", "D", "2")
+	return replacer.Replace(strings.ToUpper(format))
+}
+
+// parseAmount parses a bank-formatted amount such as "1.234,56", "$-12.00", "(45.10)" or "12.50-".
+func parseAmount(raw, decimalSeparator string) (float64, error) {
+	s := strings.TrimSpace(raw)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	if strings.HasSuffix(s, "-") {
+		negative = true
+		s = strings.TrimSuffix(s, "-")
+	}
+
+	thousands := ","
+	if decimalSeparator == "," {
+		thousands = "."
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '-':
+			negative = !negative
+		case string(r) == decimalSeparator:
+			b.WriteRune('.')
+		case string(r) == thousands, unicode.IsSpace(r), r == '+':
+			// separators and explicit plus signs carry no value
+		default:
+			// currency symbols and codes are ignored
+			if !unicode.IsLetter(r) && !unicode.IsSymbol(r) {
+				return 0, fmt.Errorf("invalid amount %q", raw)
+			}
+		}
+	}
+	if b.Len() == 0 {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+
+	value, err := strconv.ParseFloat(b.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// normalizeDescription lowercases s and keeps only letters, digits and single spaces.
+func normalizeDescription(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space && b.Len() > 0 {
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// descriptionSimilarity returns 1 - normalized Levenshtein distance between two descriptions.
+func descriptionSimilarity(a, b string) float64 {
+	ra, rb := []rune(normalizeDescription(a)), []rune(normalizeDescription(b))
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	longest := max(len(ra), len(rb))
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// findDuplicate returns the index of the existing transaction most likely to be the same as row
+// (same type and amount, dated within a day, with a similar description), or -1 if there is none.
+func findDuplicate(row ImportRow, existing []models.Transaction) int {
+	date, err := time.Parse("2006-01-02", row.Date)
+	if err != nil {
+		return -1
+	}
+
+	best := -1
+	bestScore := duplicateSimilarityThreshold
+	for i, tx := range existing {
+		if math.Abs(tx.Amount-row.Amount) > 0.005 || tx.Type != row.Type {
+			continue
+		}
+		txDate := time.Date(tx.TransactionDate.Year(), tx.TransactionDate.Month(), tx.TransactionDate.Day(), 0, 0, 0, 0, time.UTC)
+		if diff := math.Abs(txDate.Sub(date).Hours()); diff > 24 {
+			continue
+		}
+		if score := descriptionSimilarity(tx.Description, row.Description); score >= bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// importBatch holds the rows of an upload kept so far, so that later rows repeating one of them
+// (e.g. from overlapping statements) are caught.
+type importBatch struct {
+	rows   []ImportRow
+	refs   map[importExternalRef]int
+	byDate map[string][]int
+}
+
+func newImportBatch(rows []ImportRow) *importBatch {
+	return &importBatch{rows: rows, refs: make(map[importExternalRef]int), byDate: make(map[string][]int)}
+}
+
+// duplicateOf returns the index of the kept row that row i repeats, or -1 if there is none. Rows
+// carrying different external IDs of the same account are distinct transactions by definition.
+func (b *importBatch) duplicateOf(i int) int {
+	row := b.rows[i]
+	if row.ExternalID != "" {
+		if j, ok := b.refs[importExternalRef{Account: row.Account, ID: row.ExternalID}]; ok {
+			return j
+		}
+	}
+	date, err := time.Parse("2006-01-02", row.Date)
+	if err != nil {
+		return -1
+	}
+
+	var candidates []models.Transaction
+	var indexes []int
+	for day := -1; day <= 1; day++ {
+		keptDate := date.AddDate(0, 0, day)
+		for _, j := range b.byDate[keptDate.Format("2006-01-02")] {
+			kept := b.rows[j]
+			if row.ExternalID != "" && kept.ExternalID != "" && row.Account == kept.Account {
+				continue
+			}
+			candidates = append(candidates, models.Transaction{
+				Type:            kept.Type,
+				Amount:          kept.Amount,
+				Description:     kept.Description,
+				TransactionDate: keptDate,
+			})
+			indexes = append(indexes, j)
+		}
+	}
+	if dup := findDuplicate(row, candidates); dup >= 0 {
+		return indexes[dup]
+	}
+	return -1
+}
+
+// keep adds row i to the rows later ones are checked against.
+func (b *importBatch) keep(i int) {
+	row := b.rows[i]
+	if row.ExternalID != "" {
+		b.refs[importExternalRef{Account: row.Account, ID: row.ExternalID}] = i
+	}
+	b.byDate[row.Date] = append(b.byDate[row.Date], i)
+}
+
+// resolveColumn finds the index of a mapped column by header name or 0-based index.
+func resolveColumn(name string, header map[string]int) (int, error) {
+	if idx, ok := header[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return idx, nil
+	}
+	if idx, err := strconv.Atoi(strings.TrimSpace(name)); err == nil && idx >= 0 {
+		return idx, nil
+	}
+	return 0, fmt.Errorf("column %q not found", name)
+}
+
+// parseCSVStatement parses a CSV statement into import rows using opts. Row-level problems are
+// reported on the row; only problems with the file or mapping itself return an error.
+func parseCSVStatement(r io.Reader, opts ImportOptions) ([]ImportRow, error) {
+	if opts.DateColumn == "" || opts.AmountColumn == "" || opts.DescriptionColumn == "" {
+		return nil, errors.New("dateColumn, amountColumn and descriptionColumn are required for CSV files")
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if opts.Delimiter != "" {
+		if opts.Delimiter == `\t` || opts.Delimiter == "tab" {
+			reader.Comma = '\t'
+		} else {
+			reader.Comma = []rune(opts.Delimiter)[0]
+		}
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV file is empty")
+	}
+
+	header := make(map[string]int)
+	dataStart := 0
+	if !opts.NoHeader {
+		for i, name := range records[0] {
+			header[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+		}
+		dataStart = 1
+	}
+
+	dateIdx, err := resolveColumn(opts.DateColumn, header)
+	if err != nil {
+		return nil, err
+	}
+	amountIdx, err := resolveColumn(opts.AmountColumn, header)
+	if err != nil {
+		return nil, err
+	}
+	descIdx, err := resolveColumn(opts.DescriptionColumn, header)
+	if err != nil {
+		return nil, err
+	}
+	categoryIdx := -1
+	if opts.CategoryColumn != "" {
+		if categoryIdx, err = resolveColumn(opts.CategoryColumn, header); err != nil {
+			return nil, err
+		}
+	}
+
+	layout := normalizeDateLayout(opts.DateFormat)
+	decimal := opts.DecimalSeparator
+	if decimal == "" {
+		decimal = "."
+	}
+	if decimal != "." && decimal != "," {
+		return nil, errors.New("decimal separator must be \".\" or \",\"")
+	}
+
+	var rows []ImportRow
+	for i := dataStart; i < len(records); i++ {
+		record := records[i]
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		row := ImportRow{Line: i + 1}
+		field := func(idx int) string {
+			if idx < 0 || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		row.Description = field(descIdx)
+		if categoryIdx >= 0 {
+			row.CategoryName = field(categoryIdx)
+		}
+
+		date, err := time.Parse(layout, field(dateIdx))
+		if err != nil {
+			row.Error = fmt.Sprintf("invalid date %q", field(dateIdx))
+			rows = append(rows, row)
+			continue
+		}
+		row.Date = date.Format("2006-01-02")
+
+		amount, err := parseAmount(field(amountIdx), decimal)
+		if err != nil {
+			row.Error = err.Error()
+			rows = append(rows, row)
+			continue
+		}
+		if amount == 0 {
+			row.Error = "amount must not be zero"
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Type = models.TransactionTypeExpense
+		if opts.SignConvention == "positive-expense" {
+			if amount < 0 {
+				row.Type = models.TransactionTypeIncome
+			}
+		} else if amount > 0 {
+			row.Type = models.TransactionTypeIncome
+		}
+		row.Amount = math.Round(math.Abs(amount)*100) / 100
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// detectImportFormat picks the statement format from the file name, falling back to the content.
+func detectImportFormat(filename string, head []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ofx":
+		return ImportFormatOFX
+	case ".qfx":
+		return ImportFormatQFX
+	case ".qif":
+		return ImportFormatQIF
+	case ".csv", ".tsv":
+		return ImportFormatCSV
+	}
+	upper := strings.ToUpper(string(head))
+	switch {
+	case strings.Contains(upper, "OFXHEADER") || strings.Contains(upper, "<OFX>"):
+		return ImportFormatOFX
+	case strings.HasPrefix(strings.TrimSpace(upper), "!TYPE:") || strings.HasPrefix(strings.TrimSpace(upper), "!ACCOUNT"):
+		return ImportFormatQIF
+	}
+	return ImportFormatCSV
+}
+
+// parseStatement parses an uploaded statement in the given format.
+func parseStatement(r io.Reader, format string, opts ImportOptions) ([]ImportRow, error) {
+	switch format {
+	case ImportFormatOFX, ImportFormatQFX:
+		return parseOFXStatement(r)
+	case ImportFormatQIF:
+		return parseQIFStatement(r, opts.DateFormat)
+	default:
+		return parseCSVStatement(r, opts)
+	}
+}
+
+// importExternalRef identifies an imported row: external IDs are only unique within an account.
+type importExternalRef struct {
+	Account string
+	ID      string
+}
+
+// existingExternalIDs maps the given external refs that the user already has transactions for to the
+// ID of that transaction. Deleted transactions count too, so re-importing a statement does not bring
+// them back.
+func existingExternalIDs(conn *gorm.DB, userID uint, refs []importExternalRef) (map[importExternalRef]uint, error) {
+	found := make(map[importExternalRef]uint)
+	if len(refs) == 0 {
+		return found, nil
+	}
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref.ID
+	}
+
+	var existing []models.Transaction
+	if err := conn.Unscoped().Select("id", "external_account", "external_id").
+		Where("user_id = ? AND external_id IN ?", userID, ids).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	for _, tx := range existing {
+		if tx.ExternalID != nil {
+			found[importExternalRef{Account: tx.ExternalAccount, ID: *tx.ExternalID}] = tx.ID
+		}
+	}
+	return found, nil
+}
+
+// categorizeImportRows applies the user's category rules to the valid rows that have no category yet.
+// Rules are only loaded when such a row exists.
+func categorizeImportRows(userID uint, rows []ImportRow, log *zap.Logger) error {
+	var rules []categoryRuleMatcher
+	loaded := false
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" || row.CategoryID != nil {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", row.Date)
+		if err != nil {
+			continue
+		}
+		if !loaded {
+			if rules, err = loadCategoryRules(userID, log); err != nil {
+				return err
+			}
+			loaded = true
+		}
+		rule := matchCategoryRule(rules, models.Transaction{
+			Type:            row.Type,
+			Amount:          row.Amount,
+			Description:     row.Description,
+			TransactionDate: dateOnly(date),
+		})
+		if rule != nil {
+			categoryID, ruleID := rule.CategoryID, rule.ID
+			row.CategoryID = &categoryID
+			row.RuleID = &ruleID
+		}
+	}
+	return nil
+}
+
+// transactionsAround loads the user's transactions dated within a day of the valid rows, the ones
+// findDuplicate may match them with.
+func transactionsAround(conn *gorm.DB, userID uint, rows []ImportRow) ([]models.Transaction, error) {
+	var minDate, maxDate string
+	for _, row := range rows {
+		if row.Error != "" {
+			continue
+		}
+		if minDate == "" || row.Date < minDate {
+			minDate = row.Date
+		}
+		if row.Date > maxDate {
+			maxDate = row.Date
+		}
+	}
+
+	var existing []models.Transaction
+	if minDate == "" {
+		return existing, nil
+	}
+	from, _ := time.Parse("2006-01-02", minDate)
+	to, _ := time.Parse("2006-01-02", maxDate)
+	if err := conn.Where("user_id = ? AND transaction_date >= ? AND transaction_date <= ?",
+		userID, dateOnly(from.AddDate(0, 0, -1)), dateOnly(to.AddDate(0, 0, 1))).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// annotateImportRows resolves category names and flags duplicates against the user's existing transactions.
+// Rows carrying an external ID that was already imported are always duplicates; other rows are matched
+// on amount, date and description. A row repeating an earlier row of the same upload is flagged as well.
+func annotateImportRows(userID uint, rows []ImportRow) error {
+	var externalIDs []importExternalRef
+	needCategories := false
+	for _, row := range rows {
+		if row.Error != "" {
+			continue
+		}
+		if row.CategoryName != "" {
+			needCategories = true
+		}
+		if row.ExternalID != "" {
+			externalIDs = append(externalIDs, importExternalRef{Account: row.Account, ID: row.ExternalID})
+		}
+	}
+
+	categoryIDs := make(map[string]uint)
+	if needCategories {
+		var categories []models.Category
+		if err := db.DB.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+			return err
+		}
+		for _, cat := range categories {
+			categoryIDs[strings.ToLower(cat.Name)] = cat.ID
+		}
+	}
+
+	imported, err := existingExternalIDs(db.DB, userID, externalIDs)
+	if err != nil {
+		return err
+	}
+
+	existing, err := transactionsAround(db.DB, userID, rows)
+	if err != nil {
+		return err
+	}
+
+	batch := newImportBatch(rows)
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		if row.CategoryName != "" {
+			if id, ok := categoryIDs[strings.ToLower(row.CategoryName)]; ok {
+				catID := id
+				row.CategoryID = &catID
+			}
+		}
+		if id, ok := imported[importExternalRef{Account: row.Account, ID: row.ExternalID}]; ok {
+			row.Duplicate = true
+			row.DuplicateOf = &id
+			continue
+		}
+		if dup := findDuplicate(*row, existing); dup >= 0 {
+			row.Duplicate = true
+			row.DuplicateOf = &existing[dup].ID
+			continue
+		}
+		if j := batch.duplicateOf(i); j >= 0 {
+			row.Duplicate = true
+			row.DuplicateOfRow = &j
+			continue
+		}
+		batch.keep(i)
+	}
+	return nil
+}
+
+// summarizeImportRows counts new, duplicate and invalid rows.
+func summarizeImportRows(rows []ImportRow) ImportSummary {
+	summary := ImportSummary{Total: len(rows)}
+	for _, row := range rows {
+		switch {
+		case row.Error != "":
+			summary.Errors++
+		case row.Duplicate:
+			summary.Duplicates++
+		default:
+			summary.New++
+		}
+	}
+	return summary
+}
+
+// recalcBudgetsForTransactions recalculates every budget touched by txs exactly once.
+func recalcBudgetsForTransactions(userID uint, txs []models.Transaction, log *zap.Logger) {
+	if len(txs) == 0 {
+		return
+	}
+
+	minDate, maxDate := txs[0].TransactionDate, txs[0].TransactionDate
+	for _, tx := range txs {
+		if tx.TransactionDate.Before(minDate) {
+			minDate = tx.TransactionDate
+		}
+		if tx.TransactionDate.After(maxDate) {
+			maxDate = tx.TransactionDate
+		}
+	}
+
+	var budgets []models.Budget
+	err := db.DB.Where("user_id = ? AND start_date <= ? AND end_date >= ?", userID, maxDate, minDate).Find(&budgets).Error
+	if err != nil {
+		log.Error("Failed to find budgets for import recalc", zap.Error(err))
+		return
+	}
+
+	// The category tree is only needed when a category budget may cover a subcategory's spending
+	needTree := false
+	for _, budget := range budgets {
+		for _, tx := range txs {
+			if budget.CategoryID != nil && tx.CategoryID != nil && *budget.CategoryID != *tx.CategoryID {
+				needTree = true
+			}
+		}
+	}
+	var parents map[uint]*uint
+	if needTree {
+		if parents, err = loadCategoryParents(userID); err != nil {
+			log.Error("Failed to load categories for import recalc", zap.Error(err))
+			return
+		}
+	}
+
+	for i := range budgets {
+		budget := &budgets[i]
+		for _, tx := range txs {
+			if tx.TransactionDate.Before(budget.StartDate) || tx.TransactionDate.After(budget.EndDate) {
+				continue
+			}
+			covered := (budget.CategoryID == nil && tx.CategoryID == nil) ||
+				(budget.CategoryID != nil && tx.CategoryID != nil && categoryWithin(*tx.CategoryID, *budget.CategoryID, parents))
+			if covered {
+				recalcBudgetRemaining(budget, log)
+				break
+			}
+		}
+	}
+}
+
+// ImportTransactions parses one or more uploaded statements ("file" form fields) in CSV, OFX/QFX or QIF
+// format and returns a preview with duplicates flagged and category rules applied, along with a summary
+// per file. Nothing is written until the preview is committed.
+func ImportTransactions(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No statement file provided"})
+		return
+	}
+	files := form.File["file"]
+
+	var opts ImportOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		log.Warn("Invalid import options", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows []ImportRow
+	fileSummaries := make([]ImportFileSummary, 0, len(files))
+	for index, file := range files {
+		if file.Size > maxImportFileSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: file size exceeds 5MB limit", file.Filename)})
+			return
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			log.Error("Failed to open uploaded statement", zap.String("file", file.Filename), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read statement"})
+			return
+		}
+		content, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			log.Error("Failed to read uploaded statement", zap.String("file", file.Filename), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read statement"})
+			return
+		}
+
+		format := opts.Format
+		if format == "" {
+			format = detectImportFormat(file.Filename, content)
+		}
+
+		fileRows, err := parseStatement(bytes.NewReader(content), format, opts)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s", file.Filename, err.Error())})
+			return
+		}
+		for i := range fileRows {
+			fileRows[i].File = file.Filename
+			fileRows[i].FileIndex = index
+		}
+		rows = append(rows, fileRows...)
+		fileSummaries = append(fileSummaries, ImportFileSummary{File: file.Filename, Format: format})
+	}
+
+	if err := annotateImportRows(userID, rows); err != nil {
+		log.Error("Failed to check import rows against existing transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check for duplicates"})
+		return
+	}
+	if err := categorizeImportRows(userID, rows, log); err != nil {
+		log.Error("Failed to apply category rules to import rows", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not apply category rules"})
+		return
+	}
+
+	for i := range fileSummaries {
+		var fileRows []ImportRow
+		for _, row := range rows {
+			if row.FileIndex == i {
+				fileRows = append(fileRows, row)
+			}
+		}
+		fileSummaries[i].Summary = summarizeImportRows(fileRows)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rows":    rows,
+		"summary": summarizeImportRows(rows),
+		"files":   fileSummaries,
+	})
+}
+
+// ownedImportCategories returns which of the categories the rows were committed with belong to the user.
+func ownedImportCategories(userID uint, rows []ImportRow) (map[uint]bool, error) {
+	owned := make(map[uint]bool)
+	var ids []uint
+	for _, row := range rows {
+		if row.CategoryID != nil {
+			ids = append(ids, *row.CategoryID)
+		}
+	}
+	if len(ids) == 0 {
+		return owned, nil
+	}
+
+	var found []uint
+	if err := db.DB.Model(&models.Category{}).Where("user_id = ? AND id IN ?", userID, ids).Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		owned[id] = true
+	}
+	return owned, nil
+}
+
+// importRowTransaction validates a committed preview row and converts it to a transaction. categories are
+// the user's own categories among those the rows were committed with.
+func importRowTransaction(userID uint, row ImportRow, categories map[uint]bool) (models.Transaction, error) {
+	date, err := time.Parse("2006-01-02", row.Date)
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("invalid date on line %d", row.Line)
+	}
+	if row.Amount <= 0 {
+		return models.Transaction{}, fmt.Errorf("invalid amount on line %d", row.Line)
+	}
+	txType := row.Type
+	switch txType {
+	case "":
+		txType = models.TransactionTypeExpense
+	case models.TransactionTypeExpense, models.TransactionTypeIncome, models.TransactionTypeRefund, models.TransactionTypeTransfer:
+	default:
+		return models.Transaction{}, fmt.Errorf("invalid type on line %d", row.Line)
+	}
+	if row.CategoryID != nil && !categories[*row.CategoryID] {
+		return models.Transaction{}, fmt.Errorf("unknown category on line %d", row.Line)
+	}
+
+	tx := models.Transaction{
+		UserID:          userID,
+		CategoryID:      row.CategoryID,
+		Type:            txType,
+		Amount:          row.Amount,
+		Description:     row.Description,
+		TransactionDate: dateOnly(date),
+	}
+	if row.ExternalID != "" {
+		externalID := row.ExternalID
+		tx.ExternalID = &externalID
+		tx.ExternalAccount = row.Account
+	}
+	return tx, nil
+}
+
+// CommitImport inserts the accepted preview rows in a single DB transaction and then recalculates
+// each affected budget once. Rows whose external ID was already imported are skipped, so committing
+// the same statement twice adds nothing. Rows not marked as accepted duplicates are checked for
+// duplicates again, against transactions added since the preview and against each other, and
+// skipped if one is found; invalid rows are reported as failed. Counts are returned overall and per
+// uploaded file.
+func CommitImport(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var req ImportCommitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid import commit data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Rows committed without a category (e.g. by a client that skipped the preview) still go through the rules
+	if err := categorizeImportRows(userID, req.Rows, log); err != nil {
+		log.Error("Failed to apply category rules to import rows", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not apply category rules"})
+		return
+	}
+
+	// The rows' categories come from the client; only the user's own can be attached
+	categories, err := ownedImportCategories(userID, req.Rows)
+	if err != nil {
+		log.Error("Failed to check import row categories", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check categories"})
+		return
+	}
+
+	// Uploaded files may share a name, so they are told apart by their position in the upload
+	type fileKey struct {
+		index int
+		file  string
+	}
+	var results []ImportFileResult
+	resultIndex := make(map[fileKey]int)
+	resultFor := func(row ImportRow) *ImportFileResult {
+		key := fileKey{row.FileIndex, row.File}
+		i, ok := resultIndex[key]
+		if !ok {
+			i = len(results)
+			resultIndex[key] = i
+			results = append(results, ImportFileResult{File: row.File, FileIndex: row.FileIndex})
+		}
+		return &results[i]
+	}
+
+	var failures []string
+	var txs []models.Transaction
+	var txRows []ImportRow
+	var externalIDs []importExternalRef
+	for _, row := range req.Rows {
+		resultFor(row)
+		tx, err := importRowTransaction(userID, row, categories)
+		if err != nil {
+			resultFor(row).Failed++
+			failures = append(failures, err.Error())
+			continue
+		}
+		txs = append(txs, tx)
+		txRows = append(txRows, row)
+		if row.ExternalID != "" {
+			externalIDs = append(externalIDs, importExternalRef{Account: row.Account, ID: row.ExternalID})
+		}
+	}
+
+	var added []models.Transaction
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		imported, err := existingExternalIDs(tx, userID, externalIDs)
+		if err != nil {
+			return err
+		}
+		existing, err := transactionsAround(tx, userID, txRows)
+		if err != nil {
+			return err
+		}
+
+		batch := newImportBatch(txRows)
+		var plain []models.Transaction
+		var plainRows []ImportRow
+		for i, t := range txs {
+			row := txRows[i]
+			skip := false
+			if t.ExternalID != nil {
+				ref := importExternalRef{Account: t.ExternalAccount, ID: *t.ExternalID}
+				_, known := imported[ref]
+				_, repeated := batch.refs[ref]
+				skip = known || repeated
+			}
+			if !skip && !row.Duplicate {
+				skip = findDuplicate(row, existing) >= 0 || batch.duplicateOf(i) >= 0
+			}
+			if skip {
+				resultFor(row).Skipped++
+				continue
+			}
+			batch.keep(i)
+
+			if t.ExternalID == nil {
+				plain = append(plain, t)
+				plainRows = append(plainRows, row)
+				continue
+			}
+
+			// A concurrent commit of the same statement may have inserted the row since the check;
+			// the unique index turns that into a skip
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&t)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				resultFor(row).Skipped++
+				continue
+			}
+			added = append(added, t)
+			resultFor(row).Added++
+		}
+
+		if len(plain) == 0 {
+			return nil
+		}
+		if err := tx.Create(&plain).Error; err != nil {
+			return err
+		}
+		added = append(added, plain...)
+		for _, row := range plainRows {
+			resultFor(row).Added++
+		}
+		return nil
+	}); err != nil {
+		log.Error("Failed to commit imported transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not import transactions"})
+		return
+	}
+
+	recalcBudgetsForTransactions(userID, added, log)
+
+	notificationHub.Publish(userID, models.StreamEventImportCompleted, gin.H{
+		"added":   len(added),
+		"skipped": len(txs) - len(added),
+		"failed":  len(failures),
+		"files":   results,
+	})
+
+	response := gin.H{
+		"message": "Transactions imported successfully",
+		"added":   len(added),
+		"skipped": len(txs) - len(added),
+		"failed":  len(failures),
+		"files":   results,
+	}
+	if len(failures) > 0 {
+		response["errors"] = failures
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// Test helper functions - exports private functions for testing
+
+// TestableParseAmount is a test-friendly version of parseAmount
+func TestableParseAmount(raw, decimalSeparator string) (float64, error) {
+	return parseAmount(raw, decimalSeparator)
+}
+
+// TestableDescriptionSimilarity is a test-friendly version of descriptionSimilarity
+func TestableDescriptionSimilarity(a, b string) float64 {
+	return descriptionSimilarity(a, b)
+}
+
+// TestableParseCSVStatement is a test-friendly version of parseCSVStatement
+func TestableParseCSVStatement(r io.Reader, opts ImportOptions) ([]ImportRow, error) {
+	return parseCSVStatement(r, opts)
+}
+
+// TestableParseOFXStatement is a test-friendly version of parseOFXStatement
+func TestableParseOFXStatement(r io.Reader) ([]ImportRow, error) {
+	return parseOFXStatement(r)
+}
+
+// TestableParseQIFStatement is a test-friendly version of parseQIFStatement
+func TestableParseQIFStatement(r io.Reader, dateFormat string) ([]ImportRow, error) {
+	return parseQIFStatement(r, dateFormat)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+var (
+	ofxTransactionStart = regexp.MustCompile(`(?i)<STMTTRN>`)
+	ofxTransactionEnd   = regexp.MustCompile(`(?i)</STMTTRN>|</BANKTRANLIST>`)
+	ofxFieldValue       = regexp.MustCompile(`(?is)<([A-Z0-9.]+)>([^<\r\n]*)`)
+	ofxAccountID        = regexp.MustCompile(`(?is)<(?:BANK|CC)ACCTFROM>.*?<ACCTID>([^<\r\n]*)`)
+)
+
+// ofxTransactionBlocks returns the body of each <STMTTRN> in content with its offset. SGML statements
+// may leave the tag unclosed, so a block also ends where the next one starts.
+func ofxTransactionBlocks(content string) (blocks []string, offsets []int) {
+	starts := ofxTransactionStart.FindAllStringIndex(content, -1)
+	for i, start := range starts {
+		end := len(content)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		block := content[start[1]:end]
+		if loc := ofxTransactionEnd.FindStringIndex(block); loc != nil {
+			block = block[:loc[0]]
+		}
+		blocks = append(blocks, block)
+		offsets = append(offsets, start[0])
+	}
+	return blocks, offsets
+}
+
+// ofxFields extracts the leaf <TAG>value pairs of an OFX block. It handles both
+// SGML (OFX 1.x, unclosed tags) and XML (OFX 2.x) statements.
+func ofxFields(block string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range ofxFieldValue.FindAllStringSubmatch(block, -1) {
+		value := strings.TrimSpace(m[2])
+		if value == "" {
+			continue
+		}
+		fields[strings.ToUpper(m[1])] = unescapeOFX(value)
+	}
+	return fields
+}
+
+// unescapeOFX decodes the entities OFX allows in text fields.
+func unescapeOFX(s string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ").Replace(s)
+}
+
+// parseOFXDate parses an OFX date such as 20240131, 20240131120000 or 20240131120000.000[-5:EST].
+func parseOFXDate(raw string) (time.Time, error) {
+	if len(raw) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", raw)
+	}
+	return time.Parse("20060102", raw[:8])
+}
+
+// parseOFXStatement parses the transactions of an OFX or QFX statement. The bank's FITID is kept
+// as the row's ExternalID, with the ACCTID of its statement as Account, so re-imports can be skipped.
+func parseOFXStatement(r io.Reader) ([]ImportRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read OFX: %w", err)
+	}
+	content := string(data)
+	if !strings.Contains(strings.ToUpper(content), "<OFX>") {
+		return nil, errors.New("file is not an OFX statement")
+	}
+
+	// A file may hold several statements; each transaction belongs to the account declared before it
+	accounts := ofxAccountID.FindAllStringSubmatchIndex(content, -1)
+	accountAt := func(pos int) string {
+		account := ""
+		for _, a := range accounts {
+			if a[0] > pos {
+				break
+			}
+			account = strings.TrimSpace(content[a[2]:a[3]])
+		}
+		return account
+	}
+
+	var rows []ImportRow
+	blocks, offsets := ofxTransactionBlocks(content)
+	for i, block := range blocks {
+		fields := ofxFields(block)
+		row := ImportRow{
+			Line:       strings.Count(content[:offsets[i]], "\n") + 1,
+			ExternalID: fields["FITID"],
+			Account:    accountAt(offsets[i]),
+		}
+
+		row.Description = fields["NAME"]
+		if memo := fields["MEMO"]; memo != "" {
+			if row.Description == "" {
+				row.Description = memo
+			} else if !strings.EqualFold(memo, row.Description) {
+				row.Description += " - " + memo
+			}
+		}
+
+		if row.ExternalID == "" {
+			row.Error = fmt.Sprintf("transaction %d has no FITID", i+1)
+			rows = append(rows, row)
+			continue
+		}
+
+		date, err := parseOFXDate(fields["DTPOSTED"])
+		if err != nil {
+			row.Error = err.Error()
+			rows = append(rows, row)
+			continue
+		}
+		row.Date = date.Format("2006-01-02")
+
+		amount, err := parseAmount(fields["TRNAMT"], ".")
+		if err != nil || amount == 0 {
+			row.Error = fmt.Sprintf("invalid amount %q", fields["TRNAMT"])
+			rows = append(rows, row)
+			continue
+		}
+		row.Amount = math.Round(math.Abs(amount)*100) / 100
+
+		switch {
+		case strings.EqualFold(fields["TRNTYPE"], "XFER"):
+			row.Type = models.TransactionTypeTransfer
+		case amount > 0:
+			row.Type = models.TransactionTypeIncome
+		default:
+			row.Type = models.TransactionTypeExpense
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("no transactions found in OFX statement")
+	}
+	return rows, nil
+}
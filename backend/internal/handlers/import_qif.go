@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// qifDateLayouts are tried in order when no explicit date format is given. QIF files from US banks
+// use month-first dates, often with an apostrophe before a two-digit year (1/31'24).
+var qifDateLayouts = []string{"1/2/2006", "1/2/06", "2006-01-02", "01-02-2006", "1-2-06", "1.2.2006"}
+
+// parseQIFDate parses a QIF date, using layout when given.
+func parseQIFDate(raw, layout string) (time.Time, error) {
+	value := strings.TrimSpace(strings.ReplaceAll(raw, "' ", "/"))
+	value = strings.ReplaceAll(value, "'", "/")
+	value = strings.ReplaceAll(value, " ", "")
+
+	if layout != "" {
+		return time.Parse(normalizeDateLayout(layout), value)
+	}
+	for _, l := range qifDateLayouts {
+		if t, err := time.Parse(l, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", raw)
+}
+
+// qifExternalID derives a stable ID for a QIF record, which has no bank-assigned ID of its own.
+// seen counts identical records earlier in the same file so that two equal purchases stay distinct.
+func qifExternalID(date string, amount float64, payee, number string, seen int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%.2f|%s|%s|%d", date, amount, strings.ToLower(payee), number, seen)))
+	return "qif:" + hex.EncodeToString(sum[:])
+}
+
+// parseQIFStatement parses a bank or credit card QIF file. Records are terminated by "^";
+// D is the date, T/U the amount, P the payee, M the memo, L the category and N the check number.
+func parseQIFStatement(r io.Reader, dateFormat string) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	var rows []ImportRow
+	record := map[byte]string{}
+	recordLine := 0
+	lineNo := 0
+	seen := make(map[string]int)
+
+	flush := func() {
+		if len(record) == 0 {
+			return
+		}
+		row := ImportRow{Line: recordLine}
+		defer func() {
+			rows = append(rows, row)
+			record = map[byte]string{}
+		}()
+
+		row.Description = record['P']
+		if memo := record['M']; memo != "" {
+			if row.Description == "" {
+				row.Description = memo
+			} else if !strings.EqualFold(memo, row.Description) {
+				row.Description += " - " + memo
+			}
+		}
+		// [Transfer Account] categories mark transfers between the user's own accounts
+		category := record['L']
+		isTransfer := strings.HasPrefix(category, "[") && strings.HasSuffix(category, "]")
+		if !isTransfer {
+			row.CategoryName = category
+		}
+
+		date, err := parseQIFDate(record['D'], dateFormat)
+		if err != nil {
+			row.Error = err.Error()
+			return
+		}
+		row.Date = date.Format("2006-01-02")
+
+		rawAmount := record['T']
+		if rawAmount == "" {
+			rawAmount = record['U']
+		}
+		amount, err := parseAmount(rawAmount, ".")
+		if err != nil || amount == 0 {
+			row.Error = fmt.Sprintf("invalid amount %q", rawAmount)
+			return
+		}
+		row.Amount = math.Round(math.Abs(amount)*100) / 100
+
+		switch {
+		case isTransfer:
+			row.Type = models.TransactionTypeTransfer
+		case amount > 0:
+			row.Type = models.TransactionTypeIncome
+		default:
+			row.Type = models.TransactionTypeExpense
+		}
+
+		key := fmt.Sprintf("%s|%.2f|%s|%s", row.Date, amount, strings.ToLower(record['P']), record['N'])
+		row.ExternalID = qifExternalID(row.Date, amount, record['P'], record['N'], seen[key])
+		seen[key]++
+	}
+
+	inTransactions := true
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == '!' {
+			header := strings.ToLower(line)
+			// Only bank, cash and card registers hold transactions; skip account lists, classes etc.
+			inTransactions = strings.HasPrefix(header, "!type:bank") || strings.HasPrefix(header, "!type:cash") ||
+				strings.HasPrefix(header, "!type:ccard") || strings.HasPrefix(header, "!type:oth")
+			continue
+		}
+		if !inTransactions {
+			continue
+		}
+		if line[0] == '^' {
+			flush()
+			continue
+		}
+		if len(record) == 0 {
+			recordLine = lineNo
+		}
+		code := line[0]
+		if _, exists := record[code]; !exists {
+			record[code] = strings.TrimSpace(line[1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read QIF: %w", err)
+	}
+	flush()
+
+	if len(rows) == 0 {
+		return nil, errors.New("no transactions found in QIF file")
+	}
+	return rows, nil
+}
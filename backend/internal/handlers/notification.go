@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// Page sizes for GetNotifications
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+// Notify posts a notification to a user's inbox and pushes it to their open streams. payload, when not
+// nil, is stored as JSON for the client. Budget, gamification, security and anomaly events are all posted
+// through it.
+func Notify(userID uint, notificationType, severity, title, message string, payload interface{}) (*models.Notification, error) {
+	notification := models.Notification{
+		UserID:   userID,
+		Type:     notificationType,
+		Severity: severity,
+		Title:    title,
+		Message:  message,
+	}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		notification.Payload = data
+	}
+
+	if err := db.DB.Create(&notification).Error; err != nil {
+		return nil, err
+	}
+	notificationHub.Publish(userID, models.StreamEventNotification, notification)
+	return &notification, nil
+}
+
+// notify is Notify for producers whose own work must not fail over a notification: errors are only logged.
+func notify(log *zap.Logger, userID uint, notificationType, severity, title, message string, payload interface{}) {
+	if _, err := Notify(userID, notificationType, severity, title, message, payload); err != nil {
+		log.Warn("Failed to post notification", zap.Uint("userID", userID), zap.String("type", notificationType), zap.Error(err))
+	}
+}
+
+// anomalyTitles heads the notification of each anomaly kind.
+var anomalyTitles = map[string]string{
+	models.AnomalyKindOutlier:            "Unusually large purchase",
+	models.AnomalyKindFirstLargePurchase: "Large first-time purchase",
+	models.AnomalyKindDuplicate:          "Possible duplicate charge",
+	models.AnomalyKindCategoryMonth:      "Unusual month of spending",
+}
+
+// NotifyAnomalies is an AnomalyListener posting one warning per anomaly, with the anomaly as payload.
+func NotifyAnomalies(userID uint, anomalies []models.Anomaly, log *zap.Logger) {
+	for _, anomaly := range anomalies {
+		notify(log, userID, models.NotificationTypeAnomaly, models.NotificationSeverityWarning,
+			anomalyTitles[anomaly.Kind], anomaly.Message, anomaly)
+	}
+}
+
+// unreadNotificationCount counts the unread notifications in the user's inbox; archived ones don't count.
+func unreadNotificationCount(userID uint) (int64, error) {
+	var count int64
+	err := db.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND archived_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// findUserNotification loads notification :id of the user, answering 404 when there is none.
+func findUserNotification(c *gin.Context, userID uint) (*models.Notification, bool) {
+	var notification models.Notification
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return nil, false
+	}
+	return &notification, true
+}
+
+// GetNotifications lists the user's notifications, newest first, with the unread count. Query params:
+// unread=true for unread ones only, archived=true for the archive instead of the inbox, and limit
+// (default 50, at most 200).
+func GetNotifications(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	limit := defaultNotificationLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxNotificationLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = parsed
+	}
+	var unreadOnly, archived bool
+	if raw := c.Query("unread"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unread must be true or false"})
+			return
+		}
+		unreadOnly = parsed
+	}
+	if raw := c.Query("archived"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "archived must be true or false"})
+			return
+		}
+		archived = parsed
+	}
+
+	query := db.DB.Where("user_id = ?", userID)
+	if archived {
+		query = query.Where("archived_at IS NOT NULL")
+	} else {
+		query = query.Where("archived_at IS NULL")
+	}
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	notifications := []models.Notification{}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		log.Error("Failed to retrieve notifications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve notifications"})
+		return
+	}
+
+	unread, err := unreadNotificationCount(userID)
+	if err != nil {
+		log.Error("Failed to count unread notifications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unreadCount":   unread,
+	})
+}
+
+// GetUnreadNotificationCount reports how many notifications in the user's inbox are unread.
+func GetUnreadNotificationCount(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	unread, err := unreadNotificationCount(userID)
+	if err != nil {
+		log.Error("Failed to count unread notifications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not count notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unreadCount": unread})
+}
+
+// MarkNotificationRead marks notification :id as read. Marking it again keeps the first read time.
+func MarkNotificationRead(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	notification, ok := findUserNotification(c, userID)
+	if !ok {
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := db.DB.Model(notification).Update("read_at", now).Error; err != nil {
+			log.Error("Failed to mark notification read", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update notification"})
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	unread, err := unreadNotificationCount(userID)
+	if err != nil {
+		log.Error("Failed to count unread notifications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not count notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notification": notification,
+		"unreadCount":  unread,
+	})
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user as read, archived ones included.
+func MarkAllNotificationsRead(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	result := db.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		log.Error("Failed to mark notifications read", zap.Error(result.Error))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Notifications marked as read",
+		"updated":     result.RowsAffected,
+		"unreadCount": 0,
+	})
+}
+
+// ArchiveNotification moves notification :id out of the inbox into the archive.
+func ArchiveNotification(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	notification, ok := findUserNotification(c, userID)
+	if !ok {
+		return
+	}
+
+	if notification.ArchivedAt == nil {
+		now := time.Now()
+		if err := db.DB.Model(notification).Update("archived_at", now).Error; err != nil {
+			log.Error("Failed to archive notification", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update notification"})
+			return
+		}
+		notification.ArchivedAt = &now
+	}
+
+	unread, err := unreadNotificationCount(userID)
+	if err != nil {
+		log.Error("Failed to count unread notifications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not count notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notification": notification,
+		"unreadCount":  unread,
+	})
+}
+
+// DeleteNotification removes notification :id for good.
+func DeleteNotification(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	notification, ok := findUserNotification(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Delete(notification).Error; err != nil {
+		log.Error("Failed to delete notification", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete notification"})
+		return
+	}
+
+	unread, err := unreadNotificationCount(userID)
+	if err != nil {
+		log.Error("Failed to count unread notifications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not count notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Notification deleted successfully",
+		"unreadCount": unread,
+	})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+const (
+	// streamBufferSize is how many events a connection may fall behind before new ones are dropped for it.
+	streamBufferSize = 32
+	// streamHeartbeat is how often idle streams are pinged, so proxies don't close them.
+	streamHeartbeat = 25 * time.Second
+	// streamWriteTimeout bounds each write to a WebSocket.
+	streamWriteTimeout = 10 * time.Second
+)
+
+// StreamBackend carries stream events between the replicas of the server. LocalStreamBackend only reaches
+// this process; running more than one replica needs a backend they share, e.g. on Redis pub/sub.
+type StreamBackend interface {
+	// Publish sends event to the subscribers of every replica, this one included.
+	Publish(event models.StreamEvent) error
+	// Subscribe has deliver called with every event published by any replica.
+	Subscribe(deliver func(event models.StreamEvent))
+}
+
+// LocalStreamBackend is an in-process StreamBackend for a single instance.
+type LocalStreamBackend struct {
+	mu       sync.RWMutex
+	delivers []func(event models.StreamEvent)
+}
+
+// NewLocalStreamBackend returns an in-process StreamBackend.
+func NewLocalStreamBackend() *LocalStreamBackend {
+	return &LocalStreamBackend{}
+}
+
+// Publish hands event to every subscriber right away.
+func (b *LocalStreamBackend) Publish(event models.StreamEvent) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, deliver := range b.delivers {
+		deliver(event)
+	}
+	return nil
+}
+
+// Subscribe registers deliver for every event published from now on.
+func (b *LocalStreamBackend) Subscribe(deliver func(event models.StreamEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.delivers = append(b.delivers, deliver)
+}
+
+// NotificationHub fans the events published through its backend out to the open streams of each user.
+type NotificationHub struct {
+	backend StreamBackend
+	log     *zap.Logger
+
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan models.StreamEvent]struct{}
+}
+
+// NewNotificationHub returns a hub publishing through backend and logging failures to log.
+func NewNotificationHub(backend StreamBackend, log *zap.Logger) *NotificationHub {
+	hub := &NotificationHub{
+		backend:     backend,
+		log:         log,
+		subscribers: make(map[uint]map[chan models.StreamEvent]struct{}),
+	}
+	backend.Subscribe(hub.deliver)
+	return hub
+}
+
+// Subscribe opens a stream of the user's events. The returned function closes it.
+func (h *NotificationHub) Subscribe(userID uint) (<-chan models.StreamEvent, func()) {
+	events := make(chan models.StreamEvent, streamBufferSize)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan models.StreamEvent]struct{})
+	}
+	h.subscribers[userID][events] = struct{}{}
+	h.mu.Unlock()
+
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers[userID], events)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+	}
+}
+
+// Publish pushes an event with data as JSON to every open stream of the user. Failures are only logged:
+// streams are best effort, the inbox is the record.
+func (h *NotificationHub) Publish(userID uint, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		h.log.Warn("Failed to encode stream event", zap.String("type", eventType), zap.Error(err))
+		return
+	}
+	event := models.StreamEvent{UserID: userID, Type: eventType, Data: payload}
+	if err := h.backend.Publish(event); err != nil {
+		h.log.Warn("Failed to publish stream event", zap.Uint("userID", userID), zap.String("type", eventType), zap.Error(err))
+	}
+}
+
+// deliver queues event on the user's streams on this replica, dropping it for streams too far behind.
+func (h *NotificationHub) deliver(event models.StreamEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for events := range h.subscribers[event.UserID] {
+		select {
+		case events <- event:
+		default:
+			h.log.Warn("Dropping stream event for slow client", zap.Uint("userID", event.UserID), zap.String("type", event.Type))
+		}
+	}
+}
+
+// notificationHub is the hub the streams and producers go through.
+var notificationHub = NewNotificationHub(NewLocalStreamBackend(), zap.NewNop())
+
+// UseNotificationHub replaces the hub the streams and producers go through. Call it before serving.
+func UseNotificationHub(hub *NotificationHub) {
+	notificationHub = hub
+}
+
+// StreamNotifications pushes the user's events over Server-Sent Events until the client disconnects.
+// Each event is named after its type with the JSON data; idle streams get a comment line as heartbeat.
+func StreamNotifications(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	events, unsubscribe := notificationHub.Subscribe(userID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // keep proxies such as nginx from buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-events:
+			c.SSEvent(event.Type, event.Data)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+// streamUpgrader upgrades notification WebSocket requests. Clients authenticate with a bearer token rather
+// than cookies, so a page on another origin can't open a stream on the user's behalf.
+var streamUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// NotificationWebSocket pushes the user's events as JSON messages over a WebSocket until either side
+// closes it. Messages from the client are ignored.
+func NotificationWebSocket(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already answered the client
+		log.Warn("WebSocket upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := notificationHub.Subscribe(userID)
+	defer unsubscribe()
+
+	// Reading is what notices the client going away and answers its control frames
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event := <-events:
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				log.Debug("WebSocket write failed", zap.Error(err))
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
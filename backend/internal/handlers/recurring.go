@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// RecurringTransactionRequest describes a recurring transaction. The schedule can be given either as
+// frequency/interval/endDate/count fields or as an RRULE-style string such as
+// "FREQ=MONTHLY;INTERVAL=1;COUNT=12" or "FREQ=WEEKLY;UNTIL=20241231".
+type RecurringTransactionRequest struct {
+	CategoryID  *uint   `json:"categoryId"` // null => uncategorized
+	Type        string  `json:"type" binding:"omitempty,oneof=expense income refund transfer"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Description string  `json:"description"`
+	Frequency   string  `json:"frequency" binding:"omitempty,oneof=daily weekly monthly yearly"`
+	Interval    int     `json:"interval" binding:"omitempty,gte=1"`
+	StartDate   string  `json:"startDate" binding:"required"`
+	EndDate     string  `json:"endDate"`
+	Count       *int    `json:"count" binding:"omitempty,gte=1"`
+	RRule       string  `json:"rrule"`
+}
+
+// recurrenceRule is the parsed schedule part of a RecurringTransactionRequest.
+type recurrenceRule struct {
+	Frequency string
+	Interval  int
+	Count     *int
+	Until     *time.Time
+}
+
+// dateOnly truncates t to local midnight, the same way handlers store dates.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// parseRRule parses the subset of RFC 5545 RRULE used by recurring transactions:
+// FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT and UNTIL (YYYYMMDD or YYYY-MM-DD).
+func parseRRule(rule string) (recurrenceRule, error) {
+	parsed := recurrenceRule{Interval: 1}
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return parsed, fmt.Errorf("invalid rrule part %q", part)
+		}
+		key, value := strings.ToUpper(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+
+		switch key {
+		case "FREQ":
+			freq := strings.ToLower(value)
+			switch freq {
+			case models.FrequencyDaily, models.FrequencyWeekly, models.FrequencyMonthly, models.FrequencyYearly:
+				parsed.Frequency = freq
+			default:
+				return parsed, fmt.Errorf("unsupported rrule frequency %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return parsed, fmt.Errorf("invalid rrule interval %q", value)
+			}
+			parsed.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return parsed, fmt.Errorf("invalid rrule count %q", value)
+			}
+			parsed.Count = &n
+		case "UNTIL":
+			var until time.Time
+			var err error
+			if len(value) >= 8 && !strings.Contains(value, "-") {
+				until, err = time.Parse("20060102", value[:8])
+			} else {
+				until, err = time.Parse("2006-01-02", value)
+			}
+			if err != nil {
+				return parsed, fmt.Errorf("invalid rrule until %q", value)
+			}
+			until = dateOnly(until)
+			parsed.Until = &until
+		default:
+			return parsed, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if parsed.Frequency == "" {
+		return parsed, errors.New("rrule must include FREQ")
+	}
+	return parsed, nil
+}
+
+// addMonthsClamped adds months to t, clamping the day to the end of the target month
+// so that e.g. Jan 31 + 1 month is Feb 28/29 instead of rolling over into March.
+func addMonthsClamped(t time.Time, months int) time.Time {
+	firstOfTarget := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstOfTarget.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfTarget.Year(), firstOfTarget.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// occurrenceAt returns the date of occurrence n (0-based) of a schedule starting on start.
+// Each occurrence is computed from the start date, so month-end clamping never drifts.
+func occurrenceAt(start time.Time, frequency string, interval, n int) time.Time {
+	if interval < 1 {
+		interval = 1
+	}
+	steps := n * interval
+	switch frequency {
+	case models.FrequencyDaily:
+		return start.AddDate(0, 0, steps)
+	case models.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*steps)
+	case models.FrequencyYearly:
+		return addMonthsClamped(start, 12*steps)
+	default:
+		return addMonthsClamped(start, steps)
+	}
+}
+
+// recurrenceFinished reports whether the schedule has no occurrences left after NextOccurrence.
+func recurrenceFinished(rt *models.RecurringTransaction) bool {
+	if rt.Count != nil && rt.OccurrencesGenerated >= *rt.Count {
+		return true
+	}
+	if rt.EndDate != nil && rt.NextOccurrence.After(*rt.EndDate) {
+		return true
+	}
+	return false
+}
+
+// advanceRecurring moves the schedule past its current NextOccurrence.
+func advanceRecurring(rt *models.RecurringTransaction) {
+	rt.OccurrencesGenerated++
+	rt.NextOccurrence = occurrenceAt(rt.StartDate, rt.Frequency, rt.Interval, rt.OccurrencesGenerated)
+	rt.Active = !recurrenceFinished(rt)
+}
+
+// applyRecurringRequest validates req and copies it onto rt, recomputing NextOccurrence
+// from the occurrences already generated.
+func applyRecurringRequest(rt *models.RecurringTransaction, req RecurringTransactionRequest) error {
+	parsedStart, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return errors.New("Invalid start date")
+	}
+	start := dateOnly(parsedStart)
+
+	rule := recurrenceRule{Frequency: req.Frequency, Interval: req.Interval, Count: req.Count}
+	if req.RRule != "" {
+		rule, err = parseRRule(req.RRule)
+		if err != nil {
+			return err
+		}
+	}
+	if rule.Frequency == "" {
+		return errors.New("Either frequency or rrule is required")
+	}
+	if rule.Interval < 1 {
+		rule.Interval = 1
+	}
+
+	if req.EndDate != "" {
+		parsedEnd, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return errors.New("Invalid end date")
+		}
+		end := dateOnly(parsedEnd)
+		rule.Until = &end
+	}
+	if rule.Until != nil && rule.Until.Before(start) {
+		return errors.New("End date must be after start date")
+	}
+
+	txType := req.Type
+	if txType == "" {
+		txType = models.TransactionTypeExpense
+	}
+
+	rt.CategoryID = req.CategoryID
+	rt.Type = txType
+	rt.Amount = req.Amount
+	rt.Description = req.Description
+	rt.Frequency = rule.Frequency
+	rt.Interval = rule.Interval
+	rt.StartDate = start
+	rt.EndDate = rule.Until
+	rt.Count = rule.Count
+	rt.NextOccurrence = occurrenceAt(start, rule.Frequency, rule.Interval, rt.OccurrencesGenerated)
+	rt.Active = !recurrenceFinished(rt)
+	return nil
+}
+
+// CreateRecurringTransaction stores a new recurring transaction. Occurrences on or before today
+// are materialized by the next worker run.
+func CreateRecurringTransaction(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var req RecurringTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid recurring transaction data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recurring := models.RecurringTransaction{UserID: userID}
+	if err := applyRecurringRequest(&recurring, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Create(&recurring).Error; err != nil {
+		log.Error("Failed to create recurring transaction", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create recurring transaction"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":              "Recurring transaction created successfully",
+		"recurringTransaction": recurring,
+	})
+}
+
+// GetRecurringTransactions lists the authenticated user's recurring transactions.
+func GetRecurringTransactions(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var recurring []models.RecurringTransaction
+	if err := db.DB.Where("user_id = ?", userID).Find(&recurring).Error; err != nil {
+		log.Error("Failed to fetch recurring transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch recurring transactions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recurringTransactions": recurring})
+}
+
+// UpdateRecurringTransaction overwrites a recurring transaction. Occurrences already generated are kept;
+// the next occurrence is recomputed from the new schedule.
+func UpdateRecurringTransaction(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+	recurringID := c.Param("id")
+
+	var existing models.RecurringTransaction
+	if err := db.DB.Where("id = ? AND user_id = ?", recurringID, userID).First(&existing).Error; err != nil {
+		log.Warn("Recurring transaction not found or unauthorized", zap.Error(err))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring transaction not found"})
+		return
+	}
+
+	var req RecurringTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid recurring transaction update data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := applyRecurringRequest(&existing, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Save(&existing).Error; err != nil {
+		log.Error("Failed to update recurring transaction", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update recurring transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "Recurring transaction updated successfully",
+		"recurringTransaction": existing,
+	})
+}
+
+// DeleteRecurringTransaction stops a recurring transaction. Transactions it already created are kept.
+func DeleteRecurringTransaction(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+	recurringID := c.Param("id")
+
+	result := db.DB.Where("id = ? AND user_id = ?", recurringID, userID).Delete(&models.RecurringTransaction{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		log.Warn("Failed to delete recurring transaction", zap.Error(result.Error))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring transaction not found or could not be deleted"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring transaction deleted successfully"})
+}
+
+// materializeRecurring inserts every occurrence of rt due on or before today and advances its schedule,
+// all in one DB transaction. The row lock and the unique (recurring_transaction_id, transaction_date)
+// index make this safe to repeat after a crash or restart.
+func materializeRecurring(rt *models.RecurringTransaction, today time.Time) ([]models.Transaction, error) {
+	var created []models.Transaction
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var locked models.RecurringTransaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, rt.ID).Error; err != nil {
+			return err
+		}
+
+		for locked.Active && !locked.NextOccurrence.After(today) {
+			recurringID := locked.ID
+			occurrence := models.Transaction{
+				UserID:                 locked.UserID,
+				CategoryID:             locked.CategoryID,
+				Type:                   locked.Type,
+				Amount:                 locked.Amount,
+				Description:            locked.Description,
+				TransactionDate:        dateOnly(locked.NextOccurrence),
+				RecurringTransactionID: &recurringID,
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&occurrence)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				created = append(created, occurrence)
+			}
+			advanceRecurring(&locked)
+		}
+
+		*rt = locked
+		return tx.Save(&locked).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// MaterializeRecurringTransactions turns every recurring occurrence due on or before asOf into a
+// Transaction and recalculates the budgets each new transaction falls into.
+// It returns the number of transactions created.
+func MaterializeRecurringTransactions(asOf time.Time, logger *zap.Logger) (int, error) {
+	today := dateOnly(asOf)
+
+	var due []models.RecurringTransaction
+	if err := db.DB.Where("active = ? AND next_occurrence <= ?", true, today).Find(&due).Error; err != nil {
+		logger.Error("Failed to fetch due recurring transactions", zap.Error(err))
+		return 0, err
+	}
+
+	total := 0
+	for i := range due {
+		created, err := materializeRecurring(&due[i], today)
+		if err != nil {
+			logger.Error("Failed to materialize recurring transaction",
+				zap.Error(err),
+				zap.Uint("recurringTransactionID", due[i].ID))
+			continue
+		}
+		for _, tx := range created {
+			recalcAllBudgetsForTransaction(tx, logger)
+		}
+		total += len(created)
+	}
+	return total, nil
+}
+
+// RunRecurringWorker materializes due recurring transactions and opens due budget periods immediately
+// and then every interval until ctx is cancelled. Transactions go first so a period is never closed
+// before its last recurring bill is booked.
+func RunRecurringWorker(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	run := func() {
+		created, err := MaterializeRecurringTransactions(time.Now(), logger)
+		if err == nil && created > 0 {
+			logger.Info("Materialized recurring transactions", zap.Int("created", created))
+		}
+		opened, err := OpenDueBudgetPeriods(time.Now(), logger)
+		if err == nil && opened > 0 {
+			logger.Info("Opened new budget periods", zap.Int("opened", opened))
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// Test helper functions - exports private functions for testing
+
+// TestableParseRRule is a test-friendly version of parseRRule
+func TestableParseRRule(rule string) (frequency string, interval int, count *int, until *time.Time, err error) {
+	parsed, err := parseRRule(rule)
+	return parsed.Frequency, parsed.Interval, parsed.Count, parsed.Until, err
+}
+
+// TestableOccurrenceAt is a test-friendly version of occurrenceAt
+func TestableOccurrenceAt(start time.Time, frequency string, interval, n int) time.Time {
+	return occurrenceAt(start, frequency, interval, n)
+}
@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// maxRuleTestMatches caps how many matching transactions a rule dry-run returns.
+const maxRuleTestMatches = 100
+
+// CategoryRuleRequest describes an auto-categorization rule. At least one condition is required.
+// Weekdays are day names such as "sat" or "saturday".
+type CategoryRuleRequest struct {
+	Name               string   `json:"name"`
+	CategoryID         uint     `json:"categoryId" binding:"required"`
+	Priority           int      `json:"priority"`
+	Active             *bool    `json:"active"` // omitted => active
+	DescriptionMatch   string   `json:"descriptionMatch" binding:"omitempty,oneof=contains regex"`
+	DescriptionPattern string   `json:"descriptionPattern"`
+	MinAmount          *float64 `json:"minAmount" binding:"omitempty,gte=0"`
+	MaxAmount          *float64 `json:"maxAmount" binding:"omitempty,gte=0"`
+	Weekdays           []string `json:"weekdays"`
+}
+
+// ruleWeekdays maps the accepted day names to the short form stored on a rule.
+var ruleWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseRuleWeekday accepts a short ("sat") or full ("Saturday") day name.
+func parseRuleWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 3 {
+		return 0, false
+	}
+	day, ok := ruleWeekdays[name[:3]]
+	if !ok || !strings.HasPrefix(strings.ToLower(day.String()), name) {
+		return 0, false
+	}
+	return day, true
+}
+
+// categoryRuleMatcher is a rule with its pattern compiled, ready to test transactions against.
+type categoryRuleMatcher struct {
+	rule     models.CategoryRule
+	pattern  *regexp.Regexp
+	weekdays map[time.Weekday]bool
+}
+
+// compileCategoryRule validates rule's conditions and compiles them.
+func compileCategoryRule(rule models.CategoryRule) (categoryRuleMatcher, error) {
+	m := categoryRuleMatcher{rule: rule}
+
+	switch rule.DescriptionMatch {
+	case "":
+	case models.RuleMatchContains:
+		if strings.TrimSpace(rule.DescriptionPattern) == "" {
+			return m, errors.New("descriptionPattern is required when matching descriptions")
+		}
+	case models.RuleMatchRegex:
+		pattern, err := regexp.Compile("(?i)" + rule.DescriptionPattern)
+		if err != nil {
+			return m, fmt.Errorf("invalid descriptionPattern: %v", err)
+		}
+		m.pattern = pattern
+	default:
+		return m, fmt.Errorf("invalid descriptionMatch %q", rule.DescriptionMatch)
+	}
+
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return m, errors.New("minAmount must not exceed maxAmount")
+	}
+
+	if rule.Weekdays != "" {
+		m.weekdays = make(map[time.Weekday]bool)
+		for _, name := range strings.Split(rule.Weekdays, ",") {
+			day, ok := parseRuleWeekday(name)
+			if !ok {
+				return m, fmt.Errorf("invalid weekday %q", name)
+			}
+			m.weekdays[day] = true
+		}
+	}
+
+	if rule.DescriptionMatch == "" && rule.MinAmount == nil && rule.MaxAmount == nil && m.weekdays == nil {
+		return m, errors.New("A rule needs at least one condition")
+	}
+	return m, nil
+}
+
+// matches reports whether tx meets every condition of the rule.
+func (m categoryRuleMatcher) matches(tx models.Transaction) bool {
+	switch m.rule.DescriptionMatch {
+	case models.RuleMatchContains:
+		if !strings.Contains(strings.ToLower(tx.Description), strings.ToLower(strings.TrimSpace(m.rule.DescriptionPattern))) {
+			return false
+		}
+	case models.RuleMatchRegex:
+		if !m.pattern.MatchString(tx.Description) {
+			return false
+		}
+	}
+	if m.rule.MinAmount != nil && tx.Amount < *m.rule.MinAmount {
+		return false
+	}
+	if m.rule.MaxAmount != nil && tx.Amount > *m.rule.MaxAmount {
+		return false
+	}
+	if m.weekdays != nil && !m.weekdays[tx.TransactionDate.Weekday()] {
+		return false
+	}
+	return true
+}
+
+// loadCategoryRules returns the user's active rules in the order they are tried.
+// A stored rule that no longer compiles is skipped rather than blocking the others.
+func loadCategoryRules(userID uint, log *zap.Logger) ([]categoryRuleMatcher, error) {
+	var rules []models.CategoryRule
+	if err := db.DB.Where("user_id = ? AND active = ?", userID, true).
+		Order("priority DESC, id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	matchers := make([]categoryRuleMatcher, 0, len(rules))
+	for _, rule := range rules {
+		m, err := compileCategoryRule(rule)
+		if err != nil {
+			log.Warn("Skipping invalid category rule", zap.Uint("ruleID", rule.ID), zap.Error(err))
+			continue
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// matchCategoryRule returns the first rule that matches tx, or nil.
+func matchCategoryRule(rules []categoryRuleMatcher, tx models.Transaction) *models.CategoryRule {
+	for i := range rules {
+		if rules[i].matches(tx) {
+			return &rules[i].rule
+		}
+	}
+	return nil
+}
+
+// categorizeTransaction assigns tx the category of the first matching rule when it has none.
+func categorizeTransaction(rules []categoryRuleMatcher, tx *models.Transaction) {
+	if tx.CategoryID != nil {
+		return
+	}
+	if rule := matchCategoryRule(rules, *tx); rule != nil {
+		categoryID := rule.CategoryID
+		tx.CategoryID = &categoryID
+	}
+}
+
+// applyCategoryRuleRequest validates req and copies it onto rule.
+func applyCategoryRuleRequest(userID uint, rule *models.CategoryRule, req CategoryRuleRequest) error {
+	if _, err := findUserCategory(userID, req.CategoryID); err != nil {
+		return errors.New("Category not found")
+	}
+
+	weekdays := make([]string, 0, len(req.Weekdays))
+	seen := make(map[time.Weekday]bool)
+	for _, name := range req.Weekdays {
+		day, ok := parseRuleWeekday(name)
+		if !ok {
+			return fmt.Errorf("invalid weekday %q", name)
+		}
+		if !seen[day] {
+			seen[day] = true
+			weekdays = append(weekdays, strings.ToLower(day.String()[:3]))
+		}
+	}
+
+	rule.UserID = userID
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.CategoryID = req.CategoryID
+	rule.Priority = req.Priority
+	rule.Active = req.Active == nil || *req.Active
+	rule.DescriptionMatch = req.DescriptionMatch
+	rule.DescriptionPattern = req.DescriptionPattern
+	rule.MinAmount = req.MinAmount
+	rule.MaxAmount = req.MaxAmount
+	rule.Weekdays = strings.Join(weekdays, ",")
+
+	_, err := compileCategoryRule(*rule)
+	return err
+}
+
+// CreateCategoryRule adds an auto-categorization rule.
+func CreateCategoryRule(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var req CategoryRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid category rule data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rule models.CategoryRule
+	if err := applyCategoryRuleRequest(userID, &rule, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Create(&rule).Error; err != nil {
+		log.Error("Failed to create category rule", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create category rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Category rule created successfully",
+		"rule":    rule,
+	})
+}
+
+// GetCategoryRules lists the user's rules in the order they are tried.
+func GetCategoryRules(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var rules []models.CategoryRule
+	if err := db.DB.Where("user_id = ?", userID).Order("priority DESC, id ASC").Find(&rules).Error; err != nil {
+		log.Error("Failed to fetch category rules", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch category rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// UpdateCategoryRule overwrites a rule. Transactions it already categorized are left alone.
+func UpdateCategoryRule(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var rule models.CategoryRule
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		log.Warn("Category rule not found or unauthorized", zap.Error(err))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category rule not found"})
+		return
+	}
+
+	var req CategoryRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid category rule update data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyCategoryRuleRequest(userID, &rule, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Save(&rule).Error; err != nil {
+		log.Error("Failed to update category rule", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update category rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category rule updated successfully",
+		"rule":    rule,
+	})
+}
+
+// DeleteCategoryRule removes a rule.
+func DeleteCategoryRule(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	result := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.CategoryRule{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		log.Warn("Failed to delete category rule", zap.Error(result.Error))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category rule not found or could not be deleted"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Category rule deleted successfully"})
+}
+
+// TestCategoryRule dry-runs an unsaved rule against the user's transaction history and reports what it
+// would match. Nothing is written. Optional startDate/endDate query params narrow the history searched.
+func TestCategoryRule(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var req CategoryRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid category rule test data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rule models.CategoryRule
+	if err := applyCategoryRuleRequest(userID, &rule, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	matcher, _ := compileCategoryRule(rule)
+
+	// Amount bounds are cheap to push down; description and weekday are checked in Go
+	query := db.DB.Where("user_id = ?", userID)
+	if startDate := c.Query("startDate"); startDate != "" {
+		query = query.Where("transaction_date >= ?", startDate)
+	}
+	if endDate := c.Query("endDate"); endDate != "" {
+		query = query.Where("transaction_date <= ?", endDate)
+	}
+	if rule.MinAmount != nil {
+		query = query.Where("amount >= ?", *rule.MinAmount)
+	}
+	if rule.MaxAmount != nil {
+		query = query.Where("amount <= ?", *rule.MaxAmount)
+	}
+
+	var txs []models.Transaction
+	if err := query.Order("transaction_date DESC, id DESC").Find(&txs).Error; err != nil {
+		log.Error("Failed to fetch transactions for rule test", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not test category rule"})
+		return
+	}
+
+	matched := []models.Transaction{}
+	total, uncategorized, recategorized := 0, 0, 0
+	for _, tx := range txs {
+		if !matcher.matches(tx) {
+			continue
+		}
+		total++
+		switch {
+		case tx.CategoryID == nil:
+			uncategorized++
+		case *tx.CategoryID != rule.CategoryID:
+			recategorized++
+		}
+		if len(matched) < maxRuleTestMatches {
+			matched = append(matched, tx)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"matched":       total,
+		"uncategorized": uncategorized, // would be categorized by the rule
+		"otherCategory": recategorized, // already in a different category; rules never override these
+		"transactions":  matched,
+	})
+}
+
+// ApplyCategoryRules runs the active rules over the user's existing uncategorized transactions and
+// assigns the category of the first matching rule. Budgets covering the changed rows are recalculated.
+func ApplyCategoryRules(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	rules, err := loadCategoryRules(userID, log)
+	if err != nil {
+		log.Error("Failed to load category rules", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not apply category rules"})
+		return
+	}
+	if len(rules) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "No active category rules", "updated": 0})
+		return
+	}
+
+	var uncategorized []models.Transaction
+	if err := db.DB.Where("user_id = ? AND category_id IS NULL", userID).Find(&uncategorized).Error; err != nil {
+		log.Error("Failed to fetch uncategorized transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not apply category rules"})
+		return
+	}
+
+	byCategory := make(map[uint][]uint)
+	perRule := make(map[uint]int)
+	var changed, before []models.Transaction
+	for _, tx := range uncategorized {
+		rule := matchCategoryRule(rules, tx)
+		if rule == nil {
+			continue
+		}
+		before = append(before, tx)
+		categoryID := rule.CategoryID
+		byCategory[categoryID] = append(byCategory[categoryID], tx.ID)
+		perRule[rule.ID]++
+		tx.CategoryID = &categoryID
+		changed = append(changed, tx)
+	}
+
+	categoryIDs := make([]uint, 0, len(byCategory))
+	for id := range byCategory {
+		categoryIDs = append(categoryIDs, id)
+	}
+	sort.Slice(categoryIDs, func(i, j int) bool { return categoryIDs[i] < categoryIDs[j] })
+
+	// category_id IS NULL again so a row categorized in the meantime is not overwritten
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, categoryID := range categoryIDs {
+			if err := tx.Model(&models.Transaction{}).
+				Where("user_id = ? AND id IN ? AND category_id IS NULL", userID, byCategory[categoryID]).
+				Update("category_id", categoryID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("Failed to apply category rules", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not apply category rules"})
+		return
+	}
+
+	// Both the uncategorized budgets the rows left and the category budgets they joined change
+	recalcBudgetsForTransactions(userID, append(before, changed...), log)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category rules applied successfully",
+		"updated": len(changed),
+		"rules":   perRule, // rule ID => transactions it categorized
+	})
+}
+
+// Test helper functions - exports private functions for testing
+
+// TestableMatchCategoryRule compiles rule and reports whether it matches tx
+func TestableMatchCategoryRule(rule models.CategoryRule, tx models.Transaction) (bool, error) {
+	m, err := compileCategoryRule(rule)
+	if err != nil {
+		return false, err
+	}
+	return m.matches(tx), nil
+}
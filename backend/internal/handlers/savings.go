@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// savingsDefaultMonths is how many calendar months the savings series covers by default.
+const savingsDefaultMonths = 12
+
+// dailyTotal is the sum of one type of transaction on one day.
+type dailyTotal struct {
+	TransactionDate time.Time
+	Type            string
+	Total           float64
+}
+
+// periodStart returns the first day of the month or week (from Monday) containing day.
+func periodStart(day time.Time, granularity string) time.Time {
+	if granularity == models.GranularityWeek {
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+}
+
+// savingsRate returns savings as a percentage of income, nil without income.
+func savingsRate(summary models.CashFlowSummary) *float64 {
+	if summary.Income <= 0 {
+		return nil
+	}
+	rate := roundCents(summary.NetCashFlow / summary.Income * 100)
+	return &rate
+}
+
+// savingsSeries splits [start, end] into months or weeks and reports the income, net spending and savings of
+// each, with the net worth at its end. opening is the net worth before start; totals are the user's
+// transactions in [start, end] summed per day and type.
+func savingsSeries(opening float64, start, end time.Time, granularity string, totals []dailyTotal) models.SavingsReport {
+	report := models.SavingsReport{
+		Granularity:     granularity,
+		StartDate:       start.Format("2006-01-02"),
+		EndDate:         end.Format("2006-01-02"),
+		OpeningNetWorth: roundCents(opening),
+		Series:          []models.SavingsPoint{},
+	}
+
+	// One summary per period, keyed by the period's first day
+	summaries := make(map[string]*models.CashFlowSummary)
+	var total models.CashFlowSummary
+	for _, t := range totals {
+		key := periodStart(dateOnly(t.TransactionDate), granularity).Format("2006-01-02")
+		if summaries[key] == nil {
+			summaries[key] = &models.CashFlowSummary{}
+		}
+		addToCashFlow(summaries[key], t.Type, t.Total)
+		addToCashFlow(&total, t.Type, t.Total)
+	}
+
+	netWorth := opening
+	for from := periodStart(start, granularity); !from.After(end); {
+		next := from.AddDate(0, 1, 0)
+		label := from.Format("2006-01")
+		if granularity == models.GranularityWeek {
+			next = from.AddDate(0, 0, 7)
+			label = from.Format("2006-01-02")
+		}
+
+		var summary models.CashFlowSummary
+		if s := summaries[from.Format("2006-01-02")]; s != nil {
+			summary = *s
+		}
+		summary = finishCashFlow(summary)
+		netWorth += summary.NetCashFlow
+
+		point := models.SavingsPoint{
+			Period:      label,
+			StartDate:   maxTime(from, start).Format("2006-01-02"),
+			EndDate:     minTime(next.AddDate(0, 0, -1), end).Format("2006-01-02"),
+			Income:      summary.Income,
+			Expenses:    summary.NetExpenses,
+			Savings:     summary.NetCashFlow,
+			SavingsRate: savingsRate(summary),
+			NetWorth:    roundCents(netWorth),
+		}
+		report.Series = append(report.Series, point)
+		from = next
+	}
+
+	total = finishCashFlow(total)
+	report.Income = total.Income
+	report.Expenses = total.NetExpenses
+	report.Savings = total.NetCashFlow
+	report.SavingsRate = savingsRate(total)
+	report.ClosingNetWorth = roundCents(netWorth)
+	return report
+}
+
+// maxTime returns the later of a and b.
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// minTime returns the earlier of a and b.
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// GetSavingsSeries reports the user's monthly or weekly income, spending, savings rate and running net worth
+// for charts. Query parameters, all optional:
+//   - granularity: month (default) or week
+//   - startDate, endDate: YYYY-MM-DD (default the last 12 months, the current one included)
+//   - openingBalance: what the user's accounts held before their first recorded transaction (default 0);
+//     net worth adds every transaction since
+func GetSavingsSeries(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	granularity := c.DefaultQuery("granularity", models.GranularityMonth)
+	if granularity != models.GranularityMonth && granularity != models.GranularityWeek {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be month or week"})
+		return
+	}
+
+	start, end, err := analyticsRange(c, savingsDefaultMonths)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var openingBalance float64
+	if raw := c.Query("openingBalance"); raw != "" {
+		openingBalance, err = strconv.ParseFloat(raw, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid opening balance"})
+			return
+		}
+	}
+
+	if db.DB == nil {
+		log.Error("Database connection not initialized")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	before, err := accountBalance(userID, start)
+	if err != nil {
+		log.Error("Failed to derive account balance", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not compute savings"})
+		return
+	}
+
+	var totals []dailyTotal
+	err = db.DB.Model(&models.Transaction{}).
+		Select("transaction_date, type, COALESCE(SUM(amount), 0) as total").
+		Where("user_id = ? AND transaction_date >= ? AND transaction_date <= ?", userID, start, end).
+		Group("transaction_date, type").
+		Order("transaction_date").
+		Scan(&totals).Error
+	if err != nil {
+		log.Error("Failed to aggregate transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not compute savings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, savingsSeries(openingBalance+before, start, end, granularity, totals))
+}
+
+// Test helper functions - exports private functions for testing
+// These are only used by tests and do not affect normal operation
+
+// TestableSavingsSeries is a test-friendly version of savingsSeries, taking one transaction per daily total
+func TestableSavingsSeries(opening float64, start, end time.Time, granularity string, transactions []models.Transaction) models.SavingsReport {
+	totals := make([]dailyTotal, len(transactions))
+	for i, tx := range transactions {
+		totals[i] = dailyTotal{TransactionDate: tx.TransactionDate, Type: tx.Type, Total: tx.Amount}
+	}
+	return savingsSeries(opening, start, end, granularity, totals)
+}
@@ -0,0 +1,596 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// ScenarioChangeRequest describes one hypothetical change. Which fields are needed depends on Kind:
+// cancel_recurring takes recurringTransactionId, scale_category takes categoryId and percent, and
+// add_recurring takes amount and optionally categoryId. startDate and endDate (YYYY-MM-DD) limit the
+// months the change applies to.
+type ScenarioChangeRequest struct {
+	Kind                   string  `json:"kind" binding:"required,oneof=cancel_recurring scale_category add_recurring"`
+	RecurringTransactionID *uint   `json:"recurringTransactionId"`
+	CategoryID             *uint   `json:"categoryId"`
+	Percent                float64 `json:"percent" binding:"gte=-100"`
+	Amount                 float64 `json:"amount" binding:"gte=0"`
+	Description            string  `json:"description"`
+	StartDate              string  `json:"startDate"`
+	EndDate                string  `json:"endDate"`
+}
+
+// ScenarioRequest creates or replaces a saved scenario.
+type ScenarioRequest struct {
+	Name        string                  `json:"name" binding:"required"`
+	Description string                  `json:"description"`
+	Changes     []ScenarioChangeRequest `json:"changes" binding:"required,min=1,dive"`
+}
+
+// SimulationRequest simulates saved scenarios and changes posted with the request next to the baseline forecast.
+type SimulationRequest struct {
+	MonthsAhead int                     `json:"monthsAhead"`         // How many months to forecast, 1-12
+	StartDate   *time.Time              `json:"startDate,omitempty"` // Optional: first forecast month (default: current month)
+	ScenarioIDs []uint                  `json:"scenarioIds"`         // saved scenarios to compare
+	Changes     []ScenarioChangeRequest `json:"changes" binding:"omitempty,dive"`
+}
+
+// parseScenarioDate parses an optional YYYY-MM-DD date.
+func parseScenarioDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	date := dateOnly(parsed)
+	return &date, nil
+}
+
+// parseScenarioChange validates req and turns it into a change. Categories and recurring transactions must
+// belong to the user.
+func parseScenarioChange(userID uint, req ScenarioChangeRequest) (models.ScenarioChange, error) {
+	change := models.ScenarioChange{
+		Kind:        req.Kind,
+		Percent:     req.Percent,
+		Amount:      req.Amount,
+		Description: strings.TrimSpace(req.Description),
+	}
+
+	var err error
+	if change.StartDate, err = parseScenarioDate(req.StartDate); err != nil {
+		return change, errors.New("Invalid start date")
+	}
+	if change.EndDate, err = parseScenarioDate(req.EndDate); err != nil {
+		return change, errors.New("Invalid end date")
+	}
+	if change.StartDate != nil && change.EndDate != nil && change.EndDate.Before(*change.StartDate) {
+		return change, errors.New("End date must be after start date")
+	}
+
+	switch req.Kind {
+	case models.ScenarioChangeCancelRecurring:
+		if req.RecurringTransactionID == nil {
+			return change, errors.New("cancel_recurring needs a recurringTransactionId")
+		}
+		var rt models.RecurringTransaction
+		if err := db.DB.Where("id = ? AND user_id = ?", *req.RecurringTransactionID, userID).First(&rt).Error; err != nil {
+			return change, errors.New("Recurring transaction not found")
+		}
+		change.RecurringTransactionID = req.RecurringTransactionID
+	case models.ScenarioChangeScaleCategory:
+		if req.CategoryID == nil {
+			return change, errors.New("scale_category needs a categoryId")
+		}
+		if req.Percent == 0 {
+			return change, errors.New("scale_category needs a percent other than 0")
+		}
+	case models.ScenarioChangeAddRecurring:
+		if req.Amount <= 0 {
+			return change, errors.New("add_recurring needs an amount greater than 0")
+		}
+	}
+
+	if req.CategoryID != nil {
+		if _, err := findUserCategory(userID, *req.CategoryID); err != nil {
+			return change, errors.New("Category not found")
+		}
+		change.CategoryID = req.CategoryID
+	}
+	return change, nil
+}
+
+// parseScenarioChanges parses every change of a request, stopping at the first invalid one.
+func parseScenarioChanges(userID uint, reqs []ScenarioChangeRequest) ([]models.ScenarioChange, error) {
+	changes := make([]models.ScenarioChange, 0, len(reqs))
+	for _, req := range reqs {
+		change, err := parseScenarioChange(userID, req)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// simulationBase is the baseline forecast of every spending series that scenarios are applied to.
+type simulationBase struct {
+	start          time.Time
+	months         int
+	model          string
+	total          []float64
+	categories     map[uint][]float64 // forecast per category ID
+	categoryModels map[uint]string
+	uncategorized  []float64
+	names          map[uint]string
+	parents        map[uint]*uint
+	recurring      map[uint]models.RecurringTransaction
+	budgets        []models.Budget
+}
+
+// simulationSeries is one set of monthly amounts a scenario adjusts: the total, per category and uncategorized.
+type simulationSeries struct {
+	total         []float64
+	categories    map[uint][]float64
+	uncategorized []float64
+}
+
+// newSimulationBase forecasts the total, each category and uncategorized spending for months months from
+// start, the same way ForecastExpensesHandler does.
+func newSimulationBase(transactions []models.Transaction, historyStart, start time.Time, months int) *simulationBase {
+	fit := runForecast("", monthlySeries(transactions, historyStart, forecastHistoryMonths, nil), months)
+	base := &simulationBase{
+		start:          start,
+		months:         months,
+		model:          fit.Model,
+		total:          fit.Forecast,
+		categories:     make(map[uint][]float64),
+		categoryModels: make(map[uint]string),
+		uncategorized:  make([]float64, months),
+		recurring:      make(map[uint]models.RecurringTransaction),
+	}
+	for _, catID := range spendingCategoryIDs(transactions) {
+		fit := runForecast("", categorySeries(transactions, catID, historyStart, forecastHistoryMonths), months)
+		base.categories[catID], base.categoryModels[catID] = fit.Forecast, fit.Model
+	}
+	uncategorized := monthlySeries(transactions, historyStart, forecastHistoryMonths, func(tx models.Transaction) bool {
+		return tx.CategoryID == nil
+	})
+	if len(uncategorized) > 0 {
+		base.uncategorized = runForecast("", uncategorized, months).Forecast
+	}
+	return base
+}
+
+// baseline returns a copy of the baseline series for a scenario to adjust.
+func (b *simulationBase) baseline() simulationSeries {
+	series := simulationSeries{
+		total:         append([]float64(nil), b.total...),
+		categories:    make(map[uint][]float64, len(b.categories)),
+		uncategorized: append([]float64(nil), b.uncategorized...),
+	}
+	for id, amounts := range b.categories {
+		series.categories[id] = append([]float64(nil), amounts...)
+	}
+	return series
+}
+
+// monthBounds returns the first and last day of forecast month i.
+func (b *simulationBase) monthBounds(i int) (time.Time, time.Time) {
+	first := b.start.AddDate(0, i, 0)
+	return first, first.AddDate(0, 1, -1)
+}
+
+// adjust adds delta to month i of categoryID's series (uncategorized when nil) and to the total.
+// Spending cannot go below zero, so a cut is limited to what the series has.
+func (s *simulationSeries) adjust(categoryID *uint, i int, delta float64) {
+	amounts := s.uncategorized
+	if categoryID != nil {
+		if _, ok := s.categories[*categoryID]; !ok {
+			s.categories[*categoryID] = make([]float64, len(s.total))
+		}
+		amounts = s.categories[*categoryID]
+	}
+	if amounts[i]+delta < 0 {
+		delta = -amounts[i]
+	}
+	amounts[i] += delta
+	s.total[i] = math.Max(s.total[i]+delta, 0)
+}
+
+// apply adjusts series for change.
+func (b *simulationBase) apply(series *simulationSeries, change models.ScenarioChange) {
+	for i := 0; i < b.months; i++ {
+		first, last := b.monthBounds(i)
+		if change.StartDate != nil && change.StartDate.After(last) {
+			continue
+		}
+		if change.EndDate != nil && change.EndDate.Before(first) {
+			continue
+		}
+
+		switch change.Kind {
+		case models.ScenarioChangeScaleCategory:
+			for id, amounts := range series.categories {
+				if categoryWithin(id, *change.CategoryID, b.parents) {
+					id := id
+					series.adjust(&id, i, amounts[i]*change.Percent/100)
+				}
+			}
+		case models.ScenarioChangeCancelRecurring:
+			rt, ok := b.recurring[*change.RecurringTransactionID]
+			if !ok {
+				continue
+			}
+			from, to := first, last
+			if change.StartDate != nil && change.StartDate.After(from) {
+				from = *change.StartDate
+			}
+			if change.EndDate != nil && change.EndDate.Before(to) {
+				to = *change.EndDate
+			}
+			amount := expenseAmount(models.Transaction{Type: rt.Type, Amount: rt.Amount})
+			if due := len(pendingOccurrences(rt, from, to)); due > 0 {
+				series.adjust(rt.CategoryID, i, -amount*float64(due))
+			}
+		case models.ScenarioChangeAddRecurring:
+			series.adjust(change.CategoryID, i, change.Amount)
+		}
+	}
+}
+
+// budgetSpend forecasts the spending of budget from series, counting the share of each forecast month
+// that falls in the budget period.
+func (b *simulationBase) budgetSpend(series simulationSeries, budget models.Budget) float64 {
+	start, end := dateOnly(budget.StartDate), dateOnly(budget.EndDate)
+	var spend float64
+	for i := 0; i < b.months; i++ {
+		first, last := b.monthBounds(i)
+		from, to := first, last
+		if start.After(from) {
+			from = start
+		}
+		if end.Before(to) {
+			to = end
+		}
+		if to.Before(from) {
+			continue
+		}
+
+		amount := series.uncategorized[i]
+		if budget.CategoryID != nil {
+			amount = 0
+			for id, amounts := range series.categories {
+				if categoryWithin(id, *budget.CategoryID, b.parents) {
+					amount += amounts[i]
+				}
+			}
+		}
+		spend += amount * float64(daysBetween(from, to)+1) / float64(last.Day())
+	}
+	return spend
+}
+
+// outcome reports series as forecast points, per-category forecasts and budget outcomes.
+func (b *simulationBase) outcome(series simulationSeries) models.SimulationOutcome {
+	points := func(amounts []float64) []models.ForecastPoint {
+		result := make([]models.ForecastPoint, len(amounts))
+		for i, amount := range amounts {
+			result[i] = models.ForecastPoint{
+				Month:     b.start.AddDate(0, i, 0).Format("2006-01"),
+				Amount:    roundCents(amount),
+				Intervals: []models.PredictionInterval{},
+			}
+		}
+		return result
+	}
+
+	outcome := models.SimulationOutcome{
+		TotalForecast:     points(series.total),
+		CategoryForecasts: []models.CategoryForecast{},
+		Budgets:           []models.BudgetOutcome{},
+	}
+	for _, amount := range series.total {
+		outcome.TotalSpend += amount
+	}
+	outcome.TotalSpend = roundCents(outcome.TotalSpend)
+
+	categoryIDs := make([]uint, 0, len(series.categories))
+	for id := range series.categories {
+		categoryIDs = append(categoryIDs, id)
+	}
+	sort.Slice(categoryIDs, func(i, j int) bool { return categoryIDs[i] < categoryIDs[j] })
+	for _, id := range categoryIDs {
+		outcome.CategoryForecasts = append(outcome.CategoryForecasts, models.CategoryForecast{
+			CategoryID:   id,
+			CategoryName: categoryName(b.names, id),
+			Model:        b.categoryModels[id],
+			Forecast:     points(series.categories[id]),
+		})
+	}
+
+	for _, budget := range b.budgets {
+		limit := budget.LimitAmount + budget.RolloverAmount
+		spend := roundCents(b.budgetSpend(series, budget))
+		budgetOutcome := models.BudgetOutcome{
+			BudgetID:       budget.ID,
+			CategoryID:     budget.CategoryID,
+			StartDate:      dateOnly(budget.StartDate).Format("2006-01-02"),
+			EndDate:        dateOnly(budget.EndDate).Format("2006-01-02"),
+			Limit:          limit,
+			ProjectedSpend: spend,
+			Remaining:      roundCents(limit - spend),
+			Exceeded:       spend > limit,
+		}
+		if budgetOutcome.Exceeded {
+			outcome.BudgetsExceeded++
+		}
+		outcome.Budgets = append(outcome.Budgets, budgetOutcome)
+	}
+	return outcome
+}
+
+// simulate applies changes to the baseline and compares the outcome with baselineOutcome.
+func (b *simulationBase) simulate(name string, scenarioID *uint, changes []models.ScenarioChange, baselineOutcome models.SimulationOutcome) models.ScenarioOutcome {
+	series := b.baseline()
+	for _, change := range changes {
+		b.apply(&series, change)
+	}
+	outcome := b.outcome(series)
+	return models.ScenarioOutcome{
+		ScenarioID: scenarioID,
+		Name:       name,
+		Outcome:    outcome,
+		Difference: roundCents(outcome.TotalSpend - baselineOutcome.TotalSpend),
+	}
+}
+
+// CreateScenario saves a named set of hypothetical changes.
+func CreateScenario(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var req ScenarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid scenario data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	changes, err := parseScenarioChanges(userID, req.Changes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scenario := models.Scenario{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Changes:     changes,
+	}
+	if err := db.DB.Create(&scenario).Error; err != nil {
+		log.Error("Failed to create scenario", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create scenario"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Scenario created successfully",
+		"scenario": scenario,
+	})
+}
+
+// GetScenarios lists the user's saved scenarios with their changes.
+func GetScenarios(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var scenarios []models.Scenario
+	if err := db.DB.Preload("Changes").Where("user_id = ?", userID).Order("id").Find(&scenarios).Error; err != nil {
+		log.Error("Failed to fetch scenarios", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch scenarios"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"scenarios": scenarios})
+}
+
+// UpdateScenario renames a scenario and replaces its changes.
+func UpdateScenario(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var scenario models.Scenario
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&scenario).Error; err != nil {
+		log.Warn("Scenario not found or unauthorized", zap.Error(err))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scenario not found"})
+		return
+	}
+
+	var req ScenarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid scenario update data", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	changes, err := parseScenarioChanges(userID, req.Changes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scenario.Name = strings.TrimSpace(req.Name)
+	scenario.Description = req.Description
+	scenario.Changes = changes
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scenario_id = ?", scenario.ID).Delete(&models.ScenarioChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&scenario).Error
+	})
+	if err != nil {
+		log.Error("Failed to update scenario", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update scenario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Scenario updated successfully",
+		"scenario": scenario,
+	})
+}
+
+// DeleteScenario removes a saved scenario. Nothing else is affected.
+func DeleteScenario(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	result := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.Scenario{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		log.Warn("Failed to delete scenario", zap.Error(result.Error))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scenario not found or could not be deleted"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Scenario deleted successfully"})
+}
+
+// SimulateScenarios forecasts spending and the budgets ending in the forecast months, first as is and then
+// under each saved scenario in scenarioIds and under the changes posted with the request. Only the forecast
+// is changed; no transaction, recurring transaction or budget is written.
+func SimulateScenarios(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	var req SimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn("Invalid simulation request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.MonthsAhead <= 0 || req.MonthsAhead > 12 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "MonthsAhead must be between 1 and 12"})
+		return
+	}
+	if len(req.ScenarioIDs) == 0 && len(req.Changes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give scenarioIds or changes to simulate"})
+		return
+	}
+
+	start := time.Now().UTC()
+	if req.StartDate != nil {
+		start = *req.StartDate
+	}
+	start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local)
+
+	adHoc, err := parseScenarioChanges(userID, req.Changes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A scenario asked for twice is simulated once
+	var scenarioIDs []uint
+	requested := make(map[uint]bool, len(req.ScenarioIDs))
+	for _, id := range req.ScenarioIDs {
+		if !requested[id] {
+			requested[id] = true
+			scenarioIDs = append(scenarioIDs, id)
+		}
+	}
+
+	var scenarios []models.Scenario
+	if len(scenarioIDs) > 0 {
+		if err := db.DB.Preload("Changes").Where("id IN ? AND user_id = ?", scenarioIDs, userID).
+			Order("id").Find(&scenarios).Error; err != nil {
+			log.Error("Failed to fetch scenarios", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch scenarios"})
+			return
+		}
+		if len(scenarios) != len(scenarioIDs) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scenario not found"})
+			return
+		}
+	}
+
+	historyStart := start.AddDate(0, -forecastHistoryMonths, 0)
+	transactions, err := loadSpendingHistory(userID, nil, historyStart, start)
+	if err != nil {
+		log.Error("Failed to retrieve transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transaction history"})
+		return
+	}
+	base := newSimulationBase(transactions, historyStart, start, req.MonthsAhead)
+
+	if base.names, err = loadCategoryNames(userID); err == nil {
+		base.parents, err = loadCategoryParents(userID)
+	}
+	if err != nil {
+		log.Error("Failed to retrieve categories", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
+		return
+	}
+
+	var recurring []models.RecurringTransaction
+	if err := db.DB.Where("user_id = ? AND active = ?", userID, true).Find(&recurring).Error; err != nil {
+		log.Error("Failed to retrieve recurring transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recurring transactions"})
+		return
+	}
+	for _, rt := range recurring {
+		base.recurring[rt.ID] = rt
+	}
+
+	// Budgets whose whole period lies in the forecast months
+	if err := db.DB.Where("user_id = ? AND start_date >= ? AND end_date < ?", userID, start, start.AddDate(0, req.MonthsAhead, 0)).
+		Order("start_date, id").Find(&base.budgets).Error; err != nil {
+		log.Error("Failed to fetch budgets", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch budgets"})
+		return
+	}
+
+	response := models.SimulationResponse{
+		Model:     base.model,
+		Baseline:  base.outcome(base.baseline()),
+		Scenarios: []models.ScenarioOutcome{},
+	}
+	for _, scenario := range scenarios {
+		id := scenario.ID
+		response.Scenarios = append(response.Scenarios, base.simulate(scenario.Name, &id, scenario.Changes, response.Baseline))
+	}
+	if len(adHoc) > 0 {
+		response.Scenarios = append(response.Scenarios, base.simulate("Unsaved changes", nil, adHoc, response.Baseline))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Test helper functions - exports private functions for testing
+
+// TestableSimulateScenario forecasts transactions as SimulateScenarios does and returns the baseline outcome
+// and the outcome of changes, without touching the database.
+func TestableSimulateScenario(transactions []models.Transaction, start time.Time, months int, parents map[uint]*uint,
+	recurring []models.RecurringTransaction, budgets []models.Budget, changes []models.ScenarioChange) (models.SimulationOutcome, models.ScenarioOutcome) {
+	base := newSimulationBase(transactions, start.AddDate(0, -forecastHistoryMonths, 0), start, months)
+	base.parents, base.budgets = parents, budgets
+	for _, rt := range recurring {
+		base.recurring[rt.ID] = rt
+	}
+	baseline := base.outcome(base.baseline())
+	return baseline, base.simulate("Test", nil, changes, baseline)
+}
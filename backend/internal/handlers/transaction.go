@@ -1,30 +1,123 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"github.com/RedShawn258/FinTrack/backend/internal/db"
 	"github.com/RedShawn258/FinTrack/backend/internal/models"
 )
 
 type TransactionRequest struct {
-	CategoryID      *uint   `json:"categoryId"` // null => uncategorized
+	CategoryID      *uint   `json:"categoryId"`                                                    // null => uncategorized
+	Type            string  `json:"type" binding:"omitempty,oneof=expense income refund transfer"` // empty => expense
 	Amount          float64 `json:"amount" binding:"required,gt=0"`
 	Description     string  `json:"description"`
 	TransactionDate string  `json:"transactionDate" binding:"required"`
+	TransactionTime string  `json:"transactionTime"` // optional time of day, HH:MM or HH:MM:SS
 }
 
-// recalcAllBudgetsForTransaction: find budgets that include this transaction's date/category and recalc each.
+// containsPattern escapes s for a LIKE pattern matching values that contain it.
+func containsPattern(s string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s) + "%"
+}
+
+// occurredAt combines a transaction's date with its time of day (HH:MM or HH:MM:SS), nil when the
+// time is not given.
+func occurredAt(date time.Time, timeOfDay string) (*time.Time, error) {
+	if timeOfDay == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, timeOfDay); err == nil {
+			at := time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			return &at, nil
+		}
+	}
+	return nil, errors.New("invalid transaction time format")
+}
+
+// expenseAmount returns how much a transaction counts toward spending:
+// expenses count in full, refunds are netted off, income and transfers are ignored.
+func expenseAmount(tx models.Transaction) float64 {
+	switch tx.Type {
+	case models.TransactionTypeExpense, "":
+		return tx.Amount
+	case models.TransactionTypeRefund:
+		return -tx.Amount
+	default:
+		return 0
+	}
+}
+
+// addToCashFlow adds amount to the summary total for txType.
+func addToCashFlow(summary *models.CashFlowSummary, txType string, amount float64) {
+	switch txType {
+	case models.TransactionTypeIncome:
+		summary.Income += amount
+	case models.TransactionTypeRefund:
+		summary.Refunds += amount
+	case models.TransactionTypeTransfer:
+		summary.Transfers += amount
+	default:
+		summary.Expenses += amount
+	}
+}
+
+// finishCashFlow fills in the derived net figures and rounds to cents.
+func finishCashFlow(summary models.CashFlowSummary) models.CashFlowSummary {
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	summary.Income = round(summary.Income)
+	summary.Expenses = round(summary.Expenses)
+	summary.Refunds = round(summary.Refunds)
+	summary.Transfers = round(summary.Transfers)
+	summary.NetExpenses = round(summary.Expenses - summary.Refunds)
+	summary.NetCashFlow = round(summary.Income - summary.NetExpenses)
+	return summary
+}
+
+// getCashFlowSummary aggregates a user's transactions in [start, end] by type in SQL.
+// Forecasting and gamification use it to read real income and savings.
+func getCashFlowSummary(userID uint, start, end time.Time) (models.CashFlowSummary, error) {
+	var rows []struct {
+		Type  string
+		Total float64
+	}
+	err := db.DB.Model(&models.Transaction{}).
+		Select("type, COALESCE(SUM(amount), 0) as total").
+		Where("user_id = ? AND transaction_date >= ? AND transaction_date <= ?", userID, start, end).
+		Group("type").
+		Scan(&rows).Error
+	if err != nil {
+		return models.CashFlowSummary{}, err
+	}
+
+	var summary models.CashFlowSummary
+	for _, r := range rows {
+		addToCashFlow(&summary, r.Type, r.Total)
+	}
+	return finishCashFlow(summary), nil
+}
+
+// recalcAllBudgetsForTransaction: find budgets that include this transaction's date/category (or a parent category) and recalc each.
 func recalcAllBudgetsForTransaction(tx models.Transaction, log *zap.Logger) {
 	// Budgets that match user_id, date range covers transaction date, and category_id matches or is null for global.
 	var budgets []models.Budget
 	q := db.DB.Where("user_id = ? AND start_date <= ? AND end_date >= ?", tx.UserID, tx.TransactionDate, tx.TransactionDate)
 	if tx.CategoryID != nil {
-		q = q.Where("category_id = ?", *tx.CategoryID)
+		// Budgets on the category's parents cover it too
+		q = q.Where("category_id IN ("+categoryAncestorsSQL+")", *tx.CategoryID)
 	} else {
 		q = q.Where("category_id IS NULL")
 	}
@@ -58,13 +151,34 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 	start := time.Date(txDate.Year(), txDate.Month(), txDate.Day(), 0, 0, 0, 0, time.Local)
+	at, err := occurredAt(start, req.TransactionTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction time format"})
+		return
+	}
+
+	txType := req.Type
+	if txType == "" {
+		txType = models.TransactionTypeExpense
+	}
 
 	newTx := models.Transaction{
 		UserID:          userID,
 		CategoryID:      req.CategoryID,
+		Type:            txType,
 		Amount:          req.Amount,
 		Description:     req.Description,
 		TransactionDate: start,
+		OccurredAt:      at,
+	}
+
+	// Uncategorized transactions go through the user's rules; a rule lookup failure leaves it uncategorized
+	if newTx.CategoryID == nil {
+		rules, err := loadCategoryRules(userID, log)
+		if err != nil {
+			log.Warn("Failed to load category rules", zap.Error(err))
+		}
+		categorizeTransaction(rules, &newTx)
 	}
 
 	if err := db.DB.Create(&newTx).Error; err != nil {
@@ -75,6 +189,13 @@ func CreateTransaction(c *gin.Context) {
 
 	// Recalc all budgets that might include this transaction
 	recalcAllBudgetsForTransaction(newTx, log)
+	reportTransactionAnomalies(newTx, log)
+	publishGamificationEvent(models.GamificationEvent{
+		UserID: userID,
+		Type:   models.EventTransactionAdded,
+		Key:    transactionEventKey(newTx.ID),
+		Reason: "Added a transaction",
+	}, log)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":     "Transaction created successfully",
@@ -82,34 +203,305 @@ func CreateTransaction(c *gin.Context) {
 	})
 }
 
+// Page sizes for GetTransactions
+const (
+	defaultTransactionPageSize = 50
+	maxTransactionPageSize     = 500
+)
+
+// transactionFilters are the listing filters shared by GetTransactions and ExportTransactions.
+type transactionFilters struct {
+	CategoryIDs   []uint
+	Uncategorized bool
+	Type          string
+	StartDate     string
+	EndDate       string
+	MinAmount     *float64
+	MaxAmount     *float64
+	Search        string
+}
+
+// parseTransactionFilters reads the filter query parameters. categoryId may be repeated or
+// comma-separated; uncategorized=true includes rows without a category; q searches descriptions.
+func parseTransactionFilters(c *gin.Context) (transactionFilters, error) {
+	var f transactionFilters
+
+	for _, param := range c.QueryArray("categoryId") {
+		for _, raw := range strings.Split(param, ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				return f, fmt.Errorf("invalid categoryId %q", raw)
+			}
+			f.CategoryIDs = append(f.CategoryIDs, uint(id))
+		}
+	}
+	if raw := c.Query("uncategorized"); raw != "" {
+		uncategorized, err := strconv.ParseBool(raw)
+		if err != nil {
+			return f, errors.New("uncategorized must be true or false")
+		}
+		f.Uncategorized = uncategorized
+	}
+
+	f.Type = c.Query("type")
+	switch f.Type {
+	case "", models.TransactionTypeExpense, models.TransactionTypeIncome, models.TransactionTypeRefund, models.TransactionTypeTransfer:
+	default:
+		return f, errors.New("type must be one of expense, income, refund, transfer")
+	}
+
+	f.StartDate = c.Query("startDate")
+	f.EndDate = c.Query("endDate")
+	for param, value := range map[string]string{"startDate": f.StartDate, "endDate": f.EndDate} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return f, fmt.Errorf("Invalid %s format. Use YYYY-MM-DD", param)
+		}
+	}
+
+	for param, target := range map[string]**float64{"minAmount": &f.MinAmount, "maxAmount": &f.MaxAmount} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return f, fmt.Errorf("invalid %s %q", param, raw)
+		}
+		*target = &value
+	}
+
+	f.Search = strings.TrimSpace(c.Query("q"))
+	return f, nil
+}
+
+// applyTransactionFilters adds f to a query on the transactions table. Columns are qualified so
+// the query can be joined with categories.
+func applyTransactionFilters(query *gorm.DB, userID uint, f transactionFilters) *gorm.DB {
+	query = query.Where("transactions.user_id = ?", userID)
+
+	switch {
+	case len(f.CategoryIDs) > 0 && f.Uncategorized:
+		query = query.Where("transactions.category_id IN ? OR transactions.category_id IS NULL", f.CategoryIDs)
+	case len(f.CategoryIDs) > 0:
+		query = query.Where("transactions.category_id IN ?", f.CategoryIDs)
+	case f.Uncategorized:
+		query = query.Where("transactions.category_id IS NULL")
+	}
+	if f.Type != "" {
+		query = query.Where("transactions.type = ?", f.Type)
+	}
+	if f.StartDate != "" {
+		query = query.Where("transactions.transaction_date >= ?", f.StartDate)
+	}
+	if f.EndDate != "" {
+		query = query.Where("transactions.transaction_date <= ?", f.EndDate)
+	}
+	if f.MinAmount != nil {
+		query = query.Where("transactions.amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		query = query.Where("transactions.amount <= ?", *f.MaxAmount)
+	}
+	if f.Search != "" {
+		query = query.Where("transactions.description LIKE ?", containsPattern(f.Search))
+	}
+	return query
+}
+
+// transactionCursor is the position after the last row of a page. It is handed to clients as an
+// opaque base64 string and records the sort it was made for, so it cannot be replayed against another.
+type transactionCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"` // date as YYYY-MM-DD, or amount
+	ID    uint   `json:"id"`
+}
+
+func encodeTransactionCursor(cursor transactionCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTransactionCursor(raw string) (transactionCursor, error) {
+	var cursor transactionCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return cursor, errors.New("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// transactionSortColumns maps the sort query parameter to its column.
+var transactionSortColumns = map[string]string{
+	"date":   "transactions.transaction_date",
+	"amount": "transactions.amount",
+}
+
+// GetTransactions lists the user's transactions one page at a time. Rows are sorted by date (default) or
+// amount, with id as a tie-breaker so pages are stable, and the response carries a nextCursor to pass
+// back for the following page. total and summary cover every row matching the filters, not just the page.
 func GetTransactions(c *gin.Context) {
 	logger, _ := c.Get("logger")
 	log := logger.(*zap.Logger)
 	userID := c.MustGet("userID").(uint)
 
-	startDate := c.Query("startDate")
-	endDate := c.Query("endDate")
-	categoryParam := c.Query("categoryId")
+	filters, err := parseTransactionFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	var transactions []models.Transaction
-	query := db.DB.Where("user_id = ?", userID)
+	sortBy := c.DefaultQuery("sort", "date")
+	sortColumn, ok := transactionSortColumns[sortBy]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be date or amount"})
+		return
+	}
+	order := strings.ToLower(c.DefaultQuery("order", "desc"))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+
+	limit := defaultTransactionPageSize
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxTransactionPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxTransactionPageSize)})
+			return
+		}
+	}
+
+	var cursor *transactionCursor
+	if raw := c.Query("cursor"); raw != "" {
+		decoded, err := decodeTransactionCursor(raw)
+		if err != nil || decoded.Sort != sortBy || decoded.Order != order {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		cursor = &decoded
+	}
+
+	base := applyTransactionFilters(db.DB.Model(&models.Transaction{}), userID, filters).Session(&gorm.Session{})
 
-	if categoryParam != "" {
-		query = query.Where("category_id = ?", categoryParam)
+	// One aggregate gives both the total count and the cash-flow summary of the filtered set
+	var totals []struct {
+		Type  string
+		Count int64
+		Total float64
 	}
-	if startDate != "" {
-		query = query.Where("transaction_date >= ?", startDate)
+	if err := base.Select("transactions.type, COUNT(*) AS count, COALESCE(SUM(transactions.amount), 0) AS total").
+		Group("transactions.type").
+		Scan(&totals).Error; err != nil {
+		log.Error("Failed to count transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch transactions"})
+		return
 	}
-	if endDate != "" {
-		query = query.Where("transaction_date <= ?", endDate)
+	var total int64
+	var summary models.CashFlowSummary
+	for _, t := range totals {
+		total += t.Count
+		addToCashFlow(&summary, t.Type, t.Total)
+	}
+
+	query := base
+	if cursor != nil {
+		var value interface{}
+		if sortBy == "date" {
+			date, err := time.Parse("2006-01-02", cursor.Value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+			value = dateOnly(date)
+		} else {
+			amount, err := strconv.ParseFloat(cursor.Value, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+			value = amount
+		}
+		cmp := "<"
+		if order == "asc" {
+			cmp = ">"
+		}
+		query = query.Where(fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND transactions.id %[2]s ?)", sortColumn, cmp),
+			value, value, cursor.ID)
 	}
 
-	if err := query.Find(&transactions).Error; err != nil {
+	var transactions []models.Transaction
+	if err := query.Order(fmt.Sprintf("%s %s, transactions.id %s", sortColumn, order, order)).
+		Limit(limit + 1).
+		Find(&transactions).Error; err != nil {
 		log.Error("Failed to fetch transactions", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch transactions"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"transactions": transactions})
+
+	var nextCursor *string
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		next := transactionCursor{Sort: sortBy, Order: order, ID: last.ID}
+		if sortBy == "date" {
+			next.Value = last.TransactionDate.Format("2006-01-02")
+		} else {
+			next.Value = strconv.FormatFloat(last.Amount, 'f', -1, 64)
+		}
+		encoded := encodeTransactionCursor(next)
+		nextCursor = &encoded
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"nextCursor":   nextCursor,
+		"total":        total,
+		"summary":      finishCashFlow(summary),
+	})
+}
+
+// GetCashFlowSummary reports income, expenses, refunds and net cash flow for a date range.
+// Both startDate and endDate are required (YYYY-MM-DD).
+func GetCashFlowSummary(c *gin.Context) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
+	userID := c.MustGet("userID").(uint)
+
+	start, err := time.Parse("2006-01-02", c.Query("startDate"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date"})
+		return
+	}
+	end, err := time.Parse("2006-01-02", c.Query("endDate"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date"})
+		return
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be after start date"})
+		return
+	}
+
+	summary, err := getCashFlowSummary(userID,
+		time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local),
+		time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.Local))
+	if err != nil {
+		log.Error("Failed to summarize cash flow", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not summarize cash flow"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"summary": summary})
 }
 
 // UpdateTransaction: Overwrite existing transaction, then recalc budgets for oldTx and newTx.
@@ -140,12 +532,24 @@ func UpdateTransaction(c *gin.Context) {
 		return
 	}
 	start := time.Date(txDate.Year(), txDate.Month(), txDate.Day(), 0, 0, 0, 0, time.Local)
+	at, err := occurredAt(start, req.TransactionTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction time format"})
+		return
+	}
 
-	// Overwrite
+	// Overwrite; keep the stored type and time of day when the request omits them
 	existing.CategoryID = req.CategoryID
+	if req.Type != "" {
+		existing.Type = req.Type
+	}
 	existing.Amount = req.Amount
 	existing.Description = req.Description
 	existing.TransactionDate = start
+	if at == nil && existing.OccurredAt != nil {
+		at, _ = occurredAt(start, existing.OccurredAt.In(time.Local).Format("15:04:05"))
+	}
+	existing.OccurredAt = at
 
 	if err := db.DB.Save(&existing).Error; err != nil {
 		log.Error("Failed to update transaction", zap.Error(err))
@@ -178,7 +582,13 @@ func DeleteTransaction(c *gin.Context) {
 		return
 	}
 
-	if err := db.DB.Delete(&transaction).Error; err != nil {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&transaction).Error; err != nil {
+			return err
+		}
+		return revokeGamificationPoints(tx, userID, transactionEventKey(transaction.ID))
+	})
+	if err != nil {
 		log.Error("Failed to delete transaction", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete transaction"})
 		return
@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestAnalyticsHandler(t *testing.T) {
+	router, _ := setup()
+	router.GET("/api/v1/features/analytics", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.AnalyticsHandler(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+	summarySQL := regexp.QuoteMeta("SELECT type, COALESCE(SUM(amount), 0) as total FROM `transactions` WHERE (user_id = ? AND transaction_date >= ? AND transaction_date <= ?)")
+
+	t.Run("Success", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(summarySQL).
+			WithArgs(uint(1), date(2024, 6, 1), date(2024, 6, 20)).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "total"}).
+				AddRow("expense", 600.00).AddRow("refund", 20.00).AddRow("income", 3000.00))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT category_id, COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as amount, COUNT(*) as transactions FROM `transactions`")).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, date(2024, 6, 1), date(2024, 6, 20)).
+			WillReturnRows(sqlmock.NewRows([]string{"category_id", "amount", "transactions"}).
+				AddRow(3, 400.00, 4).AddRow(nil, 180.00, 2))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE user_id = ?")).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(3, 1, "Groceries"))
+
+		// A month earlier 500 was spent; a year earlier nothing
+		mock.ExpectQuery(summarySQL).
+			WithArgs(uint(1), date(2024, 5, 1), date(2024, 5, 20)).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "total"}).AddRow("expense", 500.00))
+		mock.ExpectQuery(summarySQL).
+			WithArgs(uint(1), date(2023, 6, 1), date(2023, 6, 20)).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "total"}))
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT description, COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as amount, COUNT(*) as transactions FROM `transactions`")).
+			WillReturnRows(sqlmock.NewRows([]string{"description", "amount", "transactions"}).AddRow("Supermarket", 250.00, 3))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT transaction_date, COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as amount FROM `transactions`")).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, date(2024, 6, 1), date(2024, 6, 20)).
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_date", "amount"}).
+				AddRow(date(2024, 6, 3), 60.00).AddRow(date(2024, 6, 8), 120.00))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date <= ? AND end_date >= ?)")).
+			WithArgs(uint(1), date(2024, 6, 20), date(2024, 6, 1)).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(7, 1, 3, 500.00, 100.00, date(2024, 6, 1), date(2024, 6, 30), "monthly", true, 50.00, nil, 0, false))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND type = ? AND transaction_date >= ? AND transaction_date <= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "amount", "description", "transaction_date"}).
+				AddRow(11, 1, "expense", 120.00, "Supermarket", date(2024, 6, 8)))
+
+		req, _ := http.NewRequest("GET", "/api/v1/features/analytics?startDate=2024-06-01&endDate=2024-06-20", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.AnalyticsReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 20, response.Days)
+		assert.Equal(t, 580.0, response.Summary.NetExpenses)
+		assert.Equal(t, 29.0, response.AverageDailySpend)
+
+		require.Len(t, response.Categories, 2)
+		assert.Equal(t, "Groceries", response.Categories[0].CategoryName)
+		assert.Equal(t, 68.97, response.Categories[0].Share)
+		assert.Nil(t, response.Categories[1].CategoryID)
+		assert.Equal(t, "Uncategorized", response.Categories[1].CategoryName)
+
+		assert.Equal(t, "2024-05-01", response.MonthOverMonth.StartDate)
+		assert.Equal(t, 80.0, response.MonthOverMonth.Change)
+		require.NotNil(t, response.MonthOverMonth.ChangePercent)
+		assert.Equal(t, 16.0, *response.MonthOverMonth.ChangePercent)
+		assert.Equal(t, 580.0, response.YearOverYear.Change)
+		assert.Nil(t, response.YearOverYear.ChangePercent)
+
+		assert.Equal(t, []models.DescriptionSpending{{Description: "Supermarket", Amount: 250, Transactions: 3}}, response.TopDescriptions)
+
+		// Every day of the range, zero on days without spending
+		require.Len(t, response.Daily, 20)
+		assert.Equal(t, models.DailySpending{Date: "2024-06-01", Amount: 0}, response.Daily[0])
+		assert.Equal(t, models.DailySpending{Date: "2024-06-03", Amount: 60}, response.Daily[2])
+		assert.Equal(t, models.DailySpending{Date: "2024-06-08", Amount: 120}, response.Daily[7])
+		assert.Equal(t, "2024-06-20", response.Daily[19].Date)
+
+		// The rollover raises the limit to 550, of which 100 is left
+		require.Len(t, response.BudgetUtilization, 1)
+		assert.Equal(t, 550.0, response.BudgetUtilization[0].Limit)
+		assert.Equal(t, 450.0, response.BudgetUtilization[0].Spent)
+		assert.Equal(t, 81.82, response.BudgetUtilization[0].Utilization)
+
+		require.Len(t, response.LargestTransactions, 1)
+		assert.Equal(t, 120.0, response.LargestTransactions[0].Amount)
+	})
+
+	t.Run("Invalid_Parameters", func(t *testing.T) {
+		for _, query := range []string{"startDate=June", "endDate=2024-13-01", "startDate=2024-06-10&endDate=2024-06-01"} {
+			req, _ := http.NewRequest("GET", "/api/v1/features/analytics?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
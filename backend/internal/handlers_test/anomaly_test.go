@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(2024, m, d, 0, 0, 0, 0, time.Local)
+	}
+	groceries, streaming := uint(1), uint(2)
+	expense := func(id uint, categoryID *uint, amount float64, desc string, when time.Time) models.Transaction {
+		return models.Transaction{ID: id, CategoryID: categoryID, Type: models.TransactionTypeExpense, Amount: amount,
+			Description: desc, TransactionDate: when}
+	}
+
+	// About 100 a month on groceries from January to May, in two shops of about 50
+	var history []models.Transaction
+	for i, amount := range []float64{48, 52, 50, 49, 51, 50, 47, 53, 50, 50} {
+		history = append(history, expense(uint(i+1), &groceries, amount, "Market", date(time.Month(i/2+1), 5+15*(i%2))))
+	}
+
+	t.Run("Finds each kind", func(t *testing.T) {
+		june := append(append([]models.Transaction(nil), history...),
+			expense(101, &groceries, 400, "Market", date(6, 3)),
+			expense(102, &streaming, 15.99, "Netflix", date(6, 10)),
+			expense(103, &streaming, 15.99, " netflix", date(6, 10)),
+			expense(104, nil, 900, "Jeweler", date(6, 15)),
+			// A refund is not a charge
+			models.Transaction{ID: 105, CategoryID: &streaming, Type: models.TransactionTypeRefund, Amount: 15.99,
+				Description: "Netflix", TransactionDate: date(6, 10)},
+		)
+
+		anomalies := handlers.TestableDetectAnomalies(june, date(6, 1), date(6, 30))
+		require.Len(t, anomalies, 4)
+
+		assert.Equal(t, models.Anomaly{
+			Kind: models.AnomalyKindOutlier, TransactionIDs: []uint{101}, CategoryID: &groceries, Date: "2024-06-03",
+			Amount: 400, Expected: 50, Score: 70, Message: "Market: 400.00 is far above the usual 50.00 for this category",
+		}, anomalies[0])
+
+		// The jewelry is uncategorized, so there is no category history to call it an outlier against
+		assert.Equal(t, models.AnomalyKindFirstLargePurchase, anomalies[1].Kind)
+		assert.Equal(t, []uint{104}, anomalies[1].TransactionIDs)
+		assert.Nil(t, anomalies[1].CategoryID)
+
+		assert.Equal(t, models.AnomalyKindDuplicate, anomalies[2].Kind)
+		assert.Equal(t, []uint{102, 103}, anomalies[2].TransactionIDs)
+		assert.Equal(t, "Netflix: charged 2 times on 2024-06-10 for 15.99", anomalies[2].Message)
+
+		assert.Equal(t, models.Anomaly{
+			Kind: models.AnomalyKindCategoryMonth, CategoryID: &groceries, Month: "2024-06", Amount: 400, Expected: 100, Score: 30,
+			Message: "Spending of 400.00 in 2024-06 is far above this category's usual 100.00 a month",
+		}, anomalies[3])
+	})
+
+	t.Run("Ordinary spending", func(t *testing.T) {
+		june := append(append([]models.Transaction(nil), history...),
+			expense(101, &groceries, 55, "Market", date(6, 3)),
+			expense(102, &groceries, 45, "Market", date(6, 18)),
+			expense(103, &streaming, 15.99, "Netflix", date(6, 10)),
+		)
+
+		assert.Empty(t, handlers.TestableDetectAnomalies(june, date(6, 1), date(6, 30)))
+	})
+
+	t.Run("Short history", func(t *testing.T) {
+		// Four earlier transactions over two months are not enough to judge the category by
+		june := append(append([]models.Transaction(nil), history[6:]...),
+			expense(101, &groceries, 400, "Market", date(6, 3)))
+
+		assert.Empty(t, handlers.TestableDetectAnomalies(june, date(6, 1), date(6, 30)))
+	})
+
+	t.Run("Only the period is checked", func(t *testing.T) {
+		// May's duplicates and outlier are history for a June report
+		may := append(append([]models.Transaction(nil), history...),
+			expense(101, &groceries, 400, "Market", date(5, 25)),
+			expense(102, &groceries, 400, "Market", date(5, 25)))
+
+		assert.Empty(t, handlers.TestableDetectAnomalies(may, date(6, 1), date(6, 30)))
+	})
+}
+
+func TestGetSpendingAnomalies(t *testing.T) {
+	router, _ := setup()
+	router.GET("/api/v1/features/analytics/anomalies", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.GetSpendingAnomalies(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	t.Run("Success", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		charged := time.Date(2024, 6, 10, 0, 0, 0, 0, time.Local)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND type IN (?,?)) AND (transaction_date >= ? AND transaction_date < ?)")).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund,
+				time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local), time.Date(2024, 6, 21, 0, 0, 0, 0, time.Local)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "amount", "description", "transaction_date"}).
+				AddRow(1, 1, "expense", 42.50, "Gym", charged).
+				AddRow(2, 1, "expense", 42.50, "Gym", charged))
+
+		req, _ := http.NewRequest("GET", "/api/v1/features/analytics/anomalies?startDate=2024-06-01&endDate=2024-06-20", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.AnomalyReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "2024-06-01", response.StartDate)
+		require.Len(t, response.Anomalies, 1)
+		assert.Equal(t, models.AnomalyKindDuplicate, response.Anomalies[0].Kind)
+		assert.Equal(t, []uint{1, 2}, response.Anomalies[0].TransactionIDs)
+	})
+
+	t.Run("Invalid_Parameters", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/features/analytics/anomalies?startDate=2024-06-10&endDate=2024-06-01", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
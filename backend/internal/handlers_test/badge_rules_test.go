@@ -0,0 +1,113 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestParseBadgeRule(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rule  string
+		valid bool
+	}{
+		{"Comparison", `{"stat": "transaction_count", "op": ">=", "value": 100}`, true},
+		{"Nested", `{"all": [{"stat": "tracking_streak", "op": ">=", "value": 7}, {"any": [{"stat": "savings_rate", "op": ">", "value": 10}]}]}`, true},
+		{"Not_JSON", `{"stat": `, false},
+		{"Unknown_Statistic", `{"stat": "logins", "op": ">=", "value": 1}`, false},
+		{"Unknown_Operator", `{"stat": "budget_count", "op": "!=", "value": 1}`, false},
+		{"Stat_And_All", `{"stat": "budget_count", "op": ">=", "value": 1, "all": [{"stat": "budget_count", "op": ">=", "value": 1}]}`, false},
+		{"Empty_Any", `{"any": []}`, false},
+		{"Empty_Rule", `{}`, false},
+		{"Invalid_Nested_Rule", `{"all": [{"stat": "budget_count", "op": ">="}, {"stat": "streak", "op": ">=", "value": 1}]}`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := handlers.ParseBadgeRule(json.RawMessage(tc.rule))
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestEvaluateBadgeRule(t *testing.T) {
+	stats := map[string]float64{
+		models.BadgeStatTransactionCount: 120,
+		models.BadgeStatTrackingStreak:   5,
+		models.BadgeStatSavingsRate:      12.5,
+	}
+
+	testCases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{"At_Least_Met", `{"stat": "transaction_count", "op": ">=", "value": 100}`, true},
+		{"At_Least_Not_Met", `{"stat": "tracking_streak", "op": ">=", "value": 7}`, false},
+		{"Less_Than", `{"stat": "tracking_streak", "op": "<", "value": 7}`, true},
+		{"Equal", `{"stat": "savings_rate", "op": "==", "value": 12.5}`, true},
+		{"All_Needs_Every_Rule", `{"all": [{"stat": "transaction_count", "op": ">=", "value": 100}, {"stat": "tracking_streak", "op": ">=", "value": 7}]}`, false},
+		{"Any_Needs_One_Rule", `{"any": [{"stat": "tracking_streak", "op": ">=", "value": 7}, {"stat": "savings_rate", "op": ">", "value": 10}]}`, true},
+		// Statistics after the deciding rule are never loaded
+		{"All_Stops_At_First_Failure", `{"all": [{"stat": "tracking_streak", "op": ">=", "value": 7}, {"stat": "budget_count", "op": ">=", "value": 1}]}`, false},
+		{"Any_Stops_At_First_Match", `{"any": [{"stat": "savings_rate", "op": ">", "value": 10}, {"stat": "budget_count", "op": ">=", "value": 1}]}`, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := handlers.ParseBadgeRule(json.RawMessage(tc.rule))
+			require.NoError(t, err)
+
+			met, err := handlers.TestableEvaluateBadgeRule(rule, stats)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, met)
+		})
+	}
+}
+
+func TestLongestDailyStreak(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.Local) }
+
+	assert.Equal(t, 0, handlers.TestableLongestDailyStreak(nil))
+	assert.Equal(t, 1, handlers.TestableLongestDailyStreak([]time.Time{day(4)}))
+	assert.Equal(t, 3, handlers.TestableLongestDailyStreak([]time.Time{day(1), day(2), day(5), day(6), day(6), day(7), day(9)}))
+	// Runs carry across month ends
+	assert.Equal(t, 4, handlers.TestableLongestDailyStreak([]time.Time{
+		time.Date(2024, 12, 30, 0, 0, 0, 0, time.Local), time.Date(2024, 12, 31, 0, 0, 0, 0, time.Local), day(1), day(2),
+	}))
+}
+
+func TestLongestMonthsUnderBudget(t *testing.T) {
+	ended := func(year int, month time.Month, remaining float64) models.Budget {
+		return models.Budget{EndDate: time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local), RemainingAmount: remaining}
+	}
+
+	testCases := []struct {
+		name     string
+		budgets  []models.Budget
+		expected int
+	}{
+		{"No_Budgets", nil, 0},
+		{"Consecutive_Months", []models.Budget{ended(2024, 11, 10), ended(2024, 12, 0), ended(2025, 1, 35)}, 3},
+		{"One_Overspent_Budget_Spoils_Its_Month", []models.Budget{ended(2025, 1, 10), ended(2025, 2, 10), ended(2025, 2, -5), ended(2025, 3, 20)}, 1},
+		{"Gap_Breaks_The_Run", []models.Budget{ended(2025, 1, 10), ended(2025, 3, 10), ended(2025, 4, 10)}, 2},
+		{"Unordered", []models.Budget{ended(2025, 6, 10), ended(2025, 4, 10), ended(2025, 5, 10)}, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, handlers.TestableLongestMonthsUnderBudget(tc.budgets))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/middlewares"
+)
+
+func TestBadgeAdmin(t *testing.T) {
+	router, _ := setup()
+	admin := router.Group("/api/v1/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		c.Next()
+	}, middlewares.AdminMiddleware())
+	admin.POST("/badges", handlers.CreateBadge)
+	admin.PUT("/badges/:id", handlers.UpdateBadge)
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	roleQuery := regexp.QuoteMeta("SELECT `id`,`is_admin` FROM `users` WHERE `users`.`id` = ?")
+	isAdmin := func(admin bool) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "is_admin"}).AddRow(1, admin)
+	}
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Refuses_Non_Admins", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(roleQuery).WithArgs(1, 1).WillReturnRows(isAdmin(false))
+
+		w := send("POST", "/api/v1/admin/badges", `{"name": "Saver", "description": "Saved", "category": "savings"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejects_Invalid_Rule", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(roleQuery).WillReturnRows(isAdmin(true))
+
+		w := send("POST", "/api/v1/admin/badges",
+			`{"name": "Saver", "description": "Saved", "category": "savings", "rule": {"stat": "logins", "op": ">=", "value": 1}}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid badge rule")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Creates_Badge", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		rule := `{"stat":"savings_rate","op":">=","value":20}`
+		mock.ExpectQuery(roleQuery).WillReturnRows(isAdmin(true))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `badges` WHERE name = ? AND id <> ?")).
+			WithArgs("Saver", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `badges`")).
+			WithArgs("Saver", "Saved a fifth", "", "savings", 0, []byte(rule), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(9, 1))
+		mock.ExpectCommit()
+
+		w := send("POST", "/api/v1/admin/badges",
+			`{"name": " Saver ", "description": "Saved a fifth", "category": "savings", "rule": `+rule+`}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response struct {
+			Badge struct {
+				ID   uint            `json:"id"`
+				Rule json.RawMessage `json:"rule"`
+			} `json:"badge"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, uint(9), response.Badge.ID)
+		assert.JSONEq(t, rule, string(response.Badge.Rule))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update_Rejects_Invalid_Rule", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(roleQuery).WillReturnRows(isAdmin(true))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `badges` WHERE `badges`.`id` = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "category", "threshold"}).
+				AddRow(3, "Saver", "Saved", "savings", 0))
+
+		w := send("PUT", "/api/v1/admin/badges/3",
+			`{"name": "Saver", "description": "Saved", "category": "savings", "rule": {"any": []}}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Updates_Badge", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(roleQuery).WillReturnRows(isAdmin(true))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `badges` WHERE `badges`.`id` = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "category", "threshold"}).
+				AddRow(3, "Saver", "Saved", "savings", 0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `badges` WHERE name = ? AND id <> ?")).
+			WithArgs("Big Saver", uint(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `badges` SET")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		w := send("PUT", "/api/v1/admin/badges/3",
+			`{"name": "Big Saver", "description": "Saved", "category": "savings", "threshold": 500}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
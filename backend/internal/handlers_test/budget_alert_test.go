@@ -0,0 +1,268 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestReachedBudgetThresholds(t *testing.T) {
+	percent := func(v float64) *float64 { return &v }
+
+	testCases := []struct {
+		name     string
+		budget   models.Budget
+		expected []float64
+	}{
+		{"Under_Half", models.Budget{LimitAmount: 500, RemainingAmount: 260}, nil},
+		{"Exactly_Half", models.Budget{LimitAmount: 500, RemainingAmount: 250}, []float64{50}},
+		{"Over_Limit", models.Budget{LimitAmount: 500, RemainingAmount: -20}, []float64{50, 80, 100}},
+		{"Rollover_Raises_The_Limit", models.Budget{LimitAmount: 500, RolloverAmount: 100, RemainingAmount: 110}, []float64{50, 80}},
+		{"Custom_Threshold", models.Budget{LimitAmount: 200, RemainingAmount: 20, AlertPercent: percent(90)}, []float64{50, 80, 90}},
+		{"Custom_Threshold_Equal_To_A_Default", models.Budget{LimitAmount: 200, RemainingAmount: 20, AlertPercent: percent(80)}, []float64{50, 80}},
+		{"No_Limit_Left_After_Rollover", models.Budget{LimitAmount: 100, RolloverAmount: -150, RemainingAmount: -80}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, handlers.TestableReachedBudgetThresholds(tc.budget))
+		})
+	}
+}
+
+func TestAlertBudgetThresholds(t *testing.T) {
+	_, logger := setup()
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	type email struct{ to, subject string }
+	sent := make(chan email, 1)
+	defer handlers.TestableSetAlertMailer(func(to, subject, body string) error {
+		sent <- email{to, subject}
+		return nil
+	})()
+
+	today := time.Now()
+	categoryID := uint(3)
+	budget := models.Budget{
+		ID:              7,
+		UserID:          1,
+		CategoryID:      &categoryID,
+		LimitAmount:     500,
+		RemainingAmount: 90, // 410 spent, 82%
+		StartDate:       time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local),
+		EndDate:         time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, time.Local),
+	}
+	alertsSQL := regexp.QuoteMeta("SELECT `threshold` FROM `budget_alerts` WHERE budget_id = ?")
+
+	t.Run("Alerts_A_New_Threshold_Once", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		// 50% was alerted earlier in the period
+		mock.ExpectQuery(alertsSQL).
+			WithArgs(uint(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"threshold"}).AddRow(50.0))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `budget_alerts` (`budget_id`,`threshold`,`created_at`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE")).
+			WithArgs(uint(7), 80.0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `name` FROM `categories`")).
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Groceries"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`email`,`currency`,`notifications_enabled` FROM `users`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "currency", "notifications_enabled"}).
+				AddRow(1, "test@example.com", "USD", true))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `notifications`")).
+			WithArgs(uint(1), models.NotificationTypeBudget, models.NotificationSeverityWarning, "Groceries budget at 80%",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		handlers.TestableAlertBudgetThresholds(budget, logger)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		select {
+		case e := <-sent:
+			assert.Equal(t, email{"test@example.com", "FinTrack: Groceries budget at 80%"}, e)
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected an alert email")
+		}
+	})
+
+	t.Run("Recalculating_Does_Not_Alert_Again", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(alertsSQL).
+			WithArgs(uint(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"threshold"}).AddRow(50.0).AddRow(80.0))
+
+		handlers.TestableAlertBudgetThresholds(budget, logger)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Ended_Period", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		ended := budget
+		ended.StartDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+		ended.EndDate = time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)
+
+		handlers.TestableAlertBudgetThresholds(ended, logger)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	assert.Empty(t, sent)
+}
+
+func TestBudgetAlertTermsChanged(t *testing.T) {
+	percent := func(v float64) *float64 { return &v }
+	category := func(id uint) *uint { return &id }
+	before := models.Budget{
+		CategoryID:   category(3),
+		LimitAmount:  500,
+		StartDate:    time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local),
+		EndDate:      time.Date(2025, 3, 31, 0, 0, 0, 0, time.Local),
+		AlertPercent: percent(90),
+		Recurrence:   models.BudgetRecurrenceMonthly,
+	}
+
+	testCases := []struct {
+		name     string
+		edit     func(b *models.Budget)
+		expected bool
+	}{
+		{"Unchanged", func(b *models.Budget) {}, false},
+		{"Same_Values_New_Pointers", func(b *models.Budget) { b.CategoryID, b.AlertPercent = category(3), percent(90) }, false},
+		{"Recurrence_Only", func(b *models.Budget) { b.Recurrence = "" }, false},
+		{"Limit", func(b *models.Budget) { b.LimitAmount = 800 }, true},
+		{"Start_Date", func(b *models.Budget) { b.StartDate = b.StartDate.AddDate(0, 0, 1) }, true},
+		{"End_Date", func(b *models.Budget) { b.EndDate = b.EndDate.AddDate(0, 0, 1) }, true},
+		{"Alert_Percent", func(b *models.Budget) { b.AlertPercent = percent(95) }, true},
+		{"Alert_Percent_Removed", func(b *models.Budget) { b.AlertPercent = nil }, true},
+		{"Category", func(b *models.Budget) { b.CategoryID = nil }, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			after := before
+			tc.edit(&after)
+			assert.Equal(t, tc.expected, handlers.TestableBudgetAlertTermsChanged(before, after))
+		})
+	}
+}
+
+func TestEditedBudgetAlertsAfresh(t *testing.T) {
+	router, _ := setup()
+	router.PUT("/api/v1/budgets/:id", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.UpdateBudget(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	today := time.Now()
+	start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, time.Local)
+	budgetColumns := []string{"id", "user_id", "category_id", "limit_amount", "remaining_amount", "start_date", "end_date"}
+	update := func(limit float64) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"categoryId":  3,
+			"limitAmount": limit,
+			"startDate":   start.Format("2006-01-02"),
+			"endDate":     end.Format("2006-01-02"),
+		})
+		req, _ := http.NewRequest("PUT", "/api/v1/budgets/7", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Raised_Limit_Alerts_Again", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		// 410 of 500 spent: 50% and 80% were alerted
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE")).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).AddRow(7, 1, 3, 500.0, 90.0, start, end))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `budget_alerts` WHERE budget_id = ?")).
+			WithArgs(uint(7)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		// Spending has since grown to 850 of the new 1000
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(")).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(850.0))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `threshold` FROM `budget_alerts` WHERE budget_id = ?")).
+			WithArgs(uint(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"threshold"}))
+		for i, threshold := range []float64{50, 80} {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `budget_alerts`")).
+				WithArgs(uint(7), threshold, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(int64(i+3), 1))
+			mock.ExpectCommit()
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `name` FROM `categories`")).
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Groceries"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`email`,`currency`,`notifications_enabled` FROM `users`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "currency", "notifications_enabled"}).
+				AddRow(1, "test@example.com", "USD", false))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `notifications`")).
+			WithArgs(uint(1), models.NotificationTypeBudget, models.NotificationSeverityWarning, "Groceries budget at 80%",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		w := update(1000)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unchanged_Terms_Keep_Alerts", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE")).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).AddRow(7, 1, 3, 500.0, 90.0, start, end))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(")).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(410.0))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `threshold` FROM `budget_alerts` WHERE budget_id = ?")).
+			WithArgs(uint(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"threshold"}).AddRow(50.0).AddRow(80.0))
+
+		w := update(500)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
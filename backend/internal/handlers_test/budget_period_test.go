@@ -0,0 +1,214 @@
+package handlers_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+)
+
+var budgetColumns = []string{"id", "user_id", "category_id", "limit_amount", "remaining_amount", "start_date", "end_date",
+	"recurrence", "rollover", "rollover_amount", "series_id", "period_index", "next_period_opened"}
+
+func TestBudgetPeriodBounds(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+
+	testCases := []struct {
+		name          string
+		anchor        time.Time
+		recurrence    string
+		k             int
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{"Weekly", date(2024, 1, 1), "weekly", 2, date(2024, 1, 15), date(2024, 1, 21)},
+		{"Monthly_February", date(2024, 1, 1), "monthly", 1, date(2024, 2, 1), date(2024, 2, 29)},
+		{"Monthly_Anchor_On_31st", date(2024, 1, 31), "monthly", 1, date(2024, 2, 29), date(2024, 3, 30)},
+		{"Monthly_Anchor_On_31st_No_Drift", date(2024, 1, 31), "monthly", 2, date(2024, 3, 31), date(2024, 4, 29)},
+		{"Quarterly", date(2024, 1, 1), "quarterly", 1, date(2024, 4, 1), date(2024, 6, 30)},
+		{"Yearly", date(2024, 1, 1), "yearly", 1, date(2025, 1, 1), date(2025, 12, 31)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end := handlers.TestableBudgetPeriodBounds(tc.anchor, tc.recurrence, tc.k)
+			assert.Equal(t, tc.expectedStart.Format("2006-01-02"), start.Format("2006-01-02"))
+			assert.Equal(t, tc.expectedEnd.Format("2006-01-02"), end.Format("2006-01-02"))
+		})
+	}
+}
+
+func TestOpenDueBudgetPeriods(t *testing.T) {
+	_, logger := setup()
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)
+	row := []driver.Value{1, 1, 3, 500.00, 500.00, start, end, "monthly", true, 0.00, nil, 0, false}
+
+	// 1. Budgets whose period has ended
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE recurrence <> '' AND next_period_opened = ? AND end_date < ?")).
+		WillReturnRows(sqlmock.NewRows(budgetColumns).AddRow(row...))
+
+	// 2. Settle the closing period: 420 spent, 80 left over
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as total FROM `transactions`")).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(420.00))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// 3. Open February with the 80 carried over
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE `budgets`.`id` = ?")).
+		WillReturnRows(sqlmock.NewRows(budgetColumns).AddRow(row...))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `budgets`")).
+		WithArgs(uint(1), uint(3), 500.00, 0.0,
+			time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local), time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local),
+			"monthly", true, 80.00, uint(1), 1, false, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET `next_period_opened`=?")).
+		WithArgs(true, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// 4. Recalculate the new period
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as total FROM `transactions`")).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	opened, err := handlers.OpenDueBudgetPeriods(time.Date(2024, 2, 10, 9, 0, 0, 0, time.Local), logger)
+	require.NoError(t, err)
+	assert.Equal(t, 1, opened)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOpenDueBudgetPeriodsAfterDeletedPeriod(t *testing.T) {
+	_, logger := setup()
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	// February, the current period of a series started in January, was deleted before it ended
+	columns := append(append([]string{}, budgetColumns...), "deleted_at")
+	start := time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)
+	deletedAt := time.Date(2024, 2, 10, 12, 0, 0, 0, time.Local)
+	row := []driver.Value{2, 1, 3, 500.00, 120.00, start, end, "monthly", true, 80.00, 1, 1, false, deletedAt}
+
+	// 1. Deleted periods are due as well
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT * FROM `budgets` WHERE recurrence <> '' AND next_period_opened = ? AND end_date < ?") + "$").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(row...))
+
+	// 2. The deleted period is not settled; the schedule comes from the series root
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `start_date` FROM `budgets` WHERE `budgets`.`id` = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"start_date"}).AddRow(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)))
+
+	// 3. Open March with nothing carried over
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE `budgets`.`id` = ?")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(row...))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `budgets`")).
+		WithArgs(uint(1), uint(3), 500.00, 0.0,
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local),
+			"monthly", true, 0.0, uint(1), 2, false, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET `next_period_opened`=?,`updated_at`=? WHERE `id` = ?")).
+		WithArgs(true, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// 4. Recalculate the new period
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as total FROM `transactions`")).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	opened, err := handlers.OpenDueBudgetPeriods(time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local), logger)
+	require.NoError(t, err)
+	assert.Equal(t, 1, opened)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBudgetHistory(t *testing.T) {
+	router, _ := setup()
+	router.GET("/api/v1/budgets/:id/history", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.GetBudgetHistory(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	t.Run("Lists_All_Periods_Of_Series", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+		feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)
+
+		// Requested period is the second one in series 1
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (id = ? AND user_id = ?)")).
+			WithArgs("2", uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(2, 1, nil, 500.00, 580.00, feb, feb.AddDate(0, 1, -1), "monthly", true, 80.00, 1, 1, false))
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND (id = ? OR series_id = ?)) AND `budgets`.`deleted_at` IS NULL ORDER BY period_index")).
+			WithArgs(uint(1), uint(1), uint(1)).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(1, 1, nil, 500.00, 80.00, jan, jan.AddDate(0, 1, -1), "monthly", true, 0.00, nil, 0, true).
+				AddRow(2, 1, nil, 500.00, 580.00, feb, feb.AddDate(0, 1, -1), "monthly", true, 80.00, 1, 1, false))
+
+		req, _ := http.NewRequest("GET", "/api/v1/budgets/2/history", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string][]map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response["budgets"], 2)
+		assert.Equal(t, 80.0, response["budgets"][1]["RolloverAmount"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Budget_Not_Found", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (id = ? AND user_id = ?)")).
+			WillReturnRows(sqlmock.NewRows(budgetColumns))
+
+		req, _ := http.NewRequest("GET", "/api/v1/budgets/99/history", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,239 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestProjectBudget(t *testing.T) {
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(2024, m, d, 0, 0, 0, 0, time.Local)
+	}
+	category := uint(3)
+	budget := models.Budget{ID: 7, UserID: 1, CategoryID: &category, LimitAmount: 300, StartDate: date(6, 1), EndDate: date(6, 30)}
+	rentID := uint(5)
+
+	// Ten days of June: 10 a day of groceries, plus a recurring 50 booked on the 1st
+	var june []models.Transaction
+	for d := 1; d <= 10; d++ {
+		june = append(june, models.Transaction{Type: models.TransactionTypeExpense, Amount: 10, TransactionDate: date(6, d)})
+	}
+	june = append(june, models.Transaction{Type: models.TransactionTypeExpense, Amount: 50, TransactionDate: date(6, 1), RecurringTransactionID: &rentID})
+
+	// A 60 subscription renews on the 25th of every month; May's is booked already
+	subscription := models.RecurringTransaction{ID: 9, Type: models.TransactionTypeExpense, Amount: 60, Description: "Gym",
+		Frequency: models.FrequencyMonthly, Interval: 1, StartDate: date(5, 25), OccurrencesGenerated: 1, Active: true}
+
+	t.Run("Velocity and recurring items", func(t *testing.T) {
+		p := handlers.TestableProjectBudget(budget, june, []models.RecurringTransaction{subscription}, date(6, 10))
+
+		assert.Equal(t, 10, p.DaysElapsed)
+		assert.Equal(t, 30, p.DaysTotal)
+		assert.Equal(t, 150.0, p.Spent)
+		assert.Equal(t, 10.0, p.DailyVelocity)
+		assert.Equal(t, 10.0, p.ProjectedDailyRate)
+		assert.Empty(t, p.BaselineModel)
+		assert.Equal(t, []models.ProjectedRecurringItem{
+			{RecurringTransactionID: 9, Date: "2024-06-25", Amount: 60, Description: "Gym"},
+		}, p.UpcomingRecurring)
+
+		// 150 + 20 days at 10 + 60: the limit is crossed when the subscription renews on the 25th
+		assert.Equal(t, 410.0, p.ProjectedSpend)
+		assert.Equal(t, -110.0, p.ProjectedRemaining)
+		assert.Equal(t, 110.0, p.Overrun)
+		require.NotNil(t, p.ExceedDate)
+		assert.Equal(t, "2024-06-25", *p.ExceedDate)
+		assert.Equal(t, models.BudgetStatusAtRisk, p.Status)
+	})
+
+	t.Run("Rollover raises the limit", func(t *testing.T) {
+		rolled := budget
+		rolled.RolloverAmount = 150
+
+		p := handlers.TestableProjectBudget(rolled, june, []models.RecurringTransaction{subscription}, date(6, 10))
+
+		assert.Equal(t, 450.0, p.Limit)
+		assert.Equal(t, 40.0, p.ProjectedRemaining)
+		assert.Equal(t, 0.0, p.Overrun)
+		assert.Nil(t, p.ExceedDate)
+		assert.Equal(t, models.BudgetStatusOnTrack, p.Status)
+	})
+
+	t.Run("Baseline outweighs an early splurge", func(t *testing.T) {
+		// Three quiet months at 150, then 60 spent on June 1st
+		transactions := []models.Transaction{
+			{Type: models.TransactionTypeExpense, Amount: 150, TransactionDate: date(3, 15)},
+			{Type: models.TransactionTypeExpense, Amount: 150, TransactionDate: date(4, 15)},
+			{Type: models.TransactionTypeExpense, Amount: 150, TransactionDate: date(5, 15)},
+			{Type: models.TransactionTypeExpense, Amount: 60, TransactionDate: date(6, 1)},
+		}
+
+		p := handlers.TestableProjectBudget(budget, transactions, nil, date(6, 1))
+
+		assert.Equal(t, models.ForecastModelLinearTrend, p.BaselineModel)
+		assert.Equal(t, 60.0, p.DailyVelocity)
+		assert.Equal(t, 4.93, p.BaselineDailyRate)
+		// One day in, the velocity gets a thirtieth of the weight
+		assert.Equal(t, 6.76, p.ProjectedDailyRate)
+		assert.Equal(t, models.BudgetStatusOnTrack, p.Status)
+
+		// Without the history, the same first day extrapolates to an overrun
+		p = handlers.TestableProjectBudget(budget, transactions[3:], nil, date(6, 1))
+		assert.Equal(t, models.BudgetStatusAtRisk, p.Status)
+		assert.Equal(t, "2024-06-06", *p.ExceedDate)
+	})
+
+	t.Run("Already exceeded", func(t *testing.T) {
+		transactions := append([]models.Transaction{
+			{Type: models.TransactionTypeExpense, Amount: 200, TransactionDate: date(6, 8)},
+			{Type: models.TransactionTypeRefund, Amount: 20, TransactionDate: date(6, 9)},
+		}, june...)
+
+		p := handlers.TestableProjectBudget(budget, transactions, nil, date(6, 10))
+
+		assert.Equal(t, 330.0, p.Spent)
+		assert.Equal(t, models.BudgetStatusExceeded, p.Status)
+		assert.Equal(t, "2024-06-08", *p.ExceedDate)
+	})
+
+	t.Run("Due recurring item not booked yet", func(t *testing.T) {
+		late := subscription
+		late.StartDate = date(5, 5)
+
+		p := handlers.TestableProjectBudget(budget, june, []models.RecurringTransaction{late}, date(6, 10))
+
+		// June 5th's occurrence is still to be booked; it is expected the day after asOf
+		require.Len(t, p.UpcomingRecurring, 1)
+		assert.Equal(t, "2024-06-05", p.UpcomingRecurring[0].Date)
+		assert.Equal(t, 410.0, p.ProjectedSpend)
+		assert.Equal(t, "2024-06-20", *p.ExceedDate)
+	})
+
+	t.Run("Finished period", func(t *testing.T) {
+		p := handlers.TestableProjectBudget(budget, june, []models.RecurringTransaction{subscription}, date(7, 3))
+
+		assert.Equal(t, 30, p.DaysElapsed)
+		assert.Empty(t, p.UpcomingRecurring)
+		assert.Equal(t, 150.0, p.ProjectedSpend)
+		assert.Equal(t, models.BudgetStatusOnTrack, p.Status)
+	})
+}
+
+func TestGetBudgetProjection(t *testing.T) {
+	router, _ := setup()
+	router.GET("/api/v1/budgets/:id/projection", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.GetBudgetProjection(c)
+	})
+	router.GET("/api/v1/budgets/projections", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.GetBudgetProjections(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	asOf := time.Date(2024, 6, 10, 0, 0, 0, 0, time.Local)
+	budgetRow := func(id int) *sqlmock.Rows {
+		return sqlmock.NewRows(budgetColumns).
+			AddRow(id, 1, 3, 300.00, 150.00, june, june.AddDate(0, 1, -1), "", false, 0.00, nil, 0, false)
+	}
+	expectScope := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND type IN (?,?)) AND category_id IN (WITH RECURSIVE")).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, uint(3), june.AddDate(0, -12, 0), asOf).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category_id", "type", "amount", "transaction_date"}).
+				AddRow(1, 1, 3, "expense", 150.00, asOf))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recurring_transactions` WHERE (user_id = ? AND active = ? AND type IN (?,?)) AND category_id IN (WITH RECURSIVE")).
+			WithArgs(uint(1), true, models.TransactionTypeExpense, models.TransactionTypeRefund, uint(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category_id", "type", "amount", "description", "frequency",
+				"repeat_interval", "start_date", "occurrences_generated", "next_occurrence", "active"}).
+				AddRow(4, 1, 3, "expense", 60.00, "Gym", "monthly", 1, june.AddDate(0, 0, 24), 0, june.AddDate(0, 0, 24), true))
+	}
+
+	t.Run("Single_Budget", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (id = ? AND user_id = ?)")).
+			WithArgs("2", uint(1), 1).
+			WillReturnRows(budgetRow(2))
+		expectScope(mock)
+
+		req, _ := http.NewRequest("GET", "/api/v1/budgets/2/projection?asOf=2024-06-10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response map[string]models.BudgetProjection
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		p := response["projection"]
+		assert.Equal(t, uint(2), p.BudgetID)
+		assert.Equal(t, 150.0, p.Spent)
+		assert.Equal(t, 15.0, p.DailyVelocity)
+		assert.Equal(t, 510.0, p.ProjectedSpend)
+		assert.Equal(t, "2024-06-21", *p.ExceedDate)
+		assert.Equal(t, models.BudgetStatusAtRisk, p.Status)
+		require.Len(t, p.UpcomingRecurring, 1)
+	})
+
+	t.Run("Active_Budgets", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date <= ? AND end_date >= ?) AND `budgets`.`deleted_at` IS NULL ORDER BY id")).
+			WithArgs(uint(1), asOf, asOf).
+			WillReturnRows(budgetRow(2))
+		expectScope(mock)
+
+		req, _ := http.NewRequest("GET", "/api/v1/budgets/projections?asOf=2024-06-10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response map[string][]models.BudgetProjection
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response["projections"], 1)
+		assert.Equal(t, models.BudgetStatusAtRisk, response["projections"][0].Status)
+	})
+
+	t.Run("Budget_Not_Found", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (id = ? AND user_id = ?)")).
+			WillReturnRows(sqlmock.NewRows(budgetColumns))
+
+		req, _ := http.NewRequest("GET", "/api/v1/budgets/99/projection", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid_Date", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/budgets/projections?asOf=June", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 	"time"
 
@@ -39,10 +40,11 @@ func TestCreateCategory(t *testing.T) {
 
 		// Expect the category to be saved - match the exact column order GORM uses
 		mock.ExpectBegin()
-		mock.ExpectExec("INSERT INTO `categories` \\(`user_id`,`name`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)").
+		mock.ExpectExec("INSERT INTO `categories` \\(`user_id`,`name`,`parent_id`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").
 			WithArgs(
 				uint(1),          // User ID
 				"Groceries",      // Name
+				nil,              // Parent ID
 				sqlmock.AnyArg(), // Created at
 				sqlmock.AnyArg(), // Updated at
 				nil,              // Deleted at
@@ -86,10 +88,11 @@ func TestCreateCategory(t *testing.T) {
 
 		// Expect the category to be updated - match the exact column order GORM uses
 		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE `categories` SET `user_id`=\\?,`name`=\\?,`created_at`=\\?,`updated_at`=\\?,`deleted_at`=\\? WHERE `categories`.`deleted_at` IS NULL AND `id` = \\?").
+		mock.ExpectExec("UPDATE `categories` SET `user_id`=\\?,`name`=\\?,`parent_id`=\\?,`created_at`=\\?,`updated_at`=\\?,`deleted_at`=\\? WHERE `categories`.`deleted_at` IS NULL AND `id` = \\?").
 			WithArgs(
 				uint(1),          // User ID
 				"Groceries",      // Name
+				nil,              // Parent ID
 				now,              // Created at
 				sqlmock.AnyArg(), // Updated at
 				nil,              // Deleted at
@@ -120,6 +123,49 @@ func TestCreateCategory(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("Overwrite_With_New_Parent_Recalculates_Budgets", func(t *testing.T) {
+		now := time.Now()
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+		categoryColumns := []string{"id", "user_id", "name", "parent_id", "created_at", "updated_at", "deleted_at"}
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE (user_id = ? AND name = ?)")).
+			WithArgs(uint(1), "Restaurants", 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(3, 1, "Restaurants", nil, now, now, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE (id = ? AND user_id = ?)")).
+			WithArgs(uint(1), uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Food", nil, now, now, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`parent_id` FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(1, nil).AddRow(3, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("WITH RECURSIVE ancestors")).
+			WithArgs(uint(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `categories` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("WITH RECURSIVE ancestors")).
+			WithArgs(uint(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(1))
+
+		// The Food budget now covers restaurant spending
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND category_id IN (?,?,?))")).
+			WithArgs(uint(1), uint(3), uint(3), uint(1)).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(7, 1, 1, 400.00, 300.00, start, start.AddDate(0, 1, -1), "", false, 0.00, nil, 0, false))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(")).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(220.00))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		req, _ := http.NewRequest("POST", "/api/v1/categories", bytes.NewBufferString(`{"name": "Restaurants", "parentId": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("Invalid Request Data", func(t *testing.T) {
 		mock.ExpectationsWereMet()
 
@@ -157,10 +203,11 @@ func TestCreateCategory(t *testing.T) {
 
 		// Expect database error on save - match the exact column order GORM uses
 		mock.ExpectBegin()
-		mock.ExpectExec("INSERT INTO `categories` \\(`user_id`,`name`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)").
+		mock.ExpectExec("INSERT INTO `categories` \\(`user_id`,`name`,`parent_id`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").
 			WithArgs(
 				uint(1),          // User ID
 				"Groceries",      // Name
+				nil,              // Parent ID
 				sqlmock.AnyArg(), // Created at
 				sqlmock.AnyArg(), // Updated at
 				nil,              // Deleted at
@@ -296,19 +343,32 @@ func TestDeleteCategory(t *testing.T) {
 		handlers.DeleteCategory(c)
 	})
 
-	t.Run("Successfully Delete Category", func(t *testing.T) {
-		categoryID := "1"
-		userID := uint(1)
+	now := time.Now()
+	categoryColumns := []string{"id", "user_id", "name", "parent_id", "created_at", "updated_at", "deleted_at"}
+	findCategory := regexp.QuoteMeta("SELECT * FROM `categories` WHERE (id = ? AND user_id = ?) AND `categories`.`deleted_at` IS NULL ORDER BY `categories`.`id` LIMIT ?")
+	countReferences := "SELECT\\s+" + regexp.QuoteMeta("(SELECT COUNT(*) FROM transactions WHERE category_id = ? AND deleted_at IS NULL) AS transactions")
+	referenceColumns := []string{"transactions", "budgets", "recurring", "subcategories"}
 
-		// Expect transaction for the soft delete operation
+	t.Run("Successfully Delete Category", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).
+			WithArgs("1", uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Groceries", nil, now, now, nil))
+		mock.ExpectQuery(countReferences).
+			WithArgs(uint(1), uint(1), uint(1), uint(1)).
+			WillReturnRows(sqlmock.NewRows(referenceColumns).AddRow(0, 0, 0, 0))
+
+		// Expect transaction for the soft delete operation, which also drops the category's rules
 		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE `categories` SET `deleted_at`=\\? WHERE \\(id = \\? AND user_id = \\?\\) AND `categories`\\.`deleted_at` IS NULL").
-			WithArgs(sqlmock.AnyArg(), categoryID, userID).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `category_rules` SET `deleted_at`=? WHERE (user_id = ? AND category_id = ?)")).
+			WithArgs(sqlmock.AnyArg(), uint(1), uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE `categories` SET `deleted_at`=\\? WHERE `categories`\\.`id` = \\? AND `categories`\\.`deleted_at` IS NULL").
+			WithArgs(sqlmock.AnyArg(), 1).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectCommit()
 
 		// Act: Send request
-		req, _ := http.NewRequest("DELETE", "/api/v1/categories/"+categoryID, nil)
+		req, _ := http.NewRequest("DELETE", "/api/v1/categories/1", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
@@ -327,19 +387,109 @@ func TestDeleteCategory(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
-	t.Run("Category Not Found", func(t *testing.T) {
-		nonExistentCategoryID := "999"
-		userID := uint(1)
+	t.Run("Refuses_While_In_Use", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Groceries", nil, now, now, nil))
+		mock.ExpectQuery(countReferences).
+			WillReturnRows(sqlmock.NewRows(referenceColumns).AddRow(12, 1, 0, 0))
 
-		// Expect transaction that will return error
+		req, _ := http.NewRequest("DELETE", "/api/v1/categories/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var response struct {
+			References map[string]int `json:"references"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 12, response.References["transactions"])
+		assert.Equal(t, 1, response.References["budgets"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reassigns_Before_Deleting", func(t *testing.T) {
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+		end := time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local)
+
+		// Groceries (1) is folded into Food (2)
+		mock.ExpectQuery(findCategory).
+			WithArgs("1", uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Groceries", nil, now, now, nil))
+		mock.ExpectQuery(findCategory).
+			WithArgs("2", uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(2, 1, "Food", nil, now, now, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`parent_id` FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(1, nil).AddRow(2, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("WITH RECURSIVE ancestors")).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta("WITH RECURSIVE ancestors")).
+			WithArgs(uint(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `transactions` SET `category_id`=?,`updated_at`=? WHERE user_id = ? AND category_id = ?")).
+			WithArgs(uint(2), sqlmock.AnyArg(), uint(1), uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 12))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `recurring_transactions` SET `category_id`=?,`updated_at`=? WHERE user_id = ? AND category_id = ?")).
+			WithArgs(uint(2), sqlmock.AnyArg(), uint(1), uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `category_rules` SET `category_id`=?,`updated_at`=? WHERE user_id = ? AND category_id = ?")).
+			WithArgs(uint(2), sqlmock.AnyArg(), uint(1), uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		// Both categories have a March budget: Groceries' limit is added to Food's
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND category_id = ?) AND `budgets`.`deleted_at` IS NULL")).
+			WithArgs(uint(1), uint(1)).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(5, 1, 1, 200.00, 150.00, start, end, "", false, 0.00, nil, 0, false))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND category_id = ? AND start_date = ? AND end_date = ?)")).
+			WithArgs(uint(1), uint(2), start, end, 1).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(6, 1, 2, 300.00, 100.00, start, end, "", false, 0.00, nil, 0, false))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET `limit_amount`=?,`rollover_amount`=?,`updated_at`=? WHERE `budgets`.`deleted_at` IS NULL AND `id` = ?")).
+			WithArgs(500.00, 0.00, sqlmock.AnyArg(), 6).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET `deleted_at`=? WHERE `budgets`.`id` = ?")).
+			WithArgs(sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET `category_id`=?,`updated_at`=? WHERE user_id = ? AND category_id = ? AND deleted_at IS NOT NULL")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `categories` SET `parent_id`=?,`updated_at`=? WHERE user_id = ? AND parent_id = ?")).
+			WithArgs(uint(2), sqlmock.AnyArg(), uint(1), uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `categories` SET `deleted_at`=? WHERE `categories`.`id` = ?")).
+			WithArgs(sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Food's combined budget is recalculated
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND category_id IN (?,?))")).
+			WithArgs(uint(1), uint(1), uint(2)).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(6, 1, 2, 500.00, 100.00, start, end, "", false, 0.00, nil, 0, false))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(")).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(250.00))
 		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE `categories` SET `deleted_at`=\\? WHERE \\(id = \\? AND user_id = \\?\\) AND `categories`\\.`deleted_at` IS NULL").
-			WithArgs(sqlmock.AnyArg(), nonExistentCategoryID, userID).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/categories/1?reassignTo=2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Category Not Found", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).
+			WithArgs("999", uint(1), 1).
 			WillReturnError(gorm.ErrRecordNotFound)
-		mock.ExpectRollback()
 
 		// Act: Send request
-		req, _ := http.NewRequest("DELETE", "/api/v1/categories/"+nonExistentCategoryID, nil)
+		req, _ := http.NewRequest("DELETE", "/api/v1/categories/999", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
@@ -359,18 +509,12 @@ func TestDeleteCategory(t *testing.T) {
 	})
 
 	t.Run("Invalid Category ID Format", func(t *testing.T) {
-		invalidCategoryID := "invalid"
-		userID := uint(1)
-
-		// Expect transaction that will return error
-		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE `categories` SET `deleted_at`=\\? WHERE \\(id = \\? AND user_id = \\?\\) AND `categories`\\.`deleted_at` IS NULL").
-			WithArgs(sqlmock.AnyArg(), invalidCategoryID, userID).
+		mock.ExpectQuery(findCategory).
+			WithArgs("invalid", uint(1), 1).
 			WillReturnError(errors.New("invalid category ID format"))
-		mock.ExpectRollback()
 
 		// Act: Send request
-		req, _ := http.NewRequest("DELETE", "/api/v1/categories/"+invalidCategoryID, nil)
+		req, _ := http.NewRequest("DELETE", "/api/v1/categories/invalid", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
@@ -389,3 +533,287 @@ func TestDeleteCategory(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestUpdateCategory(t *testing.T) {
+	router, _ := setup()
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	router.PUT("/api/v1/categories/:id", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.UpdateCategory(c)
+	})
+
+	now := time.Now()
+	categoryColumns := []string{"id", "user_id", "name", "parent_id", "created_at", "updated_at", "deleted_at"}
+	findCategory := regexp.QuoteMeta("SELECT * FROM `categories` WHERE (id = ? AND user_id = ?)")
+	countNames := regexp.QuoteMeta("SELECT count(*) FROM `categories` WHERE (user_id = ? AND name = ? AND id <> ?)")
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/api/v1/categories/"+id, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Rename", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).
+			WithArgs("3", uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(3, 1, "Resturants", 1, now, now, nil))
+		mock.ExpectQuery(countNames).
+			WithArgs(uint(1), "Restaurants", uint(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `categories` SET `name`=?,`parent_id`=?,`updated_at`=? WHERE `categories`.`deleted_at` IS NULL AND `id` = ?")).
+			WithArgs("Restaurants", uint(1), sqlmock.AnyArg(), uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		w := put("3", `{"name": " Restaurants "}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Name_Taken", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(3, 1, "Restaurants", 1, now, now, nil))
+		mock.ExpectQuery(countNames).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		w := put("3", `{"name": "Groceries"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Move_To_Top_Level_Recalculates_Old_Parent", func(t *testing.T) {
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+
+		mock.ExpectQuery(findCategory).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(3, 1, "Restaurants", 1, now, now, nil))
+		mock.ExpectQuery(countNames).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta("WITH RECURSIVE ancestors")).
+			WithArgs(uint(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(1))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `categories` SET `name`=?,`parent_id`=?,`updated_at`=?")).
+			WithArgs("Restaurants", nil, sqlmock.AnyArg(), uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("WITH RECURSIVE ancestors")).
+			WithArgs(uint(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		// The Food budget no longer covers restaurant spending
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND category_id IN (?,?,?))")).
+			WithArgs(uint(1), uint(3), uint(1), uint(3)).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(7, 1, 1, 400.00, 100.00, start, start.AddDate(0, 1, -1), "", false, 0.00, nil, 0, false))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(")).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(120.00))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		w := put("3", `{"name": "Restaurants", "parentId": 0}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not_Found", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).WillReturnError(gorm.ErrRecordNotFound)
+
+		w := put("99", `{"name": "Anything"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMergeCategory(t *testing.T) {
+	router, _ := setup()
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	router.POST("/api/v1/categories/:id/merge", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.MergeCategory(c)
+	})
+
+	now := time.Now()
+	categoryColumns := []string{"id", "user_id", "name", "parent_id", "created_at", "updated_at", "deleted_at"}
+	findCategory := regexp.QuoteMeta("SELECT * FROM `categories` WHERE (id = ? AND user_id = ?)")
+
+	merge := func(id, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/categories/"+id+"/merge", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Rejects_Merge_Into_Subcategory", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).
+			WithArgs("1", uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Food", nil, now, now, nil))
+		mock.ExpectQuery(findCategory).
+			WithArgs(uint(2), uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(2, 1, "Groceries", 1, now, now, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`parent_id` FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(1, nil).AddRow(2, 1))
+
+		w := merge("1", `{"targetId": 2}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejects_Merge_Into_Itself", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Food", nil, now, now, nil))
+		mock.ExpectQuery(findCategory).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Food", nil, now, now, nil))
+
+		w := merge("1", `{"targetId": 1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls_Back_On_Error", func(t *testing.T) {
+		mock.ExpectQuery(findCategory).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Groceries", nil, now, now, nil))
+		mock.ExpectQuery(findCategory).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(2, 1, "Food", nil, now, now, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`parent_id` FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(1, nil).AddRow(2, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("WITH RECURSIVE ancestors")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta("WITH RECURSIVE ancestors")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `transactions` SET `category_id`=?")).
+			WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		w := merge("1", `{"targetId": 2}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCategoryHierarchy(t *testing.T) {
+	router, _ := setup()
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	router.POST("/api/v1/categories", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.CreateCategory(c)
+	})
+	router.GET("/api/v1/categories", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.GetCategories(c)
+	})
+
+	now := time.Now()
+	categoryColumns := []string{"id", "user_id", "name", "parent_id", "created_at", "updated_at", "deleted_at"}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/categories", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Create_Subcategory", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE \\(user_id = \\? AND name = \\?\\)").
+			WithArgs(uint(1), "Groceries", 1).
+			WillReturnError(gorm.ErrRecordNotFound)
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE \\(id = \\? AND user_id = \\?\\)").
+			WithArgs(uint(1), uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Food", nil, now, now, nil))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `categories`").
+			WithArgs(uint(1), "Groceries", uint(1), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		w := post(`{"name": "Groceries", "parentId": 1}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown_Parent", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE \\(user_id = \\? AND name = \\?\\)").
+			WillReturnError(gorm.ErrRecordNotFound)
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE \\(id = \\? AND user_id = \\?\\)").
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		w := post(`{"name": "Groceries", "parentId": 42}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejects_Cycle", func(t *testing.T) {
+		// Food (1) > Groceries (2); moving Food under Groceries would loop
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE \\(user_id = \\? AND name = \\?\\)").
+			WithArgs(uint(1), "Food", 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Food", nil, now, now, nil))
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE \\(id = \\? AND user_id = \\?\\)").
+			WithArgs(uint(2), uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(2, 1, "Groceries", 1, now, now, nil))
+		mock.ExpectQuery("SELECT `id`,`parent_id` FROM `categories` WHERE user_id = \\?").
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(1, nil).AddRow(2, 1))
+
+		w := post(`{"name": "Food", "parentId": 2}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Contains(t, response["error"], "subcategories")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejects_Self_Parent", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE \\(user_id = \\? AND name = \\?\\)").
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Food", nil, now, now, nil))
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE \\(id = \\? AND user_id = \\?\\)").
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, 1, "Food", nil, now, now, nil))
+
+		w := post(`{"name": "Food", "parentId": 1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Get_Tree", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE user_id = \\?").
+			WillReturnRows(sqlmock.NewRows(categoryColumns).
+				AddRow(1, 1, "Food", nil, now, now, nil).
+				AddRow(2, 1, "Groceries", 1, now, now, nil).
+				AddRow(3, 1, "Restaurants", 1, now, now, nil).
+				AddRow(4, 1, "Rent", nil, now, now, nil).
+				AddRow(5, 1, "Takeaway", 3, now, now, nil))
+
+		req, _ := http.NewRequest("GET", "/api/v1/categories?tree=true", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Categories []handlers.CategoryNode `json:"categories"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Categories, 2)
+
+		food := response.Categories[0]
+		assert.Equal(t, "Food", food.Name)
+		require.Len(t, food.Children, 2)
+		assert.Equal(t, "Restaurants", food.Children[1].Name)
+		require.Len(t, food.Children[1].Children, 1)
+		assert.Equal(t, "Takeaway", food.Children[1].Children[0].Name)
+		assert.Empty(t, response.Categories[1].Children)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,223 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+)
+
+var exportColumns = []string{"id", "transaction_date", "type", "amount", "category_id", "name", "description", "external_id"}
+
+// exportRows returns three transactions: a categorized expense, an uncategorized income and an imported refund
+func exportRows() *sqlmock.Rows {
+	return sqlmock.NewRows(exportColumns).
+		AddRow(1, time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), "expense", 82.40, 4, "Groceries", "Whole Foods, weekly", nil).
+		AddRow(2, time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local), "income", 2500.00, nil, nil, "Salary", nil).
+		AddRow(3, time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local), "refund", 25.00, 4, "Groceries", "Returned item", "2024030500009")
+}
+
+func TestExportTransactions(t *testing.T) {
+	router, _ := setup()
+	router.GET("/api/v1/transactions/export", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.ExportTransactions(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	selectRows := regexp.QuoteMeta("SELECT transactions.id, transactions.transaction_date, transactions.type, transactions.amount, " +
+		"transactions.category_id, categories.name, transactions.description, transactions.external_id FROM `transactions` " +
+		"LEFT JOIN categories ON categories.id = transactions.category_id AND categories.deleted_at IS NULL")
+
+	t.Run("CSV_With_Filters", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(selectRows+".*"+regexp.QuoteMeta("WHERE transactions.user_id = ? AND transactions.category_id IN (?) AND transactions.transaction_date >= ? AND transactions.transaction_date <= ?")).
+			WithArgs(uint(1), uint(4), "2024-03-01", "2024-03-31").
+			WillReturnRows(exportRows())
+
+		req, _ := http.NewRequest("GET", "/api/v1/transactions/export?format=csv&categoryId=4&startDate=2024-03-01&endDate=2024-03-31", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename=\"transactions-")
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"Date", "Type", "Amount", "Category", "Description"},
+			{"2024-03-01", "expense", "82.40", "Groceries", "Whole Foods, weekly"},
+			{"2024-03-02", "income", "2500.00", "", "Salary"},
+			{"2024-03-05", "refund", "25.00", "Groceries", "Returned item"},
+		}, records)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CSV_Neutralizes_Formulas", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(selectRows).WillReturnRows(sqlmock.NewRows(exportColumns).
+			AddRow(1, time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), "expense", 10.00, 4, "=Groceries", `=HYPERLINK("http://evil.example","Refund")`, nil).
+			AddRow(2, time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local), "expense", 20.00, nil, nil, "+cmd|' /C calc'!A0", nil).
+			AddRow(3, time.Date(2024, 3, 3, 0, 0, 0, 0, time.Local), "expense", 30.00, nil, nil, "-2+3", nil).
+			AddRow(4, time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local), "expense", 40.00, nil, nil, "@SUM(A1)", nil).
+			AddRow(5, time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local), "expense", 50.00, nil, nil, "\t=1+1", nil).
+			AddRow(6, time.Date(2024, 3, 6, 0, 0, 0, 0, time.Local), "expense", 60.00, nil, nil, "Coffee = good", nil))
+
+		req, _ := http.NewRequest("GET", "/api/v1/transactions/export?format=csv", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 7)
+		assert.Equal(t, []string{"'=Groceries", `'=HYPERLINK("http://evil.example","Refund")`}, records[1][3:])
+		assert.Equal(t, "'+cmd|' /C calc'!A0", records[2][4])
+		assert.Equal(t, "'-2+3", records[3][4])
+		assert.Equal(t, "'@SUM(A1)", records[4][4])
+		assert.Equal(t, "'\t=1+1", records[5][4])
+		assert.Equal(t, "Coffee = good", records[6][4])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(selectRows).WillReturnRows(exportRows())
+
+		req, _ := http.NewRequest("GET", "/api/v1/transactions/export?format=json", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var exported []handlers.ExportedTransaction
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+		require.Len(t, exported, 3)
+		assert.Equal(t, "Groceries", exported[0].Category)
+		require.NotNil(t, exported[0].CategoryID)
+		assert.Equal(t, uint(4), *exported[0].CategoryID)
+		assert.Nil(t, exported[1].CategoryID)
+		assert.Equal(t, "2024030500009", exported[2].ExternalID)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("JSON_Empty", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(selectRows).WillReturnRows(sqlmock.NewRows(exportColumns))
+
+		req, _ := http.NewRequest("GET", "/api/v1/transactions/export?format=json", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "[]", w.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OFX_Can_Be_Reimported", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT MIN(transactions.transaction_date) AS first_date, MAX(transactions.transaction_date) AS last_date FROM `transactions`")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_date", "last_date"}).
+				AddRow(time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)))
+		mock.ExpectQuery(selectRows).WillReturnRows(exportRows())
+
+		req, _ := http.NewRequest("GET", "/api/v1/transactions/export?format=ofx", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "<DTSTART>20240301")
+
+		rows, err := handlers.TestableParseOFXStatement(bytes.NewReader(w.Body.Bytes()))
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		assert.Equal(t, "expense", rows[0].Type)
+		assert.Equal(t, 82.40, rows[0].Amount)
+		assert.Equal(t, "FT1", rows[0].ExternalID)
+		assert.Equal(t, "income", rows[1].Type)
+		assert.Equal(t, "2024030500009", rows[2].ExternalID)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(selectRows).WillReturnRows(exportRows())
+
+		req, _ := http.NewRequest("GET", "/api/v1/transactions/export?format=xlsx", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		f, err := excelize.OpenReader(w.Body)
+		require.NoError(t, err)
+		defer f.Close()
+
+		rows, err := f.GetRows("Transactions", excelize.Options{RawCellValue: true})
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		assert.Equal(t, []string{"Date", "Type", "Amount", "Category", "Description"}, rows[0])
+		assert.Equal(t, "expense", rows[1][1])
+		assert.Equal(t, "82.4", rows[1][2])
+		assert.Equal(t, "Groceries", rows[1][3])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid_Format", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("GET", "/api/v1/transactions/export?format=pdf", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Query_Error", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(selectRows).WillReturnError(assert.AnError)
+
+		req, _ := http.NewRequest("GET", "/api/v1/transactions/export?format=csv", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,166 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+var allForecastModels = []string{
+	models.ForecastModelMean,
+	models.ForecastModelLinearTrend,
+	models.ForecastModelExponentialSmoothing,
+	models.ForecastModelHoltWinters,
+}
+
+// backtestFixture is a synthetic spending series together with the model expected to predict it best.
+type backtestFixture struct {
+	Name      string    `json:"name"`
+	BestModel string    `json:"bestModel"`
+	History   []float64 `json:"history"`
+}
+
+func loadBacktestFixtures(t *testing.T) []backtestFixture {
+	data, err := os.ReadFile("testdata/backtest_series.json")
+	require.NoError(t, err)
+	var fixtures []backtestFixture
+	require.NoError(t, json.Unmarshal(data, &fixtures))
+	require.NotEmpty(t, fixtures)
+	return fixtures
+}
+
+func TestBacktestSeries(t *testing.T) {
+	t.Run("Fixtures pick the generating model", func(t *testing.T) {
+		for _, fixture := range loadBacktestFixtures(t) {
+			t.Run(fixture.Name, func(t *testing.T) {
+				metrics, best := handlers.TestableBacktestSeries(fixture.History, 3, 3, allForecastModels)
+				assert.Equal(t, []string{fixture.BestModel, fixture.BestModel, fixture.BestModel}, best)
+
+				for _, m := range metrics {
+					assert.Greater(t, m.Forecasts, 0)
+					require.NotNil(t, m.MAPE)
+					if m.Model == fixture.BestModel {
+						// Every fixture has only a few percent of noise around its pattern
+						assert.Less(t, *m.MAPE, 5.0, "%s horizon %d", m.Model, m.Horizon)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("Exact errors", func(t *testing.T) {
+		// Origins at 2 and 3 months: the mean is 15 then 20 against actuals 30 and 40
+		metrics, best := handlers.TestableBacktestSeries([]float64{10, 20, 30, 40}, 2, 2, []string{models.ForecastModelMean})
+
+		assert.Equal(t, []models.BacktestMetrics{
+			{Model: models.ForecastModelMean, Horizon: 1, Forecasts: 2, MAE: 17.5, MAPE: floatPtr(50), Bias: -17.5},
+			{Model: models.ForecastModelMean, Horizon: 2, Forecasts: 1, MAE: 25, MAPE: floatPtr(62.5), Bias: -25},
+		}, metrics)
+		assert.Equal(t, []string{models.ForecastModelMean, models.ForecastModelMean}, best)
+	})
+
+	t.Run("Models are only scored with enough history", func(t *testing.T) {
+		metrics, best := handlers.TestableBacktestSeries([]float64{10, 20, 30, 40, 50}, 1, 1, allForecastModels)
+
+		scored := map[string]int{}
+		for _, m := range metrics {
+			scored[m.Model] = m.Forecasts
+		}
+		assert.Equal(t, map[string]int{models.ForecastModelMean: 4, models.ForecastModelLinearTrend: 2}, scored)
+		assert.Equal(t, []string{models.ForecastModelLinearTrend}, best)
+	})
+
+	t.Run("Zero months have no percentage error", func(t *testing.T) {
+		metrics, _ := handlers.TestableBacktestSeries([]float64{10, 0, 0}, 1, 1, []string{models.ForecastModelMean})
+
+		require.Len(t, metrics, 1)
+		assert.Nil(t, metrics[0].MAPE)
+		assert.Equal(t, 7.5, metrics[0].MAE)
+	})
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestBacktestForecastHandler(t *testing.T) {
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	end := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	txColumns := []string{"id", "user_id", "category_id", "type", "amount", "transaction_date"}
+
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	rows := sqlmock.NewRows(txColumns)
+	for i, amount := range []float64{100, 120, 140, 160, 180, 200} {
+		rows.AddRow(i+1, 1, 4, "expense", amount, time.Date(2024, time.Month(i+1), 15, 0, 0, 0, 0, time.Local))
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND type IN (?,?)) AND (transaction_date >= ? AND transaction_date < ?)")).
+		WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, end.AddDate(-3, 0, 0), end).
+		WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE user_id = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(4, 1, "Groceries"))
+
+	w, c := setupSimpleForecastTest()
+	reqJSON, _ := json.Marshal(models.BacktestRequest{
+		Horizon: 2,
+		EndDate: &end,
+		Models:  []string{models.ForecastModelMean, models.ForecastModelLinearTrend},
+	})
+	c.Request, _ = http.NewRequest("POST", "/api/v1/forecast/backtest", bytes.NewBuffer(reqJSON))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handlers.BacktestForecastHandler(c)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	var response models.BacktestResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	// Spending rises by 20 a month, which the trend line predicts exactly from three months on
+	// while the mean of the months so far trails 40, 50 and 60 behind
+	assert.Equal(t, 6, response.Total.Months)
+	assert.Nil(t, response.Total.CategoryID)
+	assert.Equal(t, []string{models.ForecastModelLinearTrend, models.ForecastModelLinearTrend}, response.Total.BestModels)
+	require.Len(t, response.Total.Metrics, 4)
+	assert.Equal(t, models.BacktestMetrics{Model: models.ForecastModelLinearTrend, Horizon: 1, Forecasts: 3, MAE: 0, MAPE: floatPtr(0), Bias: 0}, response.Total.Metrics[2])
+	assert.Equal(t, models.BacktestMetrics{Model: models.ForecastModelMean, Horizon: 1, Forecasts: 3, MAE: 50, MAPE: floatPtr(27.59), Bias: -50}, response.Total.Metrics[0])
+
+	require.Len(t, response.Categories, 1)
+	assert.Equal(t, uint(4), *response.Categories[0].CategoryID)
+	assert.Equal(t, "Groceries", response.Categories[0].CategoryName)
+	assert.Equal(t, response.Total.Metrics, response.Categories[0].Metrics)
+}
+
+func TestBacktestForecastHandler_InvalidRequest(t *testing.T) {
+	for name, body := range map[string]string{
+		"Horizon too long":  `{"horizon": 13}`,
+		"Negative training": `{"minTrainMonths": -1}`,
+		"Unknown model":     `{"models": ["crystal_ball"]}`,
+		"Malformed JSON":    `{"horizon": `,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w, c := setupSimpleForecastTest()
+			c.Request, _ = http.NewRequest("POST", "/api/v1/forecast/backtest", bytes.NewBufferString(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handlers.BacktestForecastHandler(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestProjectCashFlow(t *testing.T) {
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(2024, m, d, 0, 0, 0, 0, time.Local)
+	}
+	rentID := uint(2)
+
+	// 300 of day-to-day spending in each of March to May, 10 a day in June. May's rent doesn't count.
+	history := []models.Transaction{
+		{Type: models.TransactionTypeExpense, Amount: 300, TransactionDate: date(3, 20)},
+		{Type: models.TransactionTypeExpense, Amount: 300, TransactionDate: date(4, 20)},
+		{Type: models.TransactionTypeExpense, Amount: 300, TransactionDate: date(5, 20)},
+		{Type: models.TransactionTypeExpense, Amount: 1200, TransactionDate: date(5, 12), RecurringTransactionID: &rentID},
+	}
+	// Five months of salary and rent are booked already
+	recurring := []models.RecurringTransaction{
+		{ID: 1, Type: models.TransactionTypeIncome, Amount: 2000, Description: "Salary", Frequency: models.FrequencyMonthly,
+			Interval: 1, StartDate: date(1, 15), OccurrencesGenerated: 5, Active: true},
+		{ID: rentID, Type: models.TransactionTypeExpense, Amount: 1200, Description: "Rent", Frequency: models.FrequencyMonthly,
+			Interval: 1, StartDate: date(1, 12), OccurrencesGenerated: 5, Active: true},
+		{ID: 3, Type: models.TransactionTypeTransfer, Amount: 500, Description: "Savings", Frequency: models.FrequencyMonthly,
+			Interval: 1, StartDate: date(1, 11), OccurrencesGenerated: 5, Active: true},
+	}
+
+	t.Run("Running balance", func(t *testing.T) {
+		p := handlers.TestableProjectCashFlow(date(6, 10), date(6, 10), 10, 1000, 200, history, recurring)
+
+		assert.Equal(t, models.ForecastModelLinearTrend, p.DiscretionaryModel)
+		require.Len(t, p.Calendar, 10)
+		assert.Equal(t, models.CashFlowDay{Date: "2024-06-10", Discretionary: 10, Balance: 990, Items: []models.CashFlowItem{}}, p.Calendar[0])
+
+		// Rent on the 12th takes the balance under the threshold until the salary arrives on the 15th
+		assert.Equal(t, 1200.0, p.Calendar[2].Bills)
+		assert.Equal(t, []models.CashFlowItem{{RecurringTransactionID: 2, Type: "expense", Amount: 1200, Description: "Rent"}}, p.Calendar[2].Items)
+		assert.Equal(t, -230.0, p.Calendar[2].Balance)
+		assert.True(t, p.Calendar[2].BelowThreshold)
+		assert.Equal(t, 2000.0, p.Calendar[5].Income)
+		assert.Equal(t, 1740.0, p.Calendar[5].Balance)
+		assert.False(t, p.Calendar[5].BelowThreshold)
+
+		// The transfer moves money between the user's own accounts and is left out
+		for _, day := range p.Calendar {
+			for _, item := range day.Items {
+				assert.NotEqual(t, models.TransactionTypeTransfer, item.Type)
+			}
+		}
+
+		assert.Equal(t, 3, p.DaysBelowThreshold)
+		require.NotNil(t, p.FirstBelowDate)
+		assert.Equal(t, "2024-06-12", *p.FirstBelowDate)
+		assert.Equal(t, -250.0, p.LowestBalance)
+		assert.Equal(t, "2024-06-14", p.LowestBalanceDate)
+		assert.Equal(t, 1700.0, p.ClosingBalance)
+	})
+
+	t.Run("Spans months", func(t *testing.T) {
+		// July has 31 days and the same 300 forecast, so its days cost less
+		p := handlers.TestableProjectCashFlow(date(6, 30), date(6, 30), 2, 0, 0, history[:3], nil)
+
+		assert.Equal(t, 10.0, p.Calendar[0].Discretionary)
+		assert.Equal(t, 9.68, p.Calendar[1].Discretionary)
+		assert.Equal(t, 2, p.DaysBelowThreshold)
+	})
+
+	t.Run("Overdue item lands on the first day", func(t *testing.T) {
+		late := recurring[1]
+		late.OccurrencesGenerated = 4
+
+		p := handlers.TestableProjectCashFlow(date(6, 10), date(6, 10), 3, 3000, 0, nil, []models.RecurringTransaction{late})
+
+		assert.Empty(t, p.DiscretionaryModel)
+		assert.Equal(t, 1200.0, p.Calendar[0].Bills)
+		assert.Equal(t, 1200.0, p.Calendar[2].Bills)
+		assert.Equal(t, 600.0, p.ClosingBalance)
+		assert.Nil(t, p.FirstBelowDate)
+	})
+
+	t.Run("Items due before a future start are in the opening balance", func(t *testing.T) {
+		// June's salary and rent fall between today and the start date, July's within the projection
+		p := handlers.TestableProjectCashFlow(date(6, 1), date(6, 20), 30, 1000, 0, nil, recurring)
+
+		assert.Equal(t, 1800.0, p.OpeningBalance)
+		assert.Zero(t, p.Calendar[0].Bills)
+		assert.Zero(t, p.Calendar[0].Income)
+		assert.Empty(t, p.Calendar[0].Items)
+		assert.Equal(t, 1200.0, p.Calendar[22].Bills)
+		assert.Equal(t, 1800.0+2000-1200, p.ClosingBalance)
+	})
+}
+
+func TestCashFlowForecastHandler(t *testing.T) {
+	router, _ := setup()
+	router.GET("/api/v1/forecast/cashflow", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.CashFlowForecastHandler(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	start := time.Date(2024, 6, 10, 0, 0, 0, 0, time.Local)
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	recurringColumns := []string{"id", "user_id", "type", "amount", "description", "frequency", "repeat_interval",
+		"start_date", "occurrences_generated", "next_occurrence", "active"}
+
+	expectHistory := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND type IN (?,?)) AND (transaction_date >= ? AND transaction_date < ?)")).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, june.AddDate(-3, 0, 0), june).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "amount", "transaction_date"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recurring_transactions` WHERE (user_id = ? AND active = ?)")).
+			WithArgs(uint(1), true).
+			WillReturnRows(sqlmock.NewRows(recurringColumns).
+				AddRow(1, 1, "expense", 900.00, "Rent", "monthly", 1, start.AddDate(0, -1, 2), 1, start.AddDate(0, 0, 2), true))
+	}
+
+	t.Run("Derived_Opening_Balance", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN type IN ('income', 'refund') THEN amount WHEN type = 'expense' THEN -amount ELSE 0 END), 0) as total FROM `transactions` WHERE (user_id = ? AND transaction_date < ?)")).
+			WithArgs(uint(1), start).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1000.00))
+		expectHistory(mock)
+
+		req, _ := http.NewRequest("GET", "/api/v1/forecast/cashflow?startDate=2024-06-10&days=5&threshold=500", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.CashFlowProjection
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "transactions", response.BalanceSource)
+		assert.Equal(t, 1000.0, response.OpeningBalance)
+		require.Len(t, response.Calendar, 5)
+		assert.Equal(t, 100.0, response.ClosingBalance)
+		assert.Equal(t, "2024-06-12", *response.FirstBelowDate)
+		assert.Equal(t, 3, response.DaysBelowThreshold)
+	})
+
+	t.Run("Supplied_Opening_Balance", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		expectHistory(mock)
+
+		req, _ := http.NewRequest("GET", "/api/v1/forecast/cashflow?startDate=2024-06-10&openingBalance=2500.50", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.CashFlowProjection
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "supplied", response.BalanceSource)
+		assert.Equal(t, 30, response.Days)
+		assert.Equal(t, 1600.5, response.ClosingBalance)
+		assert.Nil(t, response.FirstBelowDate)
+	})
+
+	t.Run("Invalid_Parameters", func(t *testing.T) {
+		for _, query := range []string{"days=0", "days=366", "days=ten", "startDate=tomorrow", "threshold=low", "openingBalance=lots"} {
+			req, _ := http.NewRequest("GET", "/api/v1/forecast/cashflow?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
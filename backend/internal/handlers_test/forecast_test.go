@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/RedShawn258/FinTrack/backend/internal/db"
@@ -116,3 +121,178 @@ func TestForecastExpensesHandler_InvalidRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestForecastModels(t *testing.T) {
+	round := func(values []float64) []float64 {
+		out := make([]float64, len(values))
+		for i, v := range values {
+			out[i] = math.Round(v*100) / 100
+		}
+		return out
+	}
+
+	t.Run("Mean_For_Short_History", func(t *testing.T) {
+		forecast, model := handlers.TestableRunForecast("", []float64{100, 200}, 2)
+		assert.Equal(t, models.ForecastModelMean, model)
+		assert.Equal(t, []float64{150, 150}, forecast)
+	})
+
+	t.Run("No_History", func(t *testing.T) {
+		forecast, model := handlers.TestableRunForecast("", nil, 3)
+		assert.Equal(t, models.ForecastModelMean, model)
+		assert.Equal(t, []float64{0, 0, 0}, forecast)
+	})
+
+	t.Run("Linear_Trend", func(t *testing.T) {
+		forecast, model := handlers.TestableRunForecast("", []float64{100, 110, 120, 130}, 3)
+		assert.Equal(t, models.ForecastModelLinearTrend, model)
+		assert.Equal(t, []float64{140, 150, 160}, round(forecast))
+	})
+
+	t.Run("Falling_Trend_Floors_At_Zero", func(t *testing.T) {
+		forecast, _ := handlers.TestableRunForecast("", []float64{300, 200, 100}, 2)
+		assert.Equal(t, []float64{0, 0}, round(forecast))
+	})
+
+	t.Run("Exponential_Smoothing", func(t *testing.T) {
+		forecast, model := handlers.TestableRunForecast("", []float64{100, 110, 120, 130, 140, 150, 160, 170}, 2)
+		assert.Equal(t, models.ForecastModelExponentialSmoothing, model)
+		assert.Equal(t, []float64{180, 190}, round(forecast))
+	})
+
+	t.Run("Holt_Winters_Repeats_Season", func(t *testing.T) {
+		season := []float64{300, 280, 310, 320, 350, 400, 420, 410, 330, 320, 380, 600}
+		history := append(append([]float64{}, season...), season...)
+
+		forecast, model := handlers.TestableRunForecast("", history, 12)
+		assert.Equal(t, models.ForecastModelHoltWinters, model)
+		assert.Equal(t, season, round(forecast))
+	})
+
+	t.Run("Requested_Model_Needs_Enough_History", func(t *testing.T) {
+		history := []float64{120, 80, 150, 90, 130, 110}
+
+		_, model := handlers.TestableRunForecast(models.ForecastModelLinearTrend, history, 1)
+		assert.Equal(t, models.ForecastModelLinearTrend, model)
+
+		_, model = handlers.TestableRunForecast(models.ForecastModelHoltWinters, history, 1)
+		assert.Equal(t, models.ForecastModelExponentialSmoothing, model)
+	})
+
+	t.Run("Standard_Errors", func(t *testing.T) {
+		// One month of history says nothing about the spread
+		assert.Nil(t, handlers.TestableForecastStdErrors("", []float64{250}, 3))
+
+		history := []float64{412.5, 388.1, 455.9, 401.2, 520.4, 478.3, 430.0, 498.7}
+		errs := handlers.TestableForecastStdErrors("", history, 6)
+		require.Len(t, errs, 6)
+		for h := 1; h < len(errs); h++ {
+			assert.Greater(t, errs[h], errs[h-1], "month %d", h)
+		}
+
+		// Holt's method is initialised from the first two months, which leave no error to count: only the
+		// jump of 50 in the last month does, over the four months it predicted less its two parameters
+		errs = handlers.TestableForecastStdErrors(models.ForecastModelExponentialSmoothing, []float64{100, 110, 120, 130, 140, 200}, 1)
+		require.Len(t, errs, 1)
+		assert.InDelta(t, math.Sqrt(2500.0/2), errs[0], 1e-9)
+
+		// A season that repeats exactly is fitted without error
+		season := []float64{300, 280, 310, 320, 350, 400, 420, 410, 330, 320, 380, 600}
+		errs = handlers.TestableForecastStdErrors("", append(append([]float64{}, season...), season...), 3)
+		assert.Equal(t, []float64{0, 0, 0}, errs)
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		history := []float64{412.5, 388.1, 455.9, 401.2, 520.4, 478.3, 430.0, 498.7}
+		first, _ := handlers.TestableRunForecast("", history, 6)
+		second, _ := handlers.TestableRunForecast("", history, 6)
+		assert.Equal(t, first, second)
+	})
+}
+
+func TestForecastExpensesHandler_History(t *testing.T) {
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	txColumns := []string{"id", "user_id", "category_id", "type", "amount", "transaction_date"}
+
+	run := func() models.ForecastResponse {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		// Four months of history; April's refund offsets part of its groceries
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND type IN (?,?)) AND (transaction_date >= ? AND transaction_date < ?)")).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, start.AddDate(-3, 0, 0), start).
+			WillReturnRows(sqlmock.NewRows(txColumns).
+				AddRow(1, 1, 4, "expense", 200.0, time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)).
+				AddRow(2, 1, 4, "expense", 220.0, time.Date(2024, 2, 10, 0, 0, 0, 0, time.Local)).
+				AddRow(3, 1, 4, "expense", 240.0, time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local)).
+				AddRow(4, 1, 4, "expense", 290.0, time.Date(2024, 4, 10, 0, 0, 0, 0, time.Local)).
+				AddRow(5, 1, 4, "refund", 30.0, time.Date(2024, 4, 20, 0, 0, 0, 0, time.Local)).
+				AddRow(6, 1, 7, "expense", 50.0, time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(4, 1, "Groceries").AddRow(7, 1, "Gifts"))
+
+		w, c := setupSimpleForecastTest()
+		reqJSON, _ := json.Marshal(models.ForecastRequest{MonthsAhead: 2, StartDate: &start})
+		c.Request, _ = http.NewRequest("POST", "/api/v1/forecast/expenses", bytes.NewBuffer(reqJSON))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handlers.ForecastExpensesHandler(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.ForecastResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := run()
+
+	// Totals 200, 220, 290, 260: the least squares line through them rises 25 a month from 205
+	assert.Equal(t, models.ForecastModelLinearTrend, response.Model)
+	require.Len(t, response.TotalForecast, 2)
+	assert.Equal(t, "2024-05", response.TotalForecast[0].Month)
+	assert.Equal(t, 305.0, response.TotalForecast[0].Amount)
+	assert.Equal(t, 330.0, response.TotalForecast[1].Amount)
+
+	// Residuals -5, -10, 35, -20 give the intervals; they widen as the forecast moves away from the data
+	assert.Equal(t, []float64{0.8, 0.95}, response.ConfidenceLevels)
+	assert.Equal(t, []models.PredictionInterval{
+		{Level: 0.8, Lower: 245.06, Upper: 364.94},
+		{Level: 0.95, Lower: 213.33, Upper: 396.67},
+	}, response.TotalForecast[0].Intervals)
+	assert.Equal(t, models.PredictionInterval{Level: 0.8, Lower: 257.08, Upper: 402.92}, response.TotalForecast[1].Intervals[0])
+
+	// Gifts only has March and April (one purchase, then nothing), so it falls back to the mean
+	require.Len(t, response.CategoryForecasts, 2)
+	assert.Equal(t, "Groceries", response.CategoryForecasts[0].CategoryName)
+	assert.Equal(t, models.ForecastModelLinearTrend, response.CategoryForecasts[0].Model)
+	assert.Equal(t, 280.0, response.CategoryForecasts[0].Forecast[0].Amount)
+	assert.Equal(t, "Gifts", response.CategoryForecasts[1].CategoryName)
+	assert.Equal(t, models.ForecastModelMean, response.CategoryForecasts[1].Model)
+	assert.Equal(t, 25.0, response.CategoryForecasts[1].Forecast[0].Amount)
+	require.Len(t, response.CategoryForecasts[1].Forecast[0].Intervals, 2)
+	assert.Equal(t, 0.0, response.CategoryForecasts[1].Forecast[0].Intervals[1].Lower)
+
+	// Groceries grew by exactly 20 a month, so there is no spread around its forecast
+	assert.Equal(t, []models.PredictionInterval{
+		{Level: 0.8, Lower: 280, Upper: 280},
+		{Level: 0.95, Lower: 280, Upper: 280},
+	}, response.CategoryForecasts[0].Forecast[0].Intervals)
+
+	// The same history always gives the same forecast
+	assert.Equal(t, response, run())
+}
+
+func TestForecastExpensesHandler_InvalidConfidenceLevel(t *testing.T) {
+	w, c := setupSimpleForecastTest()
+	c.Request, _ = http.NewRequest("POST", "/api/v1/forecast/expenses",
+		bytes.NewBufferString(`{"monthsAhead": 3, "confidenceLevels": [0.8, 1.5]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handlers.ForecastExpensesHandler(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -1,17 +1,24 @@
 package handlers_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/RedShawn258/FinTrack/backend/internal/db"
 	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
 )
 
 func setupSimpleGamificationTest() (*httptest.ResponseRecorder, *gin.Context) {
@@ -127,46 +134,193 @@ func TestGetLevelTitle(t *testing.T) {
 	}
 }
 
-func TestAnalyticsHandler(t *testing.T) {
-	w, c := setupSimpleGamificationTest()
+func TestGamificationHandler(t *testing.T) {
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
 
-	// Set up request
-	c.Request, _ = http.NewRequest("GET", "/api/v1/features/analytics", nil)
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	earned := time.Date(2024, 6, 3, 9, 0, 0, 0, time.Local)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `user_badges` WHERE user_id = ?")).
+		WithArgs(uint(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "badge_id", "earned_at"}).AddRow(1, 1, 2, earned))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `badges` WHERE `badges`.`id` = ?")).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "threshold"}).AddRow(2, "Budget Beginner", 10))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `user_points` WHERE user_id = ? ORDER BY created_at DESC LIMIT ?")).
+		WithArgs(uint(1), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "points", "reason", "activity_type"}).
+			AddRow(1, 1, 10, "Created a budget", models.EventBudgetCreated))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(points), 0) as total FROM `user_points` WHERE user_id = ?")).
+		WithArgs(uint(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(150))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `badges` WHERE id NOT IN (?) AND threshold <= ? LIMIT ?")).
+		WithArgs(uint(2), 250, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "threshold"}).AddRow(3, "Savings Star", 100))
 
-	// Execute the handler
-	handlers.AnalyticsHandler(c)
+	w, c := setupSimpleGamificationTest()
+	c.Request, _ = http.NewRequest("GET", "/api/v1/features/gamification", nil)
+	handlers.GamificationHandler(c)
 
-	// Check status code
-	assert.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	var response models.GamificationSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 150, response.TotalPoints)
+	assert.Equal(t, 2, response.Level)
+	require.Len(t, response.AllBadges, 1)
+	assert.Equal(t, "Budget Beginner", response.AllBadges[0].Badge.Name)
+	require.Len(t, response.NextBadges, 1)
+	assert.Equal(t, "Savings Star", response.NextBadges[0].Name)
+}
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	assert.NoError(t, err)
+func TestApplyGamificationEvent(t *testing.T) {
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
 
-	// Validate stub data
-	assert.Equal(t, 1234.56, response["totalExpense"])
-	assert.Equal(t, 2000.00, response["totalBudget"])
-	assert.Contains(t, response["analytics"], "Advanced analytics coming soon")
+	event := models.GamificationEvent{UserID: 1, Type: models.EventBudgetCreated, Key: "budget:4", Reason: "Created a budget"}
+	pointsSQL := regexp.QuoteMeta("INSERT INTO `user_points` (`user_id`,`points`,`reason`,`activity_type`,`event_key`,`created_at`,`updated_at`) VALUES (?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE")
+	totalSQL := regexp.QuoteMeta("SELECT COALESCE(SUM(points), 0) FROM `user_points` WHERE user_id = ?")
+	badgesSQL := regexp.QuoteMeta("SELECT * FROM `badges` WHERE id NOT IN (SELECT `badge_id` FROM `user_badges` WHERE user_id = ?) ORDER BY threshold")
+	badgeColumns := []string{"id", "name", "description", "category", "threshold", "rule"}
+
+	t.Run("Earns_Points_And_Badges", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(pointsSQL).
+			WithArgs(uint(1), 10, "Created a budget", models.EventBudgetCreated, "budget:4", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(badgesSQL).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows(badgeColumns).
+				AddRow(1, "Budget Beginner", "Created your first budget", "budgeting", 10, []byte(`{"stat":"budget_count","op":">=","value":1}`)).
+				AddRow(2, "Points Collector", "Earned 50 points", "achievement", 50, nil).
+				AddRow(3, "Broken", "Has an invalid rule", "achievement", 60, []byte(`{"stat":"logins","op":">=","value":1}`)).
+				AddRow(4, "Expense Tracker", "Logged 100 transactions", "tracking", 250, []byte(`{"stat":"transaction_count","op":">=","value":100}`)))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `budgets` WHERE user_id = ?")).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `user_badges` (`user_id`,`badge_id`,`earned_at`,`created_at`,`updated_at`) VALUES (?,?,?,?,?) ON DUPLICATE KEY UPDATE")).
+			WithArgs(uint(1), uint(1), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 0))
+		mock.ExpectCommit()
+		// A badge without a rule is awarded on its points threshold
+		mock.ExpectQuery(totalSQL).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(55))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `user_badges`")).
+			WithArgs(uint(1), uint(2), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `transactions` WHERE user_id = ?")).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+		// The first badge was awarded concurrently and the broken one is skipped; only the second is new
+		awarded, err := handlers.TestableApplyGamificationEvent(event, zap.NewNop())
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		require.Len(t, awarded, 1)
+		assert.Equal(t, uint(2), awarded[0].BadgeID)
+		assert.Equal(t, "Points Collector", awarded[0].Badge.Name)
+	})
+
+	t.Run("Replayed_Event_Earns_Nothing", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(pointsSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		mock.ExpectQuery(badgesSQL).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows(badgeColumns))
+
+		awarded, err := handlers.TestableApplyGamificationEvent(event, zap.NewNop())
+		require.NoError(t, err)
+		assert.Empty(t, awarded)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown_Event", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		_, err = handlers.TestableApplyGamificationEvent(models.GamificationEvent{UserID: 1, Type: "logged_in", Key: "login:1"}, zap.NewNop())
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
-func TestNotificationHandler(t *testing.T) {
-	w, c := setupSimpleGamificationTest()
+// gamificationEvents collects the events published while a test runs.
+var gamificationEvents []models.GamificationEvent
 
-	// Set up request
-	c.Request, _ = http.NewRequest("GET", "/api/v1/features/notifications", nil)
+var subscribeGamificationEvents sync.Once
 
-	// Execute the handler
-	handlers.NotificationHandler(c)
+func TestGamificationEventPublishing(t *testing.T) {
+	subscribeGamificationEvents.Do(func() {
+		handlers.OnGamificationEvent(func(event models.GamificationEvent, log *zap.Logger) {
+			gamificationEvents = append(gamificationEvents, event)
+		})
+	})
+	gamificationEvents = nil
 
-	// Check status code
-	assert.Equal(t, http.StatusOK, w.Code)
+	router, _ := setup()
+	router.PUT("/api/v1/profile", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.UpdateProfileHandler(c)
+	})
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	assert.NoError(t, err)
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
 
-	// Validate stub data
-	assert.Contains(t, response["notification"], "Push notifications coming soon")
+	t.Run("Profile_Completed", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "first_name"}).AddRow(1, "testuser", "test@example.com", "Test"))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		body := []byte(`{"lastName":"User","phoneNumber":"555-0100"}`)
+		req, _ := http.NewRequest("PUT", "/api/v1/profile", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []models.GamificationEvent{
+			{UserID: 1, Type: models.EventProfileCompleted, Key: "profile", Reason: "Completed your profile"},
+		}, gamificationEvents)
+	})
+
+	t.Run("Profile_Incomplete", func(t *testing.T) {
+		gamificationEvents = nil
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).AddRow(1, "testuser", "test@example.com"))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		req, _ := http.NewRequest("PUT", "/api/v1/profile", bytes.NewBufferString(`{"theme":"dark"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, gamificationEvents)
+	})
 }
@@ -0,0 +1,163 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestHeatmapSeries(t *testing.T) {
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(2024, m, d, 0, 0, 0, 0, time.Local)
+	}
+	at := func(m time.Month, d, hour, minute int) *time.Time {
+		t := time.Date(2024, m, d, hour, minute, 0, 0, time.Local)
+		return &t
+	}
+	intPtr := func(v int) *int { return &v }
+
+	t.Run("Weekday_Hour", func(t *testing.T) {
+		// June 3rd and 10th are Mondays, the 8th a Saturday
+		series := handlers.TestableHeatmapSeries(models.HeatmapViewWeekdayHour, []models.Transaction{
+			{Type: models.TransactionTypeExpense, Amount: 10, TransactionDate: date(6, 3), OccurredAt: at(6, 3, 9, 15)},
+			{Type: models.TransactionTypeExpense, Amount: 30, TransactionDate: date(6, 8), OccurredAt: at(6, 8, 20, 0)},
+			{Type: models.TransactionTypeExpense, Amount: 5, TransactionDate: date(6, 10), OccurredAt: at(6, 10, 9, 40)},
+			{Type: models.TransactionTypeExpense, Amount: 7, TransactionDate: date(6, 4)},
+		})
+
+		assert.Equal(t, []models.HeatmapCell{
+			{Weekday: intPtr(1), Hour: intPtr(9), Amount: 15, Transactions: 2},
+			{Weekday: intPtr(6), Hour: intPtr(20), Amount: 30, Transactions: 1},
+		}, series.Cells)
+		assert.Equal(t, 45.0, series.Total)
+		assert.Equal(t, 1, series.Untimed)
+	})
+
+	t.Run("Day_Of_Month", func(t *testing.T) {
+		series := handlers.TestableHeatmapSeries(models.HeatmapViewDayOfMonth, []models.Transaction{
+			{Type: models.TransactionTypeExpense, Amount: 5, TransactionDate: date(6, 15)},
+			{Type: models.TransactionTypeExpense, Amount: 10, TransactionDate: date(6, 3)},
+			{Type: models.TransactionTypeExpense, Amount: 20, TransactionDate: date(7, 3)},
+		})
+
+		assert.Equal(t, []models.HeatmapCell{
+			{Day: 3, Amount: 30, Transactions: 2},
+			{Day: 15, Amount: 5, Transactions: 1},
+		}, series.Cells)
+	})
+
+	t.Run("Calendar", func(t *testing.T) {
+		// Refunds are netted off the day's spending
+		series := handlers.TestableHeatmapSeries(models.HeatmapViewCalendar, []models.Transaction{
+			{Type: models.TransactionTypeExpense, Amount: 10, TransactionDate: date(6, 3)},
+			{Type: models.TransactionTypeRefund, Amount: 4, TransactionDate: date(6, 3)},
+			{Type: models.TransactionTypeExpense, Amount: 2.5, TransactionDate: date(6, 1)},
+		})
+
+		assert.Equal(t, []models.HeatmapCell{
+			{Date: "2024-06-01", Amount: 2.5, Transactions: 1},
+			{Date: "2024-06-03", Amount: 6, Transactions: 2},
+		}, series.Cells)
+		assert.Equal(t, 8.5, series.Total)
+	})
+}
+
+func TestGetSpendingHeatmap(t *testing.T) {
+	router, _ := setup()
+	router.GET("/api/v1/features/analytics/heatmap", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.GetSpendingHeatmap(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2024, 6, 30, 0, 0, 0, 0, time.Local)
+	day := time.Date(2024, 6, 3, 0, 0, 0, 0, time.Local)
+
+	t.Run("Group_By_Category", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT transaction_date, category_id, COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as amount, COUNT(*) as transactions FROM `transactions` WHERE (user_id = ? AND type IN (?,?) AND transaction_date >= ? AND transaction_date <= ?) AND `transactions`.`deleted_at` IS NULL GROUP BY transaction_date, category_id")).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, start, end).
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_date", "category_id", "amount", "transactions"}).
+				AddRow(day, 4, 12.50, 2).
+				AddRow(day, nil, 40.00, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE user_id = ?")).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(4, 1, "Coffee"))
+
+		req, _ := http.NewRequest("GET", "/api/v1/features/analytics/heatmap?groupBy=category&startDate=2024-06-01&endDate=2024-06-30", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.Heatmap
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.HeatmapViewCalendar, response.View)
+		require.Len(t, response.Series, 2)
+		assert.Equal(t, "Uncategorized", response.Series[0].Group)
+		assert.Nil(t, response.Series[0].CategoryID)
+		assert.Equal(t, "Coffee", response.Series[1].Group)
+		assert.Equal(t, []models.HeatmapCell{{Date: "2024-06-03", Amount: 12.5, Transactions: 2}}, response.Series[1].Cells)
+	})
+
+	t.Run("Group_By_Keyword", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		keywordSQL := regexp.QuoteMeta("SELECT transaction_date, HOUR(occurred_at) as hour, COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as amount, COUNT(*) as transactions FROM `transactions` WHERE (user_id = ? AND type IN (?,?) AND transaction_date >= ? AND transaction_date <= ?) AND description LIKE ? AND `transactions`.`deleted_at` IS NULL GROUP BY transaction_date, hour")
+		mock.ExpectQuery(keywordSQL).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, start, end, "%coffee%").
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_date", "hour", "amount", "transactions"}).
+				AddRow(day, 8, 4.50, 1).
+				AddRow(day, nil, 3.00, 1))
+		mock.ExpectQuery(keywordSQL).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, start, end, `%100\%%`).
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_date", "hour", "amount", "transactions"}))
+
+		req, _ := http.NewRequest("GET", "/api/v1/features/analytics/heatmap?view=weekday_hour&groupBy=keyword&keywords=coffee,100%25&startDate=2024-06-01&endDate=2024-06-30", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.Heatmap
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Series, 2)
+		assert.Equal(t, "coffee", response.Series[0].Group)
+		require.Len(t, response.Series[0].Cells, 1)
+		assert.Equal(t, 1, *response.Series[0].Cells[0].Weekday)
+		assert.Equal(t, 8, *response.Series[0].Cells[0].Hour)
+		assert.Equal(t, 1, response.Series[0].Untimed)
+		assert.Equal(t, "100%", response.Series[1].Group)
+		assert.Empty(t, response.Series[1].Cells)
+	})
+
+	t.Run("Invalid_Parameters", func(t *testing.T) {
+		for _, query := range []string{"view=hourly", "groupBy=merchant", "groupBy=keyword", "groupBy=keyword&keywords=,", "startDate=soon"} {
+			req, _ := http.NewRequest("GET", "/api/v1/features/analytics/heatmap?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
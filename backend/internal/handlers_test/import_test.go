@@ -0,0 +1,711 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+)
+
+// newImportRequest builds a multipart statement upload with the given form fields
+func newImportRequest(t *testing.T, url, filename, content string, fields map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	for k, v := range fields {
+		require.NoError(t, writer.WriteField(k, v))
+	}
+	require.NoError(t, writer.Close())
+
+	req, _ := http.NewRequest("POST", url, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		raw       string
+		decimal   string
+		expected  float64
+		expectErr bool
+	}{
+		{"12.50", ".", 12.50, false},
+		{"-1,234.56", ".", -1234.56, false},
+		{"$ 45.00", ".", 45.00, false},
+		{"(45.10)", ".", -45.10, false},
+		{"12.50-", ".", -12.50, false},
+		{"1.234,56", ",", 1234.56, false},
+		{"-0,99 EUR", ",", -0.99, false},
+		{"", ".", 0, true},
+		{"abc", ".", 0, true},
+	}
+
+	for _, tc := range testCases {
+		got, err := handlers.TestableParseAmount(tc.raw, tc.decimal)
+		if tc.expectErr {
+			assert.Error(t, err, "amount %q should be rejected", tc.raw)
+			continue
+		}
+		require.NoError(t, err, "amount %q", tc.raw)
+		assert.InDelta(t, tc.expected, got, 0.0001, "amount %q", tc.raw)
+	}
+}
+
+func TestDescriptionSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, handlers.TestableDescriptionSimilarity("NETFLIX.COM", "netflix com"))
+	assert.Greater(t, handlers.TestableDescriptionSimilarity("AMAZON MKTPLACE 123", "Amazon Mktplace"), 0.6)
+	assert.Less(t, handlers.TestableDescriptionSimilarity("Rent", "Grocery store"), 0.3)
+}
+
+func TestParseCSVStatement(t *testing.T) {
+	t.Run("European_Format", func(t *testing.T) {
+		content := "Datum;Betrag;Verwendungszweck;Kategorie\n" +
+			"31.01.2024;-1.234,56;Miete Januar;Housing\n" +
+			"01.02.2024;2.500,00;Gehalt;\n" +
+			"xx.02.2024;-5,00;Broken;\n"
+
+		rows, err := handlers.TestableParseCSVStatement(strings.NewReader(content), handlers.ImportOptions{
+			DateColumn:        "datum",
+			AmountColumn:      "Betrag",
+			DescriptionColumn: "Verwendungszweck",
+			CategoryColumn:    "Kategorie",
+			DateFormat:        "DD.MM.YYYY",
+			DecimalSeparator:  ",",
+			Delimiter:         ";",
+		})
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		assert.Equal(t, "2024-01-31", rows[0].Date)
+		assert.Equal(t, 1234.56, rows[0].Amount)
+		assert.Equal(t, "expense", rows[0].Type)
+		assert.Equal(t, "Housing", rows[0].CategoryName)
+
+		assert.Equal(t, "income", rows[1].Type)
+		assert.Equal(t, 2500.0, rows[1].Amount)
+
+		assert.Contains(t, rows[2].Error, "invalid date")
+		assert.Equal(t, 4, rows[2].Line)
+	})
+
+	t.Run("No_Header_Positive_Expense", func(t *testing.T) {
+		content := "01/15/2024,Coffee,4.50\n01/16/2024,Refund,-4.50\n"
+
+		rows, err := handlers.TestableParseCSVStatement(strings.NewReader(content), handlers.ImportOptions{
+			DateColumn:        "0",
+			AmountColumn:      "2",
+			DescriptionColumn: "1",
+			DateFormat:        "MM/DD/YYYY",
+			NoHeader:          true,
+			SignConvention:    "positive-expense",
+		})
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		assert.Equal(t, "expense", rows[0].Type)
+		assert.Equal(t, "income", rows[1].Type)
+	})
+
+	t.Run("Unknown_Column", func(t *testing.T) {
+		_, err := handlers.TestableParseCSVStatement(strings.NewReader("Date,Amount\n"), handlers.ImportOptions{
+			DateColumn:        "Date",
+			AmountColumn:      "Amount",
+			DescriptionColumn: "Memo",
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseOFXStatement(t *testing.T) {
+	t.Run("SGML_Bank_Statement", func(t *testing.T) {
+		f, err := os.Open("testdata/statement.ofx")
+		require.NoError(t, err)
+		defer f.Close()
+
+		rows, err := handlers.TestableParseOFXStatement(f)
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+
+		assert.Equal(t, "2024-03-01", rows[0].Date)
+		assert.Equal(t, 82.40, rows[0].Amount)
+		assert.Equal(t, "expense", rows[0].Type)
+		assert.Equal(t, "WHOLE FOODS #123 - POS PURCHASE", rows[0].Description)
+		assert.Equal(t, "2024030100001", rows[0].ExternalID)
+		assert.Equal(t, "1234567890", rows[0].Account)
+
+		assert.Equal(t, "income", rows[1].Type)
+		assert.Equal(t, 2500.0, rows[1].Amount)
+
+		assert.Equal(t, "transfer", rows[2].Type)
+
+		assert.Contains(t, rows[3].Error, "no FITID")
+	})
+
+	t.Run("XML_QFX_Statement", func(t *testing.T) {
+		f, err := os.Open("testdata/statement.qfx")
+		require.NoError(t, err)
+		defer f.Close()
+
+		rows, err := handlers.TestableParseOFXStatement(f)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		assert.Equal(t, "2024-04-05", rows[0].Date)
+		assert.Equal(t, 15.99, rows[0].Amount)
+		assert.Equal(t, "CC-0405-01", rows[0].ExternalID)
+
+		assert.Equal(t, "income", rows[1].Type)
+		assert.Equal(t, "AMAZON & CO - RETURN", rows[1].Description)
+	})
+
+	t.Run("SGML_Unclosed_Transactions", func(t *testing.T) {
+		// SGML lets </STMTTRN> be left out; each block then ends where the next one starts
+		content := "<OFX>\n<BANKACCTFROM>\n<ACCTID>555\n</BANKACCTFROM>\n<BANKTRANLIST>\n" +
+			"<STMTTRN>\n<TRNTYPE>DEBIT\n<DTPOSTED>20240301\n<TRNAMT>-10.00\n<FITID>A1\n<NAME>FIRST\n" +
+			"<STMTTRN>\n<TRNTYPE>DEBIT\n<DTPOSTED>20240302\n<TRNAMT>-20.00\n<FITID>A2\n<NAME>SECOND\n" +
+			"<STMTTRN>\n<TRNTYPE>CREDIT\n<DTPOSTED>20240303\n<TRNAMT>30.00\n<FITID>A3\n<NAME>THIRD\n" +
+			"</BANKTRANLIST>\n<LEDGERBAL>\n<BALAMT>100.00\n</OFX>\n"
+
+		rows, err := handlers.TestableParseOFXStatement(strings.NewReader(content))
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		for i, want := range []struct {
+			id, description string
+			amount          float64
+			line            int
+		}{
+			{"A1", "FIRST", 10.00, 6},
+			{"A2", "SECOND", 20.00, 12},
+			{"A3", "THIRD", 30.00, 18},
+		} {
+			assert.Empty(t, rows[i].Error)
+			assert.Equal(t, want.id, rows[i].ExternalID)
+			assert.Equal(t, want.description, rows[i].Description)
+			assert.Equal(t, want.amount, rows[i].Amount)
+			assert.Equal(t, want.line, rows[i].Line)
+			assert.Equal(t, "555", rows[i].Account)
+		}
+	})
+
+	t.Run("Not_OFX", func(t *testing.T) {
+		_, err := handlers.TestableParseOFXStatement(strings.NewReader("Date,Amount\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseQIFStatement(t *testing.T) {
+	f, err := os.Open("testdata/statement.qif")
+	require.NoError(t, err)
+	defer f.Close()
+
+	rows, err := handlers.TestableParseQIFStatement(f, "")
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+
+	assert.Equal(t, "2024-03-01", rows[0].Date)
+	assert.Equal(t, 82.40, rows[0].Amount)
+	assert.Equal(t, "expense", rows[0].Type)
+	assert.Equal(t, "Whole Foods - Weekly shop", rows[0].Description)
+	assert.Equal(t, "Groceries", rows[0].CategoryName)
+	assert.Equal(t, 2, rows[0].Line)
+
+	assert.Equal(t, "2024-03-02", rows[1].Date)
+	assert.Equal(t, "income", rows[1].Type)
+	assert.Equal(t, 2500.0, rows[1].Amount)
+
+	assert.Equal(t, "2024-03-03", rows[2].Date)
+	assert.Equal(t, "transfer", rows[2].Type)
+	assert.Empty(t, rows[2].CategoryName)
+
+	// Identical records get distinct but stable IDs
+	assert.True(t, strings.HasPrefix(rows[3].ExternalID, "qif:"))
+	assert.NotEqual(t, rows[3].ExternalID, rows[4].ExternalID)
+
+	assert.Contains(t, rows[5].Error, "invalid date")
+
+	f2, err := os.Open("testdata/statement.qif")
+	require.NoError(t, err)
+	defer f2.Close()
+	again, err := handlers.TestableParseQIFStatement(f2, "")
+	require.NoError(t, err)
+	for i := range rows {
+		assert.Equal(t, rows[i].ExternalID, again[i].ExternalID)
+	}
+}
+
+func TestImportTransactions(t *testing.T) {
+	router, _ := setup()
+	router.POST("/api/v1/transactions/import", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.ImportTransactions(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	t.Run("Preview_Flags_Duplicates", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE user_id = ?")).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(4, 1, "Groceries"))
+
+		existingDate := time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND transaction_date >= ? AND transaction_date <= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "amount", "description", "transaction_date"}).
+				AddRow(55, 1, "expense", 82.40, "WHOLE FOODS #123", existingDate))
+		// "Fun" is not a known category, so the cinema row goes through the rules
+		mock.ExpectQuery(ruleQuery).
+			WithArgs(uint(1), true).
+			WillReturnRows(sqlmock.NewRows(ruleColumns).AddRow(3, 1, 9, "Movies", 0, true, "contains", "cinema", nil, nil, ""))
+
+		content := "Date,Amount,Description,Category\n" +
+			"2024-03-01,-82.40,Whole Foods 123,Groceries\n" +
+			"2024-03-03,-15.00,Cinema,Fun\n"
+		req := newImportRequest(t, "/api/v1/transactions/import", "statement.csv", content, map[string]string{
+			"dateColumn":        "Date",
+			"amountColumn":      "Amount",
+			"descriptionColumn": "Description",
+			"categoryColumn":    "Category",
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Rows    []handlers.ImportRow   `json:"rows"`
+			Summary handlers.ImportSummary `json:"summary"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, handlers.ImportSummary{Total: 2, New: 1, Duplicates: 1}, response.Summary)
+
+		require.Len(t, response.Rows, 2)
+		assert.True(t, response.Rows[0].Duplicate)
+		require.NotNil(t, response.Rows[0].DuplicateOf)
+		assert.Equal(t, uint(55), *response.Rows[0].DuplicateOf)
+		require.NotNil(t, response.Rows[0].CategoryID)
+		assert.Equal(t, uint(4), *response.Rows[0].CategoryID)
+		assert.Nil(t, response.Rows[0].RuleID)
+		require.NotNil(t, response.Rows[1].CategoryID)
+		assert.Equal(t, uint(9), *response.Rows[1].CategoryID)
+		require.NotNil(t, response.Rows[1].RuleID)
+		assert.Equal(t, uint(3), *response.Rows[1].RuleID)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Multiple_Files_Skip_Known_FITIDs", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		ofx, err := os.ReadFile("testdata/statement.ofx")
+		require.NoError(t, err)
+		qif, err := os.ReadFile("testdata/statement.qif")
+		require.NoError(t, err)
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for name, content := range map[string][]byte{"march.ofx": ofx, "march.qif": qif} {
+			part, err := writer.CreateFormFile("file", name)
+			require.NoError(t, err)
+			_, err = part.Write(content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`external_account`,`external_id` FROM `transactions` WHERE user_id = ? AND external_id IN")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "external_account", "external_id"}).AddRow(70, "1234567890", "2024030100001"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND transaction_date >= ? AND transaction_date <= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(ruleQuery).WillReturnRows(sqlmock.NewRows(ruleColumns))
+
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/import", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Rows    []handlers.ImportRow         `json:"rows"`
+			Summary handlers.ImportSummary       `json:"summary"`
+			Files   []handlers.ImportFileSummary `json:"files"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, handlers.ImportSummary{Total: 10, New: 7, Duplicates: 1, Errors: 2}, response.Summary)
+
+		require.Len(t, response.Files, 2)
+		summaries := map[string]handlers.ImportFileSummary{}
+		for _, f := range response.Files {
+			summaries[f.File] = f
+		}
+		assert.Equal(t, "ofx", summaries["march.ofx"].Format)
+		assert.Equal(t, handlers.ImportSummary{Total: 4, New: 2, Duplicates: 1, Errors: 1}, summaries["march.ofx"].Summary)
+		assert.Equal(t, "qif", summaries["march.qif"].Format)
+		assert.Equal(t, handlers.ImportSummary{Total: 6, New: 5, Errors: 1}, summaries["march.qif"].Summary)
+
+		for _, row := range response.Rows {
+			if row.ExternalID == "2024030100001" {
+				require.NotNil(t, row.DuplicateOf)
+				assert.Equal(t, uint(70), *row.DuplicateOf)
+			}
+		}
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing_Mapping", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		req := newImportRequest(t, "/api/v1/transactions/import", "statement.csv", "Date,Amount\n", map[string]string{
+			"dateColumn": "Date",
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("Flags_Rows_Repeated_Across_Files_With_The_Same_Name", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		// Two overlapping exports, both named statement.csv; the second repeats the cinema row
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for _, content := range []string{
+			"Date,Amount,Description\n2024-03-01,-82.40,Whole Foods\n2024-03-03,-15.00,Cinema\n",
+			"Date,Amount,Description\n2024-03-03,-15.00,CINEMA\n2024-03-05,-9.99,Streaming\n",
+		} {
+			part, err := writer.CreateFormFile("file", "statement.csv")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		for k, v := range map[string]string{"dateColumn": "Date", "amountColumn": "Amount", "descriptionColumn": "Description"} {
+			require.NoError(t, writer.WriteField(k, v))
+		}
+		require.NoError(t, writer.Close())
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND transaction_date >= ? AND transaction_date <= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(ruleQuery).WillReturnRows(sqlmock.NewRows(ruleColumns))
+
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/import", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Rows  []handlers.ImportRow         `json:"rows"`
+			Files []handlers.ImportFileSummary `json:"files"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		require.Len(t, response.Rows, 4)
+		assert.Equal(t, []int{0, 0, 1, 1}, []int{response.Rows[0].FileIndex, response.Rows[1].FileIndex,
+			response.Rows[2].FileIndex, response.Rows[3].FileIndex})
+		assert.True(t, response.Rows[2].Duplicate)
+		assert.Nil(t, response.Rows[2].DuplicateOf)
+		require.NotNil(t, response.Rows[2].DuplicateOfRow)
+		assert.Equal(t, 1, *response.Rows[2].DuplicateOfRow)
+		assert.False(t, response.Rows[3].Duplicate)
+
+		require.Len(t, response.Files, 2)
+		assert.Equal(t, handlers.ImportSummary{Total: 2, New: 2}, response.Files[0].Summary)
+		assert.Equal(t, handlers.ImportSummary{Total: 2, New: 1, Duplicates: 1}, response.Files[1].Summary)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCommitImport(t *testing.T) {
+	router, _ := setup()
+	router.POST("/api/v1/transactions/import/commit", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.CommitImport(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	// Accepted rows are checked for duplicates again inside the commit transaction
+	transactionsAroundQuery := regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND transaction_date >= ? AND transaction_date <= ?)")
+
+	t.Run("Inserts_In_One_Transaction_And_Recalcs_Once", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(ruleQuery).WillReturnRows(sqlmock.NewRows(ruleColumns))
+		mock.ExpectBegin()
+		mock.ExpectQuery(transactionsAroundQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")).
+			WillReturnResult(sqlmock.NewResult(10, 2))
+		mock.ExpectCommit()
+
+		// One global budget covers both uncategorized rows; it is recalculated a single time
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date <= ? AND end_date >= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category_id", "limit_amount", "start_date", "end_date"}).
+				AddRow(1, 1, nil, 300.00, start, start.AddDate(0, 1, -1)))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'refund' THEN -amount ELSE 0 END), 0) as total FROM `transactions`")).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(97.40))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `budgets` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		body := `{"rows": [
+			{"line": 2, "date": "2024-03-01", "amount": 82.40, "type": "expense", "description": "Whole Foods"},
+			{"line": 3, "date": "2024-03-03", "amount": 15.00, "type": "expense", "description": "Cinema"}
+		]}`
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/import/commit", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2.0, response["added"])
+		assert.Equal(t, 0.0, response["skipped"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Skips_Already_Imported_And_Counts_Per_File", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(ruleQuery).WillReturnRows(sqlmock.NewRows(ruleColumns))
+		mock.ExpectBegin()
+		// FITID 2024030100001 was loaded by an earlier import
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`external_account`,`external_id` FROM `transactions` WHERE user_id = ? AND external_id IN (?,?,?)")).
+			WithArgs(uint(1), "2024030100001", "2024030200002", "2024030200002").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "external_account", "external_id"}).AddRow(70, "", "2024030100001"))
+		mock.ExpectQuery(transactionsAroundQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")).
+			WithArgs(uint(1), nil, "income", 2500.00, "ACME CORP PAYROLL", sqlmock.AnyArg(), nil, nil, "", "2024030200002",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(11, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date <= ? AND end_date >= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// The payroll row appears twice (e.g. overlapping statements); only the first is added
+		body := `{"rows": [
+			{"file": "march.ofx", "line": 40, "date": "2024-03-01", "amount": 82.40, "type": "expense", "description": "WHOLE FOODS #123", "externalId": "2024030100001"},
+			{"file": "march.ofx", "line": 48, "date": "2024-03-02", "amount": 2500.00, "type": "income", "description": "ACME CORP PAYROLL", "externalId": "2024030200002"},
+			{"file": "march.ofx", "line": 60, "date": "not-a-date", "amount": 12.00, "type": "expense", "description": "Broken"},
+			{"file": "april.ofx", "fileIndex": 1, "line": 12, "date": "2024-03-02", "amount": 2500.00, "type": "income", "description": "ACME CORP PAYROLL", "externalId": "2024030200002"}
+		]}`
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/import/commit", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response struct {
+			Added   int                         `json:"added"`
+			Skipped int                         `json:"skipped"`
+			Failed  int                         `json:"failed"`
+			Errors  []string                    `json:"errors"`
+			Files   []handlers.ImportFileResult `json:"files"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Added)
+		assert.Equal(t, 2, response.Skipped)
+		assert.Equal(t, 1, response.Failed)
+		assert.Equal(t, []string{"invalid date on line 60"}, response.Errors)
+		assert.Equal(t, []handlers.ImportFileResult{
+			{File: "march.ofx", Added: 1, Skipped: 1, Failed: 1},
+			{File: "april.ofx", FileIndex: 1, Skipped: 1},
+		}, response.Files)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FITIDs_Are_Per_Account_And_Conflicts_Are_Skipped", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(ruleQuery).WillReturnRows(sqlmock.NewRows(ruleColumns))
+		mock.ExpectBegin()
+		// Deleted transactions are included, so re-importing does not bring them back. FITID 0001 is
+		// known for another account only.
+		mock.ExpectQuery("^"+regexp.QuoteMeta("SELECT `id`,`external_account`,`external_id` FROM `transactions` WHERE user_id = ? AND external_id IN (?,?)")+"$").
+			WithArgs(uint(1), "0001", "0002").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "external_account", "external_id"}).AddRow(70, "222", "0001"))
+		mock.ExpectQuery(transactionsAroundQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")+".*"+regexp.QuoteMeta("ON DUPLICATE KEY UPDATE")).
+			WithArgs(uint(1), nil, "expense", 82.40, "Whole Foods", sqlmock.AnyArg(), nil, nil, "111", "0001",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(12, 1))
+		// A concurrent commit of the same statement inserted FITID 0002 after the check
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")+".*"+regexp.QuoteMeta("ON DUPLICATE KEY UPDATE")).
+			WithArgs(uint(1), nil, "expense", 15.00, "Cinema", sqlmock.AnyArg(), nil, nil, "111", "0002",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date <= ? AND end_date >= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		body := `{"rows": [
+			{"line": 40, "date": "2024-03-01", "amount": 82.40, "type": "expense", "description": "Whole Foods", "externalId": "0001", "account": "111"},
+			{"line": 48, "date": "2024-03-03", "amount": 15.00, "type": "expense", "description": "Cinema", "externalId": "0002", "account": "111"}
+		]}`
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/import/commit", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1.0, response["added"])
+		assert.Equal(t, 1.0, response["skipped"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rechecks_Duplicates_Inside_The_Transaction", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(ruleQuery).WillReturnRows(sqlmock.NewRows(ruleColumns))
+		mock.ExpectBegin()
+		// The cinema ticket was entered by hand after the preview; the coffee was already there and the
+		// user accepted the flagged row anyway
+		mock.ExpectQuery(transactionsAroundQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "amount", "description", "transaction_date"}).
+				AddRow(80, 1, "expense", 4.50, "Coffee", time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local)).
+				AddRow(81, 1, "expense", 15.00, "Cinema", time.Date(2024, 3, 3, 0, 0, 0, 0, time.Local)))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")).
+			WillReturnResult(sqlmock.NewResult(20, 2))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date <= ? AND end_date >= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// Both files are named statement.csv; the second repeats the Whole Foods row of the first
+		body := `{"rows": [
+			{"file": "statement.csv", "fileIndex": 0, "line": 2, "date": "2024-03-01", "amount": 82.40, "type": "expense", "description": "Whole Foods"},
+			{"file": "statement.csv", "fileIndex": 1, "line": 2, "date": "2024-03-01", "amount": 82.40, "type": "expense", "description": "Whole Foods"},
+			{"file": "statement.csv", "fileIndex": 1, "line": 3, "date": "2024-03-02", "amount": 4.50, "type": "expense", "description": "Coffee", "duplicate": true, "duplicateOf": 80},
+			{"file": "statement.csv", "fileIndex": 1, "line": 4, "date": "2024-03-03", "amount": 15.00, "type": "expense", "description": "Cinema"}
+		]}`
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/import/commit", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response struct {
+			Added   int                         `json:"added"`
+			Skipped int                         `json:"skipped"`
+			Files   []handlers.ImportFileResult `json:"files"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Added)
+		assert.Equal(t, 2, response.Skipped)
+		assert.Equal(t, []handlers.ImportFileResult{
+			{File: "statement.csv", FileIndex: 0, Added: 1},
+			{File: "statement.csv", FileIndex: 1, Added: 1, Skipped: 2},
+		}, response.Files)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejects_Categories_Of_Other_Users", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		// Category 4 is the user's; 99 belongs to someone else
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `categories` WHERE (user_id = ? AND id IN (?,?)) AND `categories`.`deleted_at` IS NULL")).
+			WithArgs(uint(1), uint(4), uint(99)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectBegin()
+		mock.ExpectQuery(transactionsAroundQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")).
+			WithArgs(uint(1), uint(4), "expense", 82.40, "Whole Foods", sqlmock.AnyArg(), nil, nil, "", nil,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(12, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date <= ? AND end_date >= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		body := `{"rows": [
+			{"line": 2, "date": "2024-03-01", "amount": 82.40, "type": "expense", "description": "Whole Foods", "categoryId": 4},
+			{"line": 3, "date": "2024-03-03", "amount": 15.00, "type": "expense", "description": "Cinema", "categoryId": 99}
+		]}`
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/import/commit", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response struct {
+			Added  int      `json:"added"`
+			Failed int      `json:"failed"`
+			Errors []string `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Added)
+		assert.Equal(t, 1, response.Failed)
+		assert.Equal(t, []string{"unknown category on line 3"}, response.Errors)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Counts_Invalid_Row_As_Failed", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		body := `{"rows": [{"line": 2, "date": "03/01/2024", "amount": 82.40}]}`
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/import/commit", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 0.0, response["added"])
+		assert.Equal(t, 1.0, response["failed"])
+		assert.Equal(t, []interface{}{"invalid date on line 2"}, response["errors"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,189 @@
+package handlers_test
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/middlewares"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// recordingBackend is a StreamBackend that keeps what is published, standing in for a shared one.
+type recordingBackend struct {
+	published []models.StreamEvent
+	deliver   func(event models.StreamEvent)
+}
+
+func (b *recordingBackend) Publish(event models.StreamEvent) error {
+	b.published = append(b.published, event)
+	b.deliver(event)
+	return nil
+}
+
+func (b *recordingBackend) Subscribe(deliver func(event models.StreamEvent)) {
+	b.deliver = deliver
+}
+
+// streamToken signs a JWT for userID with the secret setup() puts in the context.
+func streamToken(t *testing.T, userID uint) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &handlers.Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	signed, err := token.SignedString([]byte("test_jwt_secret_for_unit_tests"))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestNotificationHub(t *testing.T) {
+	t.Run("Delivers_To_The_Users_Streams", func(t *testing.T) {
+		hub := handlers.NewNotificationHub(handlers.NewLocalStreamBackend(), zap.NewNop())
+		mine, unsubscribe := hub.Subscribe(1)
+		theirs, unsubscribeTheirs := hub.Subscribe(2)
+		defer unsubscribeTheirs()
+
+		hub.Publish(1, models.StreamEventImportCompleted, map[string]int{"added": 3})
+
+		select {
+		case event := <-mine:
+			assert.Equal(t, uint(1), event.UserID)
+			assert.Equal(t, models.StreamEventImportCompleted, event.Type)
+			assert.JSONEq(t, `{"added":3}`, string(event.Data))
+		default:
+			t.Fatal("expected an event for user 1")
+		}
+		assert.Empty(t, theirs)
+
+		unsubscribe()
+		hub.Publish(1, models.StreamEventImportCompleted, map[string]int{"added": 1})
+		assert.Empty(t, mine)
+	})
+
+	t.Run("Publishes_Through_The_Backend", func(t *testing.T) {
+		backend := &recordingBackend{}
+		hub := handlers.NewNotificationHub(backend, zap.NewNop())
+		events, unsubscribe := hub.Subscribe(1)
+		defer unsubscribe()
+
+		hub.Publish(1, models.StreamEventNotification, models.Notification{ID: 4, Title: "Hello"})
+
+		require.Len(t, backend.published, 1)
+		assert.Equal(t, models.StreamEventNotification, backend.published[0].Type)
+		assert.Len(t, events, 1)
+	})
+}
+
+func TestNotificationStreams(t *testing.T) {
+	router, _ := setup()
+	streams := router.Group("/api/v1/notifications")
+	streams.Use(middlewares.StreamAuthMiddleware())
+	streams.GET("/stream", handlers.StreamNotifications)
+	streams.GET("/ws", handlers.NotificationWebSocket)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	hub := handlers.NewNotificationHub(handlers.NewLocalStreamBackend(), zap.NewNop())
+	handlers.UseNotificationHub(hub)
+	defer handlers.UseNotificationHub(handlers.NewNotificationHub(handlers.NewLocalStreamBackend(), zap.NewNop()))
+
+	t.Run("Server_Sent_Events", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/notifications/stream", nil)
+		req.Header.Set("Authorization", "Bearer "+streamToken(t, 1))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		// The stream is subscribed once the headers are out
+		hub.Publish(2, models.StreamEventNotification, map[string]string{"title": "Not yours"})
+		hub.Publish(1, models.StreamEventNotification, map[string]string{"title": "Budget at 80%"})
+
+		reader := bufio.NewReader(resp.Body)
+		var lines []string
+		for len(lines) < 2 {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		assert.Equal(t, []string{"event:notification", `data:{"title":"Budget at 80%"}`}, lines)
+	})
+
+	t.Run("WebSocket_With_Query_Token", func(t *testing.T) {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/notifications/ws?access_token=" + streamToken(t, 1)
+		conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		// The handler subscribes right after the upgrade; publish until the event comes through
+		received := make(chan models.StreamEvent, 1)
+		go func() {
+			var event models.StreamEvent
+			if conn.ReadJSON(&event) == nil {
+				received <- event
+			}
+		}()
+		deadline := time.After(2 * time.Second)
+		var event models.StreamEvent
+		for waiting := true; waiting; {
+			hub.Publish(1, models.StreamEventImportCompleted, map[string]int{"added": 2})
+			select {
+			case event = <-received:
+				waiting = false
+			case <-time.After(20 * time.Millisecond):
+			case <-deadline:
+				t.Fatal("no event received")
+			}
+		}
+		assert.Equal(t, models.StreamEventImportCompleted, event.Type)
+		assert.JSONEq(t, `{"added":2}`, string(event.Data))
+	})
+
+	t.Run("Token_Stays_Out_Of_The_Request_Log", func(t *testing.T) {
+		var accessLog bytes.Buffer
+		logged, _ := setup()
+		logged.Use(middlewares.RequestLogger(&accessLog))
+		logged.Use(middlewares.StreamAuthMiddleware())
+		logged.GET("/api/v1/notifications/stream", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		token := streamToken(t, 1)
+		req, _ := http.NewRequest("GET", "/api/v1/notifications/stream?access_token="+token, nil)
+		w := httptest.NewRecorder()
+		logged.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, accessLog.String(), "/api/v1/notifications/stream")
+		assert.NotContains(t, accessLog.String(), token)
+		assert.NotContains(t, accessLog.String(), "access_token")
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/notifications/stream", "/api/v1/notifications/ws?access_token=not-a-token"} {
+			resp, err := http.Get(server.URL + path)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, path)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+var notificationColumns = []string{"id", "user_id", "type", "severity", "title", "message", "payload", "read_at", "archived_at", "created_at"}
+
+const unreadCountSQL = "SELECT count(*) FROM `notifications` WHERE user_id = ? AND read_at IS NULL AND archived_at IS NULL"
+
+func TestNotify(t *testing.T) {
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	t.Run("Stores_Payload_As_JSON", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `notifications`")).
+			WithArgs(uint(1), models.NotificationTypeBudget, models.NotificationSeverityWarning, "Groceries at 80%",
+				"You have spent 80% of your Groceries budget.", []byte(`{"budgetId":3}`), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(7, 1))
+		mock.ExpectCommit()
+
+		notification, err := handlers.Notify(1, models.NotificationTypeBudget, models.NotificationSeverityWarning,
+			"Groceries at 80%", "You have spent 80% of your Groceries budget.", map[string]uint{"budgetId": 3})
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), notification.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Anomaly_Listener", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `notifications`")).
+				WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
+			mock.ExpectCommit()
+		}
+
+		handlers.NotifyAnomalies(1, []models.Anomaly{
+			{Kind: models.AnomalyKindOutlier, Message: "$900.00 at Store is unusually large"},
+			{Kind: models.AnomalyKindDuplicate, Message: "$12.00 at Cafe looks like a duplicate"},
+		}, zap.NewNop())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestNotificationInbox(t *testing.T) {
+	router, _ := setup()
+	withUser := func(handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", uint(1))
+			handler(c)
+		}
+	}
+	router.GET("/api/v1/notifications", withUser(handlers.GetNotifications))
+	router.GET("/api/v1/notifications/unread-count", withUser(handlers.GetUnreadNotificationCount))
+	router.POST("/api/v1/notifications/read-all", withUser(handlers.MarkAllNotificationsRead))
+	router.POST("/api/v1/notifications/:id/read", withUser(handlers.MarkNotificationRead))
+	router.POST("/api/v1/notifications/:id/archive", withUser(handlers.ArchiveNotification))
+	router.DELETE("/api/v1/notifications/:id", withUser(handlers.DeleteNotification))
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	created := time.Date(2024, 6, 3, 9, 0, 0, 0, time.Local)
+	notificationRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows(notificationColumns).
+			AddRow(5, 1, models.NotificationTypeSecurity, models.NotificationSeverityWarning, "Your password was changed", "", nil, nil, nil, created)
+	}
+
+	t.Run("List", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `notifications` WHERE user_id = ? AND archived_at IS NULL AND read_at IS NULL ORDER BY created_at DESC, id DESC LIMIT ?")).
+			WithArgs(uint(1), 10).
+			WillReturnRows(notificationRow())
+		mock.ExpectQuery(regexp.QuoteMeta(unreadCountSQL)).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		req, _ := http.NewRequest("GET", "/api/v1/notifications?unread=true&limit=10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response struct {
+			Notifications []models.Notification `json:"notifications"`
+			UnreadCount   int64                 `json:"unreadCount"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Notifications, 1)
+		assert.Equal(t, "Your password was changed", response.Notifications[0].Title)
+		assert.Nil(t, response.Notifications[0].ReadAt)
+		assert.Equal(t, int64(1), response.UnreadCount)
+	})
+
+	t.Run("Unread_Count", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta(unreadCountSQL)).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		req, _ := http.NewRequest("GET", "/api/v1/notifications/unread-count", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"unreadCount":3}`, w.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Mark_Read", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `notifications` WHERE id = ? AND user_id = ?")).
+			WithArgs("5", uint(1), 1).
+			WillReturnRows(notificationRow())
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `notifications` SET `read_at`=?,`updated_at`=? WHERE `id` = ?")).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta(unreadCountSQL)).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		req, _ := http.NewRequest("POST", "/api/v1/notifications/5/read", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response struct {
+			Notification models.Notification `json:"notification"`
+			UnreadCount  int64               `json:"unreadCount"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotNil(t, response.Notification.ReadAt)
+		assert.Equal(t, int64(0), response.UnreadCount)
+	})
+
+	t.Run("Mark_All_Read", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `notifications` SET `read_at`=?,`updated_at`=? WHERE user_id = ? AND read_at IS NULL")).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectCommit()
+
+		req, _ := http.NewRequest("POST", "/api/v1/notifications/read-all", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"Notifications marked as read","updated":4,"unreadCount":0}`, w.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `notifications` WHERE id = ? AND user_id = ?")).
+			WithArgs("5", uint(1), 1).
+			WillReturnRows(notificationRow())
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `notifications` SET `archived_at`=?,`updated_at`=? WHERE `id` = ?")).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta(unreadCountSQL)).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		req, _ := http.NewRequest("POST", "/api/v1/notifications/5/archive", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `notifications` WHERE id = ? AND user_id = ?")).
+			WithArgs("5", uint(1), 1).
+			WillReturnRows(notificationRow())
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `notifications` WHERE `notifications`.`id` = ?")).
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta(unreadCountSQL)).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/notifications/5", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"Notification deleted successfully","unreadCount":2}`, w.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not_Found", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `notifications` WHERE id = ? AND user_id = ?")).
+			WithArgs("9", uint(1), 1).
+			WillReturnRows(sqlmock.NewRows(notificationColumns))
+
+		req, _ := http.NewRequest("POST", "/api/v1/notifications/9/read", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid_Parameters", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=500", "limit=many", "unread=maybe", "archived=2"} {
+			req, _ := http.NewRequest("GET", "/api/v1/notifications?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package handlers_test
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+)
+
+func TestParseRRule(t *testing.T) {
+	t.Run("Monthly_With_Count", func(t *testing.T) {
+		freq, interval, count, until, err := handlers.TestableParseRRule("FREQ=MONTHLY;INTERVAL=2;COUNT=12")
+		require.NoError(t, err)
+		assert.Equal(t, "monthly", freq)
+		assert.Equal(t, 2, interval)
+		require.NotNil(t, count)
+		assert.Equal(t, 12, *count)
+		assert.Nil(t, until)
+	})
+
+	t.Run("Weekly_With_Until", func(t *testing.T) {
+		freq, interval, count, until, err := handlers.TestableParseRRule("RRULE:FREQ=WEEKLY;UNTIL=20241231T000000Z")
+		require.NoError(t, err)
+		assert.Equal(t, "weekly", freq)
+		assert.Equal(t, 1, interval)
+		assert.Nil(t, count)
+		require.NotNil(t, until)
+		assert.Equal(t, "2024-12-31", until.Format("2006-01-02"))
+	})
+
+	invalid := []string{
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=abc",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ",
+	}
+	for _, rule := range invalid {
+		_, _, _, _, err := handlers.TestableParseRRule(rule)
+		assert.Error(t, err, "rule %q should be rejected", rule)
+	}
+}
+
+func TestOccurrenceAt(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+
+	testCases := []struct {
+		name      string
+		start     time.Time
+		frequency string
+		interval  int
+		n         int
+		expected  time.Time
+	}{
+		{"Daily", date(2024, 1, 30), "daily", 1, 3, date(2024, 2, 2)},
+		{"Biweekly", date(2024, 1, 1), "weekly", 2, 2, date(2024, 1, 29)},
+		{"Monthly_Clamps_To_Leap_February", date(2024, 1, 31), "monthly", 1, 1, date(2024, 2, 29)},
+		{"Monthly_Does_Not_Drift_After_Clamp", date(2024, 1, 31), "monthly", 1, 2, date(2024, 3, 31)},
+		{"Quarterly_Via_Interval", date(2024, 11, 30), "monthly", 3, 1, date(2025, 2, 28)},
+		{"Yearly_From_Leap_Day", date(2024, 2, 29), "yearly", 1, 1, date(2025, 2, 28)},
+		{"First_Occurrence_Is_Start", date(2024, 5, 15), "monthly", 1, 0, date(2024, 5, 15)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := handlers.TestableOccurrenceAt(tc.start, tc.frequency, tc.interval, tc.n)
+			assert.Equal(t, tc.expected.Format("2006-01-02"), got.Format("2006-01-02"))
+		})
+	}
+}
+
+func TestCreateRecurringTransaction(t *testing.T) {
+	router, _ := setup()
+	router.POST("/api/v1/recurring-transactions", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.CreateRecurringTransaction(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	t.Run("Successful_Creation_With_RRule", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `recurring_transactions`")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		body := `{"amount": 1200, "description": "Rent", "startDate": "2024-01-01", "rrule": "FREQ=MONTHLY;COUNT=12"}`
+		req, _ := http.NewRequest("POST", "/api/v1/recurring-transactions", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		recurring := response["recurringTransaction"].(map[string]interface{})
+		assert.Equal(t, "monthly", recurring["Frequency"])
+		assert.Equal(t, "expense", recurring["Type"])
+		assert.Equal(t, true, recurring["Active"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	badRequests := []struct {
+		name  string
+		body  string
+		error string
+	}{
+		{"Missing_Schedule", `{"amount": 10, "startDate": "2024-01-01"}`, "Either frequency or rrule is required"},
+		{"Invalid_RRule", `{"amount": 10, "startDate": "2024-01-01", "rrule": "FREQ=HOURLY"}`, "unsupported rrule frequency"},
+		{"End_Before_Start", `{"amount": 10, "startDate": "2024-01-01", "frequency": "weekly", "endDate": "2023-12-01"}`, "End date must be after start date"},
+		{"Invalid_Frequency", `{"amount": 10, "startDate": "2024-01-01", "frequency": "hourly"}`, "Frequency"},
+	}
+	for _, tc := range badRequests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock, err := setupDBMock()
+			require.NoError(t, err)
+
+			req, _ := http.NewRequest("POST", "/api/v1/recurring-transactions", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Contains(t, response["error"], tc.error)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteRecurringTransaction(t *testing.T) {
+	router, _ := setup()
+	router.DELETE("/api/v1/recurring-transactions/:id", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.DeleteRecurringTransaction(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	testCases := []struct {
+		name         string
+		rowsAffected int64
+		expectedCode int
+	}{
+		{"Deleted", 1, http.StatusOK},
+		{"Not_Found_Or_Not_Owned", 0, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock, err := setupDBMock()
+			require.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `recurring_transactions` SET `deleted_at`=? WHERE (id = ? AND user_id = ?)")).
+				WithArgs(sqlmock.AnyArg(), "5", uint(1)).
+				WillReturnResult(sqlmock.NewResult(0, tc.rowsAffected))
+			mock.ExpectCommit()
+
+			req, _ := http.NewRequest("DELETE", "/api/v1/recurring-transactions/5", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMaterializeRecurringTransactions(t *testing.T) {
+	_, logger := setup()
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)
+	columns := []string{"id", "user_id", "category_id", "type", "amount", "description", "frequency", "repeat_interval",
+		"start_date", "end_date", "max_occurrences", "occurrences_generated", "next_occurrence", "active"}
+	row := []driver.Value{7, 1, nil, "expense", 15.99, "Streaming", "monthly", 1, start, nil, 2, 0, start, true}
+
+	// 1. Due schedules
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recurring_transactions` WHERE (active = ? AND next_occurrence <= ?)")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(row...))
+
+	// 2. Lock the schedule, insert both due occurrences and advance it
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recurring_transactions` WHERE `recurring_transactions`.`id` = ?")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(row...))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")).
+		WithArgs(uint(1), nil, "expense", 15.99, "Streaming", start, nil, uint(7), "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(100, 1))
+	// The February occurrence already exists (e.g. created before a restart), so nothing is inserted
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")).
+		WithArgs(uint(1), nil, "expense", 15.99, "Streaming", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local), nil, uint(7), "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `recurring_transactions` SET")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// 3. Budgets touched by the single new transaction
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	created, err := handlers.MaterializeRecurringTransactions(time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local), logger)
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
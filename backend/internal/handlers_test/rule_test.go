@@ -0,0 +1,306 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+var ruleColumns = []string{"id", "user_id", "category_id", "name", "priority", "active",
+	"description_match", "description_pattern", "min_amount", "max_amount", "weekdays"}
+
+// ruleQuery matches the lookup of a user's active rules
+var ruleQuery = regexp.QuoteMeta("SELECT * FROM `category_rules` WHERE (user_id = ? AND active = ?)")
+
+func TestCategoryRuleMatching(t *testing.T) {
+	amount := func(v float64) *float64 { return &v }
+	// 2024-03-02 is a Saturday
+	saturday := time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local)
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		rule    models.CategoryRule
+		tx      models.Transaction
+		matches bool
+	}{
+		{
+			name:    "Contains_Ignores_Case",
+			rule:    models.CategoryRule{DescriptionMatch: models.RuleMatchContains, DescriptionPattern: "uber"},
+			tx:      models.Transaction{Description: "UBER *TRIP 8812", TransactionDate: monday},
+			matches: true,
+		},
+		{
+			name:    "Contains_No_Match",
+			rule:    models.CategoryRule{DescriptionMatch: models.RuleMatchContains, DescriptionPattern: "uber eats"},
+			tx:      models.Transaction{Description: "UBER *TRIP 8812", TransactionDate: monday},
+			matches: false,
+		},
+		{
+			name:    "Regex",
+			rule:    models.CategoryRule{DescriptionMatch: models.RuleMatchRegex, DescriptionPattern: `^amzn\s+mktp`},
+			tx:      models.Transaction{Description: "AMZN Mktp US*2K4", TransactionDate: monday},
+			matches: true,
+		},
+		{
+			name:    "Amount_Range_Inclusive",
+			rule:    models.CategoryRule{MinAmount: amount(10), MaxAmount: amount(20)},
+			tx:      models.Transaction{Amount: 20, TransactionDate: monday},
+			matches: true,
+		},
+		{
+			name:    "Amount_Below_Range",
+			rule:    models.CategoryRule{MinAmount: amount(10)},
+			tx:      models.Transaction{Amount: 9.99, TransactionDate: monday},
+			matches: false,
+		},
+		{
+			name:    "Weekday",
+			rule:    models.CategoryRule{Weekdays: "sat,sun"},
+			tx:      models.Transaction{TransactionDate: saturday},
+			matches: true,
+		},
+		{
+			name: "All_Conditions_Must_Match",
+			rule: models.CategoryRule{DescriptionMatch: models.RuleMatchContains, DescriptionPattern: "bar",
+				MaxAmount: amount(50), Weekdays: "fri,sat"},
+			tx:      models.Transaction{Description: "Corner Bar", Amount: 32, TransactionDate: monday},
+			matches: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := handlers.TestableMatchCategoryRule(tt.rule, tt.tx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, matches)
+		})
+	}
+
+	t.Run("Invalid_Rules", func(t *testing.T) {
+		for _, rule := range []models.CategoryRule{
+			{},
+			{DescriptionMatch: models.RuleMatchRegex, DescriptionPattern: "(unclosed"},
+			{DescriptionMatch: models.RuleMatchContains},
+			{MinAmount: amount(50), MaxAmount: amount(10)},
+			{Weekdays: "someday"},
+		} {
+			_, err := handlers.TestableMatchCategoryRule(rule, models.Transaction{})
+			assert.Error(t, err, "%+v", rule)
+		}
+	})
+}
+
+func TestCreateCategoryRule(t *testing.T) {
+	router, _ := setup()
+	router.POST("/api/v1/category-rules", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.CreateCategoryRule(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	findCategory := regexp.QuoteMeta("SELECT * FROM `categories` WHERE (id = ? AND user_id = ?)")
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/category-rules", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(findCategory).
+			WithArgs(uint(9), uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(9, 1, "Dining out"))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `category_rules`")).
+			WithArgs(uint(1), uint(9), "Weekend bars", 5, true, "regex", `\bbar\b`, nil, 80.0, "sat,sun",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		w := post(`{"name": "Weekend bars", "categoryId": 9, "priority": 5, "descriptionMatch": "regex",
+			"descriptionPattern": "\\bbar\\b", "maxAmount": 80, "weekdays": ["Saturday", "sun", "sat"]}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Inactive", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(findCategory).
+			WithArgs(uint(9), uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(9, 1, "Dining out"))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `category_rules`")).
+			WithArgs(uint(1), uint(9), "Paused", 0, false, "contains", "pub", nil, nil, "",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		w := post(`{"name": "Paused", "categoryId": 9, "active": false, "descriptionMatch": "contains", "descriptionPattern": "pub"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid_Regex", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(findCategory).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(9, 1, "Dining out"))
+
+		w := post(`{"categoryId": 9, "descriptionMatch": "regex", "descriptionPattern": "[a-"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid descriptionPattern")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown_Category", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(findCategory).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		w := post(`{"categoryId": 99, "descriptionMatch": "contains", "descriptionPattern": "uber"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTestCategoryRule(t *testing.T) {
+	router, _ := setup()
+	router.POST("/api/v1/category-rules/test", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.TestCategoryRule(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE (id = ? AND user_id = ?)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(5, 1, "Transport"))
+	date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE user_id = ? AND transaction_date >= ? AND amount >= ?")).
+		WithArgs(uint(1), "2024-01-01", 5.0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category_id", "type", "amount", "description", "transaction_date"}).
+			AddRow(1, 1, nil, "expense", 14.20, "UBER *TRIP", date).
+			AddRow(2, 1, 8, "expense", 32.00, "Uber Eats", date).
+			AddRow(3, 1, 5, "expense", 18.00, "UBER *TRIP", date).
+			AddRow(4, 1, nil, "expense", 40.00, "Lyft", date))
+
+	req, _ := http.NewRequest("POST", "/api/v1/category-rules/test?startDate=2024-01-01", strings.NewReader(
+		`{"categoryId": 5, "descriptionMatch": "contains", "descriptionPattern": "uber", "minAmount": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Matched       int                  `json:"matched"`
+		Uncategorized int                  `json:"uncategorized"`
+		OtherCategory int                  `json:"otherCategory"`
+		Transactions  []models.Transaction `json:"transactions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 3, response.Matched)
+	assert.Equal(t, 1, response.Uncategorized)
+	assert.Equal(t, 1, response.OtherCategory)
+	assert.Len(t, response.Transactions, 3)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyCategoryRules(t *testing.T) {
+	router, _ := setup()
+	router.POST("/api/v1/category-rules/apply", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.ApplyCategoryRules(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	t.Run("Categorizes_Uncategorized_Rows", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(ruleQuery).
+			WithArgs(uint(1), true).
+			WillReturnRows(sqlmock.NewRows(ruleColumns).
+				AddRow(1, 1, 5, "Rides", 0, true, "contains", "uber", nil, nil, "").
+				AddRow(2, 1, 6, "Coffee", 0, true, "regex", "starbucks|blue bottle", nil, 10.0, ""))
+		date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND category_id IS NULL)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category_id", "type", "amount", "description", "transaction_date"}).
+				AddRow(10, 1, nil, "expense", 14.20, "UBER *TRIP", date).
+				AddRow(11, 1, nil, "expense", 4.50, "Starbucks 221", date).
+				AddRow(12, 1, nil, "expense", 22.00, "UBER *TRIP", date).
+				AddRow(13, 1, nil, "expense", 60.00, "Hardware store", date))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `transactions` SET `category_id`=?,`updated_at`=? WHERE (user_id = ? AND id IN (?,?) AND category_id IS NULL)")).
+			WithArgs(uint(5), sqlmock.AnyArg(), uint(1), uint(10), uint(12)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `transactions` SET `category_id`=?,`updated_at`=? WHERE (user_id = ? AND id IN (?) AND category_id IS NULL)")).
+			WithArgs(uint(6), sqlmock.AnyArg(), uint(1), uint(11)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date <= ? AND end_date >= ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		req, _ := http.NewRequest("POST", "/api/v1/category-rules/apply", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Updated int            `json:"updated"`
+			Rules   map[string]int `json:"rules"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Updated)
+		assert.Equal(t, map[string]int{"1": 2, "2": 1}, response.Rules)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("No_Rules", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(ruleQuery).WillReturnRows(sqlmock.NewRows(ruleColumns))
+
+		req, _ := http.NewRequest("POST", "/api/v1/category-rules/apply", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,139 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestSavingsSeries(t *testing.T) {
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(2024, m, d, 0, 0, 0, 0, time.Local)
+	}
+	tx := func(txType string, amount float64, when time.Time) models.Transaction {
+		return models.Transaction{Type: txType, Amount: amount, TransactionDate: when}
+	}
+
+	t.Run("Monthly", func(t *testing.T) {
+		report := handlers.TestableSavingsSeries(1000, date(3, 15), date(5, 10), models.GranularityMonth, []models.Transaction{
+			tx(models.TransactionTypeIncome, 3000, date(3, 20)),
+			tx(models.TransactionTypeExpense, 1000, date(3, 25)),
+			tx(models.TransactionTypeExpense, 500, date(4, 1)),
+			tx(models.TransactionTypeRefund, 100, date(4, 5)),
+			// Moving money between the user's accounts doesn't change net worth
+			tx(models.TransactionTypeTransfer, 200, date(4, 10)),
+			tx(models.TransactionTypeIncome, 3000, date(5, 2)),
+			tx(models.TransactionTypeExpense, 2400, date(5, 3)),
+		})
+
+		require.Len(t, report.Series, 3)
+		march, april, may := report.Series[0], report.Series[1], report.Series[2]
+
+		assert.Equal(t, "2024-03", march.Period)
+		assert.Equal(t, "2024-03-15", march.StartDate)
+		assert.Equal(t, "2024-03-31", march.EndDate)
+		assert.Equal(t, 2000.0, march.Savings)
+		assert.Equal(t, 66.67, *march.SavingsRate)
+		assert.Equal(t, 3000.0, march.NetWorth)
+
+		// Without income there is no rate
+		assert.Equal(t, 400.0, april.Expenses)
+		assert.Equal(t, -400.0, april.Savings)
+		assert.Nil(t, april.SavingsRate)
+		assert.Equal(t, 2600.0, april.NetWorth)
+
+		assert.Equal(t, "2024-05-10", may.EndDate)
+		assert.Equal(t, 20.0, *may.SavingsRate)
+		assert.Equal(t, 3200.0, may.NetWorth)
+
+		assert.Equal(t, 1000.0, report.OpeningNetWorth)
+		assert.Equal(t, 6000.0, report.Income)
+		assert.Equal(t, 3800.0, report.Expenses)
+		assert.Equal(t, 2200.0, report.Savings)
+		assert.Equal(t, 36.67, *report.SavingsRate)
+		assert.Equal(t, 3200.0, report.ClosingNetWorth)
+	})
+
+	t.Run("Weekly", func(t *testing.T) {
+		// June 5th is a Wednesday
+		report := handlers.TestableSavingsSeries(0, date(6, 5), date(6, 18), models.GranularityWeek, []models.Transaction{
+			tx(models.TransactionTypeExpense, 50, date(6, 9)),
+			tx(models.TransactionTypeIncome, 100, date(6, 10)),
+		})
+
+		require.Len(t, report.Series, 3)
+		assert.Equal(t, models.SavingsPoint{Period: "2024-06-03", StartDate: "2024-06-05", EndDate: "2024-06-09",
+			Expenses: 50, Savings: -50, NetWorth: -50}, report.Series[0])
+		assert.Equal(t, "2024-06-10", report.Series[1].Period)
+		assert.Equal(t, 50.0, report.Series[1].NetWorth)
+		assert.Equal(t, "2024-06-17", report.Series[2].StartDate)
+		assert.Equal(t, "2024-06-18", report.Series[2].EndDate)
+		assert.Equal(t, 50.0, report.Series[2].NetWorth)
+	})
+}
+
+func TestGetSavingsSeries(t *testing.T) {
+	router, _ := setup()
+	router.GET("/api/v1/features/analytics/savings", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.GetSavingsSeries(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	t.Run("Success", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+		end := time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN type IN ('income', 'refund') THEN amount WHEN type = 'expense' THEN -amount ELSE 0 END), 0) as total FROM `transactions` WHERE (user_id = ? AND transaction_date < ?)")).
+			WithArgs(uint(1), start).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(500.00))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT transaction_date, type, COALESCE(SUM(amount), 0) as total FROM `transactions` WHERE (user_id = ? AND transaction_date >= ? AND transaction_date <= ?)")).
+			WithArgs(uint(1), start, end).
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_date", "type", "total"}).
+				AddRow(time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local), "income", 2000.00).
+				AddRow(time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local), "expense", 1500.00).
+				AddRow(time.Date(2024, 2, 14, 0, 0, 0, 0, time.Local), "expense", 300.00))
+
+		req, _ := http.NewRequest("GET", "/api/v1/features/analytics/savings?startDate=2024-01-01&endDate=2024-02-29&openingBalance=1000", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.SavingsReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.GranularityMonth, response.Granularity)
+		assert.Equal(t, 1500.0, response.OpeningNetWorth)
+		require.Len(t, response.Series, 2)
+		assert.Equal(t, 25.0, *response.Series[0].SavingsRate)
+		assert.Equal(t, 2000.0, response.Series[0].NetWorth)
+		assert.Equal(t, 1700.0, response.ClosingNetWorth)
+	})
+
+	t.Run("Invalid_Parameters", func(t *testing.T) {
+		for _, query := range []string{"granularity=day", "startDate=2024-02-30", "openingBalance=plenty"} {
+			req, _ := http.NewRequest("GET", "/api/v1/features/analytics/savings?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/handlers"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+func TestSimulateScenario(t *testing.T) {
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(2024, m, d, 0, 0, 0, 0, time.Local)
+	}
+	restaurants, streaming, coffee := uint(3), uint(4), uint(5)
+	streamingID := uint(9)
+
+	// Restaurants 200, its Coffee subcategory 50 and a 15 streaming subscription, every month from March to May
+	var transactions []models.Transaction
+	for m := time.March; m <= time.May; m++ {
+		transactions = append(transactions,
+			models.Transaction{CategoryID: &restaurants, Type: models.TransactionTypeExpense, Amount: 200, TransactionDate: date(m, 5)},
+			models.Transaction{CategoryID: &coffee, Type: models.TransactionTypeExpense, Amount: 50, TransactionDate: date(m, 6)},
+			models.Transaction{CategoryID: &streaming, Type: models.TransactionTypeExpense, Amount: 15, TransactionDate: date(m, 10), RecurringTransactionID: &streamingID})
+	}
+	parents := map[uint]*uint{restaurants: nil, streaming: nil, coffee: &restaurants}
+	recurring := []models.RecurringTransaction{{ID: streamingID, CategoryID: &streaming, Type: models.TransactionTypeExpense, Amount: 15,
+		Frequency: models.FrequencyMonthly, Interval: 1, StartDate: date(1, 10), OccurrencesGenerated: 5, Active: true}}
+	budgets := []models.Budget{
+		{ID: 1, CategoryID: &restaurants, LimitAmount: 300, StartDate: date(7, 1), EndDate: date(7, 31)},
+		{ID: 2, LimitAmount: 100, StartDate: date(7, 1), EndDate: date(7, 15)},
+	}
+
+	july := date(7, 1)
+	changes := []models.ScenarioChange{
+		{Kind: models.ScenarioChangeCancelRecurring, RecurringTransactionID: &streamingID},
+		{Kind: models.ScenarioChangeScaleCategory, CategoryID: &restaurants, Percent: -20},
+		{Kind: models.ScenarioChangeAddRecurring, Amount: 400, StartDate: &july, Description: "Car payment"},
+	}
+
+	baseline, scenario := handlers.TestableSimulateScenario(transactions, date(6, 1), 3, parents, recurring, budgets, changes)
+
+	// The baseline is the plain forecast: 265 a month
+	assert.Equal(t, 795.0, baseline.TotalSpend)
+	assert.Equal(t, "2024-06", baseline.TotalForecast[0].Month)
+	require.Len(t, baseline.Budgets, 2)
+	assert.Equal(t, models.BudgetOutcome{BudgetID: 1, CategoryID: &restaurants, StartDate: "2024-07-01", EndDate: "2024-07-31",
+		Limit: 300, ProjectedSpend: 250, Remaining: 50}, baseline.Budgets[0])
+	assert.Equal(t, 0.0, baseline.Budgets[1].ProjectedSpend)
+	assert.Equal(t, 0, baseline.BudgetsExceeded)
+
+	// No streaming, a fifth less on restaurants and coffee, and the car payment from July
+	var totals []float64
+	for _, point := range scenario.Outcome.TotalForecast {
+		totals = append(totals, point.Amount)
+	}
+	assert.Equal(t, []float64{200, 600, 600}, totals)
+	assert.Equal(t, 1400.0, scenario.Outcome.TotalSpend)
+	assert.Equal(t, 605.0, scenario.Difference)
+
+	require.Len(t, scenario.Outcome.CategoryForecasts, 3)
+	assert.Equal(t, 160.0, scenario.Outcome.CategoryForecasts[0].Forecast[0].Amount)
+	assert.Equal(t, 0.0, scenario.Outcome.CategoryForecasts[1].Forecast[0].Amount)
+	assert.Equal(t, 40.0, scenario.Outcome.CategoryForecasts[2].Forecast[0].Amount)
+
+	// The uncategorized car payment lands in the global budget: 400 for 15 of July's 31 days
+	assert.Equal(t, 200.0, scenario.Outcome.Budgets[0].ProjectedSpend)
+	assert.Equal(t, 193.55, scenario.Outcome.Budgets[1].ProjectedSpend)
+	assert.True(t, scenario.Outcome.Budgets[1].Exceeded)
+	assert.Equal(t, 1, scenario.Outcome.BudgetsExceeded)
+
+	// Simulating leaves the baseline alone
+	again, _ := handlers.TestableSimulateScenario(transactions, date(6, 1), 3, parents, recurring, budgets, nil)
+	assert.Equal(t, baseline, again)
+}
+
+func TestSimulateScenariosHandler(t *testing.T) {
+	router, _ := setup()
+	router.POST("/api/v1/scenarios/simulate", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.SimulateScenarios(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+
+	t.Run("Unsaved_Changes", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions` WHERE (user_id = ? AND type IN (?,?)) AND (transaction_date >= ? AND transaction_date < ?)")).
+			WithArgs(uint(1), models.TransactionTypeExpense, models.TransactionTypeRefund, june.AddDate(-3, 0, 0), june).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category_id", "type", "amount", "transaction_date"}).
+				AddRow(1, 1, nil, "expense", 100.00, june.AddDate(0, -1, 3)))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`parent_id` FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recurring_transactions` WHERE (user_id = ? AND active = ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets` WHERE (user_id = ? AND start_date >= ? AND end_date < ?)")).
+			WithArgs(uint(1), june, june.AddDate(0, 2, 0)).
+			WillReturnRows(sqlmock.NewRows(budgetColumns).
+				AddRow(1, 1, nil, 150.00, 150.00, june, june.AddDate(0, 1, -1), "", false, 0.00, nil, 0, false))
+
+		body := `{"monthsAhead": 2, "startDate": "2024-06-01T00:00:00Z",
+			"changes": [{"kind": "add_recurring", "amount": 80, "description": "Gym", "endDate": "2024-06-30"}]}`
+		req, _ := http.NewRequest("POST", "/api/v1/scenarios/simulate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.SimulationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.ForecastModelMean, response.Model)
+		assert.Equal(t, 200.0, response.Baseline.TotalSpend)
+		assert.False(t, response.Baseline.Budgets[0].Exceeded)
+
+		require.Len(t, response.Scenarios, 1)
+		assert.Nil(t, response.Scenarios[0].ScenarioID)
+		assert.Equal(t, 80.0, response.Scenarios[0].Difference)
+		assert.Equal(t, 180.0, response.Scenarios[0].Outcome.TotalForecast[0].Amount)
+		assert.Equal(t, 100.0, response.Scenarios[0].Outcome.TotalForecast[1].Amount)
+		assert.True(t, response.Scenarios[0].Outcome.Budgets[0].Exceeded)
+	})
+
+	t.Run("Saved_Scenario_Not_Found", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `scenarios` WHERE (id IN (?,?) AND user_id = ?)")).
+			WithArgs(uint(4), uint(5), uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(4, 1, "Frugal"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `scenario_changes` WHERE `scenario_changes`.`scenario_id` = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "scenario_id", "kind"}))
+
+		req, _ := http.NewRequest("POST", "/api/v1/scenarios/simulate", bytes.NewBufferString(`{"monthsAhead": 3, "scenarioIds": [4, 5]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Repeated_Scenario_ID", func(t *testing.T) {
+		mock, err := setupDBMock()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `scenarios` WHERE (id IN (?) AND user_id = ?)")).
+			WithArgs(uint(4), uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(4, 1, "Frugal"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `scenario_changes` WHERE `scenario_changes`.`scenario_id` = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "scenario_id", "kind"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `transactions`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category_id", "type", "amount", "transaction_date"}).
+				AddRow(1, 1, nil, "expense", 100.00, june.AddDate(0, -1, 3)))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`parent_id` FROM `categories` WHERE user_id = ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recurring_transactions` WHERE (user_id = ? AND active = ?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `budgets`")).
+			WillReturnRows(sqlmock.NewRows(budgetColumns))
+
+		body := `{"monthsAhead": 1, "startDate": "2024-06-01T00:00:00Z", "scenarioIds": [4, 4]}`
+		req, _ := http.NewRequest("POST", "/api/v1/scenarios/simulate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		var response models.SimulationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Scenarios, 1)
+		assert.Equal(t, "Frugal", response.Scenarios[0].Name)
+	})
+
+	t.Run("Invalid_Requests", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"Nothing to simulate": `{"monthsAhead": 3}`,
+			"Too many months":     `{"monthsAhead": 13, "scenarioIds": [1]}`,
+			"Unknown change":      `{"monthsAhead": 3, "changes": [{"kind": "win_lottery"}]}`,
+			"Missing amount":      `{"monthsAhead": 3, "changes": [{"kind": "add_recurring"}]}`,
+			"Missing category":    `{"monthsAhead": 3, "changes": [{"kind": "scale_category", "percent": -10}]}`,
+			"Cut over 100%":       `{"monthsAhead": 3, "changes": [{"kind": "scale_category", "categoryId": 3, "percent": -120}]}`,
+			"No change in scale":  `{"monthsAhead": 3, "changes": [{"kind": "scale_category", "categoryId": 3}]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				req, _ := http.NewRequest("POST", "/api/v1/scenarios/simulate", bytes.NewBufferString(body))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			})
+		}
+	})
+}
+
+func TestCreateScenario(t *testing.T) {
+	router, _ := setup()
+	router.POST("/api/v1/scenarios", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.CreateScenario(c)
+	})
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	mock, err := setupDBMock()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE (id = ? AND user_id = ?)")).
+		WithArgs(uint(3), uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow(3, 1, "Restaurants"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `scenarios`")).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `scenario_changes`")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	body := `{"name": " Eat in ", "changes": [{"kind": "scale_category", "categoryId": 3, "percent": -20, "startDate": "2024-03-01"}]}`
+	req, _ := http.NewRequest("POST", "/api/v1/scenarios", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	var response struct {
+		Scenario models.Scenario `json:"scenario"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, uint(7), response.Scenario.ID)
+	assert.Equal(t, "Eat in", response.Scenario.Name)
+	require.Len(t, response.Scenario.Changes, 1)
+	assert.Equal(t, -20.0, response.Scenario.Changes[0].Percent)
+	assert.Equal(t, "2024-03-01", response.Scenario.Changes[0].StartDate.Format("2006-01-02"))
+}
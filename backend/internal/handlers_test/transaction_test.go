@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -85,8 +86,8 @@ func TestCreateTransaction(t *testing.T) {
 	t.Run("Successfully_Create_Transaction", func(t *testing.T) {
 		// Setup mock expectations
 		mock.ExpectBegin()
-		mock.ExpectExec("INSERT INTO `transactions` \\(`user_id`,`category_id`,`amount`,`description`,`transaction_date`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").
-			WithArgs(uint(1), uint(1), 100.5, "Grocery shopping", testTime, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		mock.ExpectExec("INSERT INTO `transactions` \\(`user_id`,`category_id`,`type`,`amount`,`description`,`transaction_date`,`occurred_at`,`recurring_transaction_id`,`external_account`,`external_id`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").
+			WithArgs(uint(1), uint(1), "expense", 100.5, "Grocery shopping", testTime, nil, nil, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
@@ -116,11 +117,56 @@ func TestCreateTransaction(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("Successfully_Create_Transaction_With_Time", func(t *testing.T) {
+		// The time of day is stored next to the date budgets go by
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")).
+			WithArgs(uint(1), uint(1), "expense", 4.5, "Coffee", testTime, time.Date(2023, time.January, 1, 8, 30, 0, 0, loc),
+				nil, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		reqBody := `{
+			"categoryId": 1,
+			"amount": 4.5,
+			"description": "Coffee",
+			"transactionDate": "2023-01-01",
+			"transactionTime": "08:30"
+		}`
+
+		req, _ := http.NewRequest("POST", "/transactions", strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid_Transaction_Time", func(t *testing.T) {
+		reqBody := `{
+			"categoryId": 1,
+			"amount": 4.5,
+			"transactionDate": "2023-01-01",
+			"transactionTime": "8.30am"
+		}`
+
+		req, _ := http.NewRequest("POST", "/transactions", strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
 	t.Run("Successfully_Create_Uncategorized_Transaction", func(t *testing.T) {
-		// Setup mock expectations for uncategorized transaction
+		// Setup mock expectations for uncategorized transaction; no rule matches
+		mock.ExpectQuery(ruleQuery).
+			WithArgs(uint(1), true).
+			WillReturnRows(sqlmock.NewRows(ruleColumns))
 		mock.ExpectBegin()
-		mock.ExpectExec("INSERT INTO `transactions` \\(`user_id`,`category_id`,`amount`,`description`,`transaction_date`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").
-			WithArgs(uint(1), nil, 100.5, "Grocery shopping", testTime, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		mock.ExpectExec("INSERT INTO `transactions` \\(`user_id`,`category_id`,`type`,`amount`,`description`,`transaction_date`,`occurred_at`,`recurring_transaction_id`,`external_account`,`external_id`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").
+			WithArgs(uint(1), nil, "expense", 100.5, "Grocery shopping", testTime, nil, nil, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
@@ -149,6 +195,94 @@ func TestCreateTransaction(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("Applies_Category_Rule", func(t *testing.T) {
+		// The higher-priority rule wins even though both match
+		mock.ExpectQuery(ruleQuery+".*"+regexp.QuoteMeta("ORDER BY priority DESC, id ASC")).
+			WithArgs(uint(1), true).
+			WillReturnRows(sqlmock.NewRows(ruleColumns).
+				AddRow(4, 1, 7, "Whole Foods", 10, true, "regex", `whole\s*foods`, nil, nil, "").
+				AddRow(2, 1, 3, "Big purchases", 0, true, "", "", 100.0, nil, ""))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `transactions`")).
+			WithArgs(uint(1), uint(7), "expense", 120.0, "WHOLE FOODS #123", testTime, nil, nil, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+
+		reqBody := `{
+			"amount": 120,
+			"description": "WHOLE FOODS #123",
+			"transactionDate": "2023-01-01"
+		}`
+
+		req, _ := http.NewRequest("POST", "/transactions", strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		transaction := response["transaction"].(map[string]interface{})
+		assert.Equal(t, 7.0, transaction["CategoryID"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Successfully_Create_Income_Transaction", func(t *testing.T) {
+		mock.ExpectQuery(ruleQuery).
+			WillReturnRows(sqlmock.NewRows(ruleColumns))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `transactions` \\(`user_id`,`category_id`,`type`,`amount`,`description`,`transaction_date`,`occurred_at`,`recurring_transaction_id`,`external_account`,`external_id`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").
+			WithArgs(uint(1), nil, "income", 2500.0, "Salary", testTime, nil, nil, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		reqBody := `{
+			"type": "income",
+			"amount": 2500,
+			"description": "Salary",
+			"transactionDate": "2023-01-01"
+		}`
+
+		req, _ := http.NewRequest("POST", "/transactions", strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		transaction := response["transaction"].(map[string]interface{})
+		assert.Equal(t, "income", transaction["Type"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid_Transaction_Type", func(t *testing.T) {
+		reqBody := `{
+			"type": "gift",
+			"amount": 10,
+			"transactionDate": "2023-01-01"
+		}`
+
+		req, _ := http.NewRequest("POST", "/transactions", strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Contains(t, response["error"].(string), "Type")
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("Invalid_Request_Data", func(t *testing.T) {
 		// Invalid request (missing required field 'amount')
 		reqBody := `{
@@ -203,8 +337,8 @@ func TestCreateTransaction(t *testing.T) {
 
 	t.Run("Database_Error_On_Create", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectExec("INSERT INTO `transactions` \\(`user_id`,`category_id`,`amount`,`description`,`transaction_date`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").
-			WithArgs(uint(1), uint(1), 100.5, "Grocery shopping", testTime, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		mock.ExpectExec("INSERT INTO `transactions` \\(`user_id`,`category_id`,`type`,`amount`,`description`,`transaction_date`,`occurred_at`,`recurring_transaction_id`,`external_account`,`external_id`,`created_at`,`updated_at`,`deleted_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").
+			WithArgs(uint(1), uint(1), "expense", 100.5, "Grocery shopping", testTime, nil, nil, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
 			WillReturnError(errors.New("database error"))
 		mock.ExpectRollback()
 
@@ -253,13 +387,21 @@ func TestGetTransactions(t *testing.T) {
 	loc, _ := time.LoadLocation("Local")
 	testTime := time.Date(2023, time.January, 1, 0, 0, 0, 0, loc)
 
-	t.Run("Successfully_Get_All_Transactions", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "user_id", "category_id", "amount", "description", "transaction_date", "created_at", "updated_at", "deleted_at"}).
-			AddRow(1, 1, 1, 100.50, "Grocery shopping", testTime, testTime, testTime, nil)
+	totalsQuery := "^" + regexp.QuoteMeta("SELECT transactions.type, COUNT(*) AS count, COALESCE(SUM(transactions.amount), 0) AS total FROM `transactions` WHERE transactions.user_id = ? ")
+	pageQuery := "^" + regexp.QuoteMeta("SELECT * FROM `transactions` WHERE transactions.user_id = ? ")
+	txColumns := []string{"id", "user_id", "category_id", "type", "amount", "description", "transaction_date", "created_at", "updated_at", "deleted_at"}
 
-		// Expect the query to get all transactions for user 1
-		mock.ExpectQuery("^SELECT \\* FROM `transactions` WHERE user_id = \\? AND `transactions`.`deleted_at` IS NULL$").
+	t.Run("Successfully_Get_All_Transactions", func(t *testing.T) {
+		mock.ExpectQuery(totalsQuery + ".*" + regexp.QuoteMeta("AND `transactions`.`deleted_at` IS NULL GROUP BY `transactions`.`type`") + "$").
 			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "count", "total"}).AddRow("expense", 1, 100.50))
+
+		rows := sqlmock.NewRows(txColumns).
+			AddRow(1, 1, 1, "expense", 100.50, "Grocery shopping", testTime, testTime, testTime, nil)
+
+		// Newest first, id as tie-breaker, one extra row to detect a next page
+		mock.ExpectQuery(pageQuery+".*"+regexp.QuoteMeta("AND `transactions`.`deleted_at` IS NULL ORDER BY transactions.transaction_date desc, transactions.id desc LIMIT ?")+"$").
+			WithArgs(uint(1), 51).
 			WillReturnRows(rows)
 
 		// Perform request
@@ -278,17 +420,21 @@ func TestGetTransactions(t *testing.T) {
 		transactions, ok := response["transactions"].([]interface{})
 		assert.True(t, ok)
 		assert.Equal(t, 1, len(transactions))
+		assert.Equal(t, 1.0, response["total"])
+		assert.Nil(t, response["nextCursor"])
 
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("No_Transactions_Found", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "user_id", "category_id", "amount", "description", "transaction_date", "created_at", "updated_at", "deleted_at"})
+		mock.ExpectQuery(totalsQuery).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "count", "total"}))
 
 		// Expect the query to get all transactions for user 1 but return empty
-		mock.ExpectQuery("^SELECT \\* FROM `transactions` WHERE user_id = \\? AND `transactions`.`deleted_at` IS NULL$").
-			WithArgs(uint(1)).
-			WillReturnRows(rows)
+		mock.ExpectQuery(pageQuery).
+			WithArgs(uint(1), 51).
+			WillReturnRows(sqlmock.NewRows(txColumns))
 
 		// Perform request
 		req, _ := http.NewRequest("GET", "/transactions", nil)
@@ -306,13 +452,153 @@ func TestGetTransactions(t *testing.T) {
 		transactions, ok := response["transactions"].([]interface{})
 		assert.True(t, ok)
 		assert.Equal(t, 0, len(transactions))
+		assert.Equal(t, 0.0, response["total"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Filter_By_Type_With_Summary", func(t *testing.T) {
+		mock.ExpectQuery(totalsQuery+".*"+regexp.QuoteMeta("AND transactions.type = ?")).
+			WithArgs(uint(1), "income").
+			WillReturnRows(sqlmock.NewRows([]string{"type", "count", "total"}).AddRow("income", 1, 3000.00))
+
+		rows := sqlmock.NewRows(txColumns).
+			AddRow(1, 1, nil, "income", 3000.00, "Salary", testTime, testTime, testTime, nil)
+
+		mock.ExpectQuery(pageQuery+".*"+regexp.QuoteMeta("AND transactions.type = ?")).
+			WithArgs(uint(1), "income", 51).
+			WillReturnRows(rows)
+
+		req, _ := http.NewRequest("GET", "/transactions?type=income", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+
+		summary := response["summary"].(map[string]interface{})
+		assert.Equal(t, 3000.0, summary["income"])
+		assert.Equal(t, 0.0, summary["expenses"])
+		assert.Equal(t, 3000.0, summary["netCashFlow"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Summary_Covers_All_Pages", func(t *testing.T) {
+		mock.ExpectQuery(totalsQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "count", "total"}).
+				AddRow("income", 1, 3000.00).
+				AddRow("expense", 4, 120.00).
+				AddRow("refund", 1, 20.00))
+
+		mock.ExpectQuery(pageQuery).
+			WithArgs(uint(1), 3).
+			WillReturnRows(sqlmock.NewRows(txColumns).
+				AddRow(1, 1, nil, "income", 3000.00, "Salary", testTime, testTime, testTime, nil).
+				AddRow(2, 1, 1, "expense", 30.00, "Groceries", testTime, testTime, testTime, nil).
+				AddRow(3, 1, 1, "expense", 30.00, "Groceries", testTime, testTime, testTime, nil))
+
+		req, _ := http.NewRequest("GET", "/transactions?limit=2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Len(t, response["transactions"], 2)
+		assert.Equal(t, 6.0, response["total"])
+		assert.NotEmpty(t, response["nextCursor"])
+
+		summary := response["summary"].(map[string]interface{})
+		assert.Equal(t, 120.0, summary["expenses"])
+		assert.Equal(t, 100.0, summary["netExpenses"])
+		assert.Equal(t, 2900.0, summary["netCashFlow"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Cursor_Continues_After_Last_Row", func(t *testing.T) {
+		// First page by amount ascending ends on transaction 7 with amount 42.5
+		mock.ExpectQuery(totalsQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "count", "total"}).AddRow("expense", 3, 127.50))
+		mock.ExpectQuery(pageQuery+".*"+regexp.QuoteMeta("ORDER BY transactions.amount asc, transactions.id asc LIMIT ?")).
+			WithArgs(uint(1), 2).
+			WillReturnRows(sqlmock.NewRows(txColumns).
+				AddRow(7, 1, nil, "expense", 42.50, "Books", testTime, testTime, testTime, nil).
+				AddRow(8, 1, nil, "expense", 42.50, "Books", testTime, testTime, testTime, nil))
+
+		req, _ := http.NewRequest("GET", "/transactions?sort=amount&order=asc&limit=1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var first map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+		cursor, ok := first["nextCursor"].(string)
+		require.True(t, ok)
+
+		// Second page resumes after (42.5, 7)
+		mock.ExpectQuery(totalsQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "count", "total"}).AddRow("expense", 3, 127.50))
+		mock.ExpectQuery(pageQuery+".*"+regexp.QuoteMeta("AND (transactions.amount > ? OR (transactions.amount = ? AND transactions.id > ?))")).
+			WithArgs(uint(1), 42.5, 42.5, uint(7), 2).
+			WillReturnRows(sqlmock.NewRows(txColumns).
+				AddRow(8, 1, nil, "expense", 42.50, "Books", testTime, testTime, testTime, nil))
+
+		req, _ = http.NewRequest("GET", "/transactions?sort=amount&order=asc&limit=1&cursor="+cursor, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var second map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+		assert.Len(t, second["transactions"], 1)
+		assert.Nil(t, second["nextCursor"])
+
+		// A cursor made for one sort is rejected for another
+		req, _ = http.NewRequest("GET", "/transactions?sort=date&cursor="+cursor, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Combined_Filters", func(t *testing.T) {
+		mock.ExpectQuery(totalsQuery+".*"+regexp.QuoteMeta("AND (transactions.category_id IN (?,?) OR transactions.category_id IS NULL) AND transactions.amount >= ? AND transactions.amount <= ? AND transactions.description LIKE ?")).
+			WithArgs(uint(1), uint(2), uint(5), 10.0, 99.99, `%50\% off%`).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "count", "total"}))
+		mock.ExpectQuery(pageQuery).
+			WithArgs(uint(1), uint(2), uint(5), 10.0, 99.99, `%50\% off%`, 51).
+			WillReturnRows(sqlmock.NewRows(txColumns))
 
+		req, _ := http.NewRequest("GET", "/transactions?categoryId=2,5&uncategorized=true&minAmount=10&maxAmount=99.99&q=50%25%20off", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid_Parameters", func(t *testing.T) {
+		for _, query := range []string{"sort=description", "order=up", "limit=0", "limit=1000", "cursor=not-a-cursor",
+			"categoryId=abc", "minAmount=ten", "startDate=01/01/2024", "type=gift"} {
+			req, _ := http.NewRequest("GET", "/transactions?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Database_Error", func(t *testing.T) {
 		// Setup mock to return an error
-		mock.ExpectQuery("SELECT \\* FROM `transactions` WHERE user_id = \\? AND `transactions`.`deleted_at` IS NULL").
+		mock.ExpectQuery(totalsQuery).
 			WithArgs(uint(1)).
 			WillReturnError(errors.New("database error"))
 
@@ -333,6 +619,58 @@ func TestGetTransactions(t *testing.T) {
 	})
 }
 
+// TestGetCashFlowSummary tests the cash-flow summary handler
+func TestGetCashFlowSummary(t *testing.T) {
+	router, _ := transactionSetup()
+
+	originalDB := db.DB
+	defer func() { db.DB = originalDB }()
+
+	mock, err := transactionSetupDBMock()
+	require.NoError(t, err)
+
+	router.GET("/transactions/summary", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		handlers.GetCashFlowSummary(c)
+	})
+
+	t.Run("Successfully_Summarize", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"type", "total"}).
+			AddRow("expense", 800.00).
+			AddRow("income", 2000.00).
+			AddRow("refund", 50.00).
+			AddRow("transfer", 300.00)
+
+		mock.ExpectQuery("SELECT type, COALESCE\\(SUM\\(amount\\), 0\\) as total FROM `transactions` WHERE \\(user_id = \\? AND transaction_date >= \\? AND transaction_date <= \\?\\) AND `transactions`.`deleted_at` IS NULL GROUP BY `type`").
+			WillReturnRows(rows)
+
+		req, _ := http.NewRequest("GET", "/transactions/summary?startDate=2023-01-01&endDate=2023-01-31", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]map[string]float64
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, 2000.0, response["summary"]["income"])
+		assert.Equal(t, 750.0, response["summary"]["netExpenses"])
+		assert.Equal(t, 1250.0, response["summary"]["netCashFlow"])
+		assert.Equal(t, 300.0, response["summary"]["transfers"])
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid_Date_Range", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/transactions/summary?startDate=2023-02-01&endDate=2023-01-01", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 // TestUpdateTransaction tests the transaction update handler
 func TestUpdateTransaction(t *testing.T) {
 	router, _ := transactionSetup()
@@ -543,10 +881,13 @@ func TestDeleteTransaction(t *testing.T) {
 			WithArgs("1", uint(1), 1).
 			WillReturnRows(rows)
 
-		// Mock the delete operation
+		// Mock the delete operation, which takes back the points adding the transaction earned
 		mock.ExpectBegin()
 		mock.ExpectExec("UPDATE `transactions` SET").
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `user_points` WHERE user_id = ? AND event_key = ?")).
+			WithArgs(uint(1), "transaction:1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectCommit()
 
 		// Perform request
@@ -565,6 +906,28 @@ func TestDeleteTransaction(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("Points_Revoke_Failure_Keeps_Transaction", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "category_id", "amount", "description", "transaction_date", "created_at", "updated_at"}).
+			AddRow(2, 1, 1, 40.00, "Coffee", testTime, testTime, testTime)
+		mock.ExpectQuery("SELECT \\* FROM `transactions` WHERE").
+			WithArgs("2", uint(1), 1).
+			WillReturnRows(rows)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `transactions` SET").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `user_points` WHERE user_id = ? AND event_key = ?")).
+			WithArgs(uint(1), "transaction:2").
+			WillReturnError(errors.New("lock wait timeout"))
+		mock.ExpectRollback()
+
+		req, _ := http.NewRequest("DELETE", "/transactions/2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("Invalid_ID_Parameter", func(t *testing.T) {
 		// Mock no transaction found for invalid ID
 		mock.ExpectQuery("SELECT \\* FROM `transactions` WHERE \\(id = \\? AND user_id = \\?\\) AND `transactions`.`deleted_at` IS NULL ORDER BY `transactions`.`id` LIMIT \\?").
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/RedShawn258/FinTrack/backend/internal/db"
+	"github.com/RedShawn258/FinTrack/backend/internal/models"
+)
+
+// AdminMiddleware lets only admin users through. It runs after AuthMiddleware, which sets the user.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger, _ := c.Get("logger")
+		log := logger.(*zap.Logger)
+		userID := c.MustGet("userID").(uint)
+
+		var user models.User
+		if err := db.DB.Select("id", "is_admin").First(&user, userID).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Error("Failed to look up user role", zap.Uint("userID", userID), zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				c.Abort()
+				return
+			}
+		}
+		if !user.IsAdmin {
+			log.Warn("Admin endpoint refused", zap.Uint("userID", userID))
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -33,38 +33,58 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenStr := parts[1]
+		authenticate(c, parts[1])
+	}
+}
 
-		// Retrieve JWT secret from context
-		secret, exists := c.Get("jwtSecret")
-		if !exists {
-			log.Error("JWT secret not found in context")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			c.Abort()
+// StreamAuthMiddleware is AuthMiddleware for the notification streams. Browsers can't set headers on
+// EventSource or WebSocket connections, so without an Authorization header the JWT may come in the
+// access_token query parameter instead.
+func StreamAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			authenticate(c, token)
 			return
 		}
+		auth(c)
+	}
+}
 
-		jwtSecret, ok := secret.(string)
-		if !ok {
-			log.Error("JWT secret type assertion failed")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			c.Abort()
-			return
-		}
+// authenticate verifies tokenStr and lets the request through as its user.
+func authenticate(c *gin.Context, tokenStr string) {
+	logger, _ := c.Get("logger")
+	log := logger.(*zap.Logger)
 
-		claims := &handlers.Claims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
-		if err != nil || !token.Valid {
-			log.Warn("Invalid or expired token", zap.Error(err))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
+	// Retrieve JWT secret from context
+	secret, exists := c.Get("jwtSecret")
+	if !exists {
+		log.Error("JWT secret not found in context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		c.Abort()
+		return
+	}
+
+	jwtSecret, ok := secret.(string)
+	if !ok {
+		log.Error("JWT secret type assertion failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		c.Abort()
+		return
+	}
 
-		// Token is valid; set the user ID in context
-		c.Set("userID", claims.UserID)
-		c.Next()
+	claims := &handlers.Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		log.Warn("Invalid or expired token", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return
 	}
+
+	// Token is valid; set the user ID in context
+	c.Set("userID", claims.UserID)
+	c.Next()
 }
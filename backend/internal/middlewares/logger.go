@@ -0,0 +1,13 @@
+package middlewares
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger writes an access log line per request to out, like gin's default logger but without the
+// query string: the notification streams take the JWT in the access_token query parameter.
+func RequestLogger(out io.Writer) gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{Output: out, SkipQueryString: true})
+}
@@ -0,0 +1,162 @@
+package models
+
+// CategorySpending is the net spending in one category over the report period.
+type CategorySpending struct {
+	CategoryID   *uint   `json:"categoryId"` // null => uncategorized
+	CategoryName string  `json:"categoryName"`
+	Amount       float64 `json:"amount"`
+	Share        float64 `json:"share"` // percent of all net spending
+	Transactions int     `json:"transactions"`
+}
+
+// DescriptionSpending is the net spending on one merchant or description.
+type DescriptionSpending struct {
+	Description  string  `json:"description"`
+	Amount       float64 `json:"amount"`
+	Transactions int     `json:"transactions"`
+}
+
+// DailySpending is the net spending on one day of the report period.
+type DailySpending struct {
+	Date   string  `json:"date"` // YYYY-MM-DD
+	Amount float64 `json:"amount"`
+}
+
+// PeriodComparison compares the report period's net spending with the same period earlier.
+type PeriodComparison struct {
+	StartDate     string   `json:"startDate"`
+	EndDate       string   `json:"endDate"`
+	NetExpenses   float64  `json:"netExpenses"`   // spending in the earlier period
+	Change        float64  `json:"change"`        // report period minus the earlier period
+	ChangePercent *float64 `json:"changePercent"` // null when nothing was spent in the earlier period
+}
+
+// BudgetUtilization reports how much of a budget overlapping the report period has been used.
+type BudgetUtilization struct {
+	BudgetID    uint    `json:"budgetId"`
+	CategoryID  *uint   `json:"categoryId"` // null => global
+	StartDate   string  `json:"startDate"`
+	EndDate     string  `json:"endDate"`
+	Limit       float64 `json:"limit"` // limit amount plus rollover
+	Spent       float64 `json:"spent"`
+	Remaining   float64 `json:"remaining"`
+	Utilization float64 `json:"utilization"` // percent of the limit spent
+}
+
+// AnalyticsReport summarizes the user's spending over a date range.
+type AnalyticsReport struct {
+	StartDate           string                `json:"startDate"`
+	EndDate             string                `json:"endDate"`
+	Days                int                   `json:"days"`
+	Summary             CashFlowSummary       `json:"summary"`
+	AverageDailySpend   float64               `json:"averageDailySpend"` // net expenses per day of the period
+	Daily               []DailySpending       `json:"daily"`             // every day of the period, oldest first
+	Categories          []CategorySpending    `json:"categories"`        // largest first
+	MonthOverMonth      PeriodComparison      `json:"monthOverMonth"`
+	YearOverYear        PeriodComparison      `json:"yearOverYear"`
+	TopDescriptions     []DescriptionSpending `json:"topDescriptions"` // largest first
+	BudgetUtilization   []BudgetUtilization   `json:"budgetUtilization"`
+	LargestTransactions []Transaction         `json:"largestTransactions"`
+}
+
+// Anomaly kinds.
+const (
+	AnomalyKindOutlier            = "outlier"              // a transaction far above its category's usual amount
+	AnomalyKindCategoryMonth      = "category_month"       // a month's spending in a category far above its usual
+	AnomalyKindFirstLargePurchase = "first_large_purchase" // a large purchase from a description never seen before
+	AnomalyKindDuplicate          = "duplicate_charge"     // the same amount and description more than once on a day
+)
+
+// Anomaly is a transaction, group of transactions or category-month that is unusual for the user.
+type Anomaly struct {
+	Kind           string  `json:"kind"`
+	TransactionIDs []uint  `json:"transactionIds,omitempty"`
+	CategoryID     *uint   `json:"categoryId"`      // null => uncategorized
+	Date           string  `json:"date,omitempty"`  // the transaction date
+	Month          string  `json:"month,omitempty"` // category_month: YYYY-MM
+	Amount         float64 `json:"amount"`
+	Expected       float64 `json:"expected"` // median of the history it was compared with
+	Score          float64 `json:"score"`    // robust z-score; 0 for kinds not scored
+	Message        string  `json:"message"`
+}
+
+// AnomalyReport lists the anomalies found between StartDate and EndDate, grouped by kind.
+type AnomalyReport struct {
+	StartDate string    `json:"startDate"`
+	EndDate   string    `json:"endDate"`
+	Anomalies []Anomaly `json:"anomalies"`
+}
+
+// Granularities of the savings series.
+const (
+	GranularityMonth = "month"
+	GranularityWeek  = "week" // weeks start on Monday
+)
+
+// SavingsPoint is one month or week of the savings series. The first and last periods are cut to the
+// report's dates.
+type SavingsPoint struct {
+	Period      string   `json:"period"` // YYYY-MM, or the Monday the week starts on as YYYY-MM-DD
+	StartDate   string   `json:"startDate"`
+	EndDate     string   `json:"endDate"`
+	Income      float64  `json:"income"`
+	Expenses    float64  `json:"expenses"`    // net of refunds
+	Savings     float64  `json:"savings"`     // income minus expenses
+	SavingsRate *float64 `json:"savingsRate"` // percent of income saved; null without income
+	NetWorth    float64  `json:"netWorth"`    // at the end of the period
+}
+
+// SavingsReport is the user's income, spending, savings rate and net worth over a date range.
+type SavingsReport struct {
+	Granularity     string         `json:"granularity"`
+	StartDate       string         `json:"startDate"`
+	EndDate         string         `json:"endDate"`
+	OpeningNetWorth float64        `json:"openingNetWorth"` // before StartDate
+	Income          float64        `json:"income"`
+	Expenses        float64        `json:"expenses"`
+	Savings         float64        `json:"savings"`
+	SavingsRate     *float64       `json:"savingsRate"`
+	ClosingNetWorth float64        `json:"closingNetWorth"`
+	Series          []SavingsPoint `json:"series"`
+}
+
+// Heatmap views.
+const (
+	HeatmapViewWeekdayHour = "weekday_hour" // needs the time of day, see Transaction.OccurredAt
+	HeatmapViewDayOfMonth  = "day_of_month"
+	HeatmapViewCalendar    = "calendar"
+)
+
+// Heatmap groupings.
+const (
+	HeatmapGroupCategory = "category"
+	HeatmapGroupKeyword  = "keyword"
+)
+
+// HeatmapCell is the net spending in one cell of a heatmap. Which fields are set depends on the view.
+type HeatmapCell struct {
+	Weekday      *int    `json:"weekday,omitempty"` // weekday_hour: 0 = Sunday
+	Hour         *int    `json:"hour,omitempty"`    // weekday_hour: 0-23
+	Day          int     `json:"day,omitempty"`     // day_of_month: 1-31
+	Date         string  `json:"date,omitempty"`    // calendar: YYYY-MM-DD
+	Amount       float64 `json:"amount"`
+	Transactions int     `json:"transactions"`
+}
+
+// HeatmapSeries is the heatmap of one group. Cells without spending are left out.
+type HeatmapSeries struct {
+	Group      string        `json:"group"`                // category name, keyword, or "All"
+	CategoryID *uint         `json:"categoryId,omitempty"` // category grouping; null => uncategorized
+	Total      float64       `json:"total"`
+	Untimed    int           `json:"untimed,omitempty"` // weekday_hour: transactions left out for lacking a time of day
+	Cells      []HeatmapCell `json:"cells"`
+}
+
+// Heatmap aggregates the user's spending into the cells of View, per group.
+type Heatmap struct {
+	View      string          `json:"view"`
+	GroupBy   string          `json:"groupBy,omitempty"`
+	StartDate string          `json:"startDate"`
+	EndDate   string          `json:"endDate"`
+	Series    []HeatmapSeries `json:"series"`
+}
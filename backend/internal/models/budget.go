@@ -6,6 +6,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// Budget recurrences. A recurring budget opens its next period automatically once the current one ends.
+const (
+	BudgetRecurrenceWeekly    = "weekly"
+	BudgetRecurrenceMonthly   = "monthly"
+	BudgetRecurrenceQuarterly = "quarterly"
+	BudgetRecurrenceYearly    = "yearly"
+)
+
 // Budget represents a user's budget, now storing only the date portion for StartDate and EndDate.
 type Budget struct {
 	ID              uint      `gorm:"primaryKey"`
@@ -15,7 +23,71 @@ type Budget struct {
 	RemainingAmount float64   `gorm:"type:decimal(10,2);default:0.00" json:"RemainingAmount"`
 	StartDate       time.Time `gorm:"type:date;not null;index" json:"StartDate"` // Only store the date, no time
 	EndDate         time.Time `gorm:"type:date;not null;index" json:"EndDate"`
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-	DeletedAt       gorm.DeletedAt `gorm:"index"`
+
+	// Recurrence: every period of a recurring budget is its own row. The first period is the series root
+	// (SeriesID null, PeriodIndex 0); later periods point back at it, so the full history stays queryable.
+	Recurrence       string  `gorm:"size:20;index"`                                          // "" => one-off budget
+	Rollover         bool    `gorm:"default:false"`                                          // carry RemainingAmount into the next period
+	RolloverAmount   float64 `gorm:"type:decimal(10,2);default:0.00"`                        // carried in from the previous period (may be negative)
+	SeriesID         *uint   `gorm:"type:int unsigned;uniqueIndex:idx_budget_series_period"` // null => this budget is the series root
+	PeriodIndex      int     `gorm:"not null;default:0;uniqueIndex:idx_budget_series_period"`
+	NextPeriodOpened bool    `gorm:"not null;default:false;index"`
+
+	// Alerts fire at 50%, 80% and 100% of the limit, and at AlertPercent when set
+	AlertPercent *float64 `gorm:"type:decimal(5,2)" json:"AlertPercent"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// BudgetAlert records that a budget period reached an alert threshold, so the alert is never sent twice.
+type BudgetAlert struct {
+	ID        uint    `gorm:"primaryKey"`
+	BudgetID  uint    `gorm:"not null;type:int unsigned;uniqueIndex:idx_budget_alert_threshold"`
+	Threshold float64 `gorm:"type:decimal(5,2);not null;uniqueIndex:idx_budget_alert_threshold"` // percent of the limit
+	CreatedAt time.Time
+}
+
+// Budget projection statuses.
+const (
+	BudgetStatusOnTrack  = "on_track"
+	BudgetStatusAtRisk   = "at_risk"  // projected to exceed the limit before the period ends
+	BudgetStatusExceeded = "exceeded" // already over the limit
+)
+
+// ProjectedRecurringItem is an occurrence of a recurring transaction expected before a budget period ends.
+type ProjectedRecurringItem struct {
+	RecurringTransactionID uint    `json:"recurringTransactionId"`
+	Date                   string  `json:"date"`   // YYYY-MM-DD
+	Amount                 float64 `json:"amount"` // negative for a recurring refund
+	Description            string  `json:"description"`
+}
+
+// BudgetProjection predicts, partway through a period, where a budget will stand when the period ends.
+type BudgetProjection struct {
+	BudgetID    uint   `json:"budgetId"`
+	CategoryID  *uint  `json:"categoryId"` // null => global
+	StartDate   string `json:"startDate"`
+	EndDate     string `json:"endDate"`
+	AsOf        string `json:"asOf"`
+	DaysElapsed int    `json:"daysElapsed"` // days of the period up to and including AsOf
+	DaysTotal   int    `json:"daysTotal"`
+
+	Limit float64 `json:"limit"` // limit amount plus rollover
+	Spent float64 `json:"spent"` // so far, net of refunds
+
+	// Day-to-day spending rates, recurring items excluded. The projection blends the velocity so far with
+	// the baseline forecast from earlier months, trusting the velocity more as the period goes on.
+	DailyVelocity      float64 `json:"dailyVelocity"`
+	BaselineDailyRate  float64 `json:"baselineDailyRate"`
+	BaselineModel      string  `json:"baselineModel,omitempty"` // forecast model behind the baseline; empty without history
+	ProjectedDailyRate float64 `json:"projectedDailyRate"`
+
+	UpcomingRecurring  []ProjectedRecurringItem `json:"upcomingRecurring"`
+	ProjectedSpend     float64                  `json:"projectedSpend"`
+	ProjectedRemaining float64                  `json:"projectedRemaining"` // negative when over the limit
+	Overrun            float64                  `json:"overrun"`            // projected amount over the limit; 0 when on track
+	ExceedDate         *string                  `json:"exceedDate"`         // first day spending is, or is projected to be, over the limit
+	Status             string                   `json:"status"`
 }
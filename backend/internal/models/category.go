@@ -10,6 +10,7 @@ type Category struct {
 	ID        uint   `gorm:"primaryKey"`
 	UserID    uint   `gorm:"not null;index;type:int unsigned"`
 	Name      string `gorm:"size:50;not null"`
+	ParentID  *uint  `gorm:"index;type:int unsigned"` // null => top-level category
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
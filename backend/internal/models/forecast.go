@@ -2,30 +2,123 @@ package models
 
 import "time"
 
+// Forecast models. ForecastRequest.Model may name one; otherwise it is picked from the months of history.
+const (
+	ForecastModelMean                 = "mean"
+	ForecastModelLinearTrend          = "linear_trend"
+	ForecastModelExponentialSmoothing = "exponential_smoothing"
+	ForecastModelHoltWinters          = "holt_winters"
+)
+
 // ForecastRequest represents a request for expense forecasting
 type ForecastRequest struct {
 	CategoryID  *uint      `json:"categoryId,omitempty"` // Optional: filter by category
 	MonthsAhead int        `json:"monthsAhead"`          // How many months to forecast
 	StartDate   *time.Time `json:"startDate,omitempty"`  // Optional: custom start date
+
+	// Optional: force a model instead of picking one from the length of the history
+	Model string `json:"model,omitempty" binding:"omitempty,oneof=mean linear_trend exponential_smoothing holt_winters"`
+
+	// Optional: confidence levels of the prediction intervals, e.g. [0.8, 0.95] (the default)
+	ConfidenceLevels []float64 `json:"confidenceLevels,omitempty" binding:"omitempty,max=5,dive,gt=0,lt=1"`
+}
+
+// PredictionInterval is the range the actual amount is expected to fall in with probability Level.
+type PredictionInterval struct {
+	Level float64 `json:"level"` // e.g. 0.95
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
 }
 
 // ForecastPoint represents a single point in the forecast
 type ForecastPoint struct {
-	Month       string  `json:"month"`       // Month in YYYY-MM format
-	Amount      float64 `json:"amount"`      // Forecasted amount
-	Probability float64 `json:"probability"` // Confidence level (0-1)
+	Month     string               `json:"month"`     // Month in YYYY-MM format
+	Amount    float64              `json:"amount"`    // Forecasted amount (point estimate)
+	Intervals []PredictionInterval `json:"intervals"` // One per confidence level, narrowest first; empty when the history is too short
 }
 
 // CategoryForecast represents the forecast for a specific category
 type CategoryForecast struct {
 	CategoryID   uint            `json:"categoryId"`
 	CategoryName string          `json:"categoryName"`
+	Model        string          `json:"model"` // forecast model used for this category
 	Forecast     []ForecastPoint `json:"forecast"`
 }
 
 // ForecastResponse represents the full response to a forecast request
 type ForecastResponse struct {
 	TotalForecast     []ForecastPoint    `json:"totalForecast"`     // Overall forecast
+	Model             string             `json:"model"`             // forecast model used for the total
+	ConfidenceLevels  []float64          `json:"confidenceLevels"`  // levels of the prediction intervals
 	CategoryForecasts []CategoryForecast `json:"categoryForecasts"` // Category-specific forecasts
 	Message           string             `json:"message"`           // Additional info about the forecast
 }
+
+// BacktestRequest asks how well each forecast model would have predicted the user's own history.
+type BacktestRequest struct {
+	CategoryID     *uint      `json:"categoryId,omitempty"`     // Optional: only backtest this category
+	Horizon        int        `json:"horizon"`                  // Months ahead to score, 1-12 (default 3)
+	EndDate        *time.Time `json:"endDate,omitempty"`        // Optional: history ends before this month (default: current month)
+	MinTrainMonths int        `json:"minTrainMonths,omitempty"` // Optional: months of history before the first forecast (default 3)
+	Models         []string   `json:"models,omitempty" binding:"omitempty,dive,oneof=mean linear_trend exponential_smoothing holt_winters"`
+}
+
+// BacktestMetrics scores one model at one horizon over every rolling origin it could be fitted at.
+type BacktestMetrics struct {
+	Model     string   `json:"model"`
+	Horizon   int      `json:"horizon"`   // months ahead
+	Forecasts int      `json:"forecasts"` // number of forecasts scored
+	MAE       float64  `json:"mae"`       // mean absolute error
+	MAPE      *float64 `json:"mape"`      // mean absolute percentage error over months with spending; null if none
+	Bias      float64  `json:"bias"`      // mean of forecast minus actual; positive means over-forecasting
+}
+
+// BacktestSeries holds the metrics for the total or for one category.
+type BacktestSeries struct {
+	CategoryID   *uint             `json:"categoryId,omitempty"` // null => total spending
+	CategoryName string            `json:"categoryName,omitempty"`
+	Months       int               `json:"months"`     // months of history replayed
+	Metrics      []BacktestMetrics `json:"metrics"`    // by model, then horizon
+	BestModels   []string          `json:"bestModels"` // lowest MAE per horizon (index 0 = 1 month ahead); "" when nothing was scored
+}
+
+// BacktestResponse is the full result of a backtest.
+type BacktestResponse struct {
+	Total      BacktestSeries   `json:"total"`
+	Categories []BacktestSeries `json:"categories"`
+}
+
+// CashFlowItem is a recurring transaction due on a day of a cash-flow projection.
+type CashFlowItem struct {
+	RecurringTransactionID uint    `json:"recurringTransactionId"`
+	Type                   string  `json:"type"`
+	Amount                 float64 `json:"amount"`
+	Description            string  `json:"description"`
+}
+
+// CashFlowDay is one day of a cash-flow projection.
+type CashFlowDay struct {
+	Date           string         `json:"date"`           // YYYY-MM-DD
+	Income         float64        `json:"income"`         // recurring income due, including recurring refunds
+	Bills          float64        `json:"bills"`          // recurring expenses due
+	Discretionary  float64        `json:"discretionary"`  // forecast day-to-day spending
+	Balance        float64        `json:"balance"`        // balance at the end of the day
+	BelowThreshold bool           `json:"belowThreshold"` // balance is under the threshold
+	Items          []CashFlowItem `json:"items"`          // recurring transactions due that day
+}
+
+// CashFlowProjection is a day-by-day projection of the user's balance.
+type CashFlowProjection struct {
+	StartDate          string        `json:"startDate"`
+	Days               int           `json:"days"`
+	OpeningBalance     float64       `json:"openingBalance"`
+	BalanceSource      string        `json:"balanceSource"` // "supplied" or "transactions"
+	Threshold          float64       `json:"threshold"`
+	DiscretionaryModel string        `json:"discretionaryModel,omitempty"` // forecast model behind the daily spending; empty without history
+	ClosingBalance     float64       `json:"closingBalance"`
+	LowestBalance      float64       `json:"lowestBalance"`
+	LowestBalanceDate  string        `json:"lowestBalanceDate"`
+	DaysBelowThreshold int           `json:"daysBelowThreshold"`
+	FirstBelowDate     *string       `json:"firstBelowDate"` // first day under the threshold; null if none
+	Calendar           []CashFlowDay `json:"calendar"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -12,15 +13,46 @@ type Badge struct {
 	ImageURL    string `gorm:"size:255" json:"imageUrl"`
 	Category    string `gorm:"size:50;not null" json:"category"` // e.g., "savings", "budgeting", "consistency"
 	Threshold   int    `gorm:"not null" json:"threshold"`        // Minimum points or condition needed to earn
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+
+	// Rule is the BadgeRule the badge is awarded on, as JSON. Without one the badge is awarded at
+	// Threshold total points.
+	Rule json.RawMessage `gorm:"type:json" json:"rule,omitempty"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// User statistics badge rules compare.
+const (
+	BadgeStatTotalPoints        = "total_points"
+	BadgeStatTransactionCount   = "transaction_count"
+	BadgeStatBudgetCount        = "budget_count"
+	BadgeStatCategoriesBudgeted = "categories_budgeted" // distinct categories the user has budgeted
+	BadgeStatTrackingStreak     = "tracking_streak"     // longest run of consecutive days with expenses recorded
+	BadgeStatMonthsUnderBudget  = "months_under_budget" // longest run of consecutive months whose ended budgets all stayed under their limit
+	BadgeStatSavingsRate        = "savings_rate"        // percent of last month's income saved
+)
+
+// BadgeRule is the condition a badge is awarded on. It compares one user statistic with a value, or
+// combines nested rules with all (every one holds) or any (at least one holds):
+//
+//	{"stat": "transaction_count", "op": ">=", "value": 100}
+//	{"all": [{"stat": "tracking_streak", "op": ">=", "value": 7}, {"stat": "budget_count", "op": ">=", "value": 1}]}
+//
+// op is one of >=, >, <=, < and ==.
+type BadgeRule struct {
+	Stat  string      `json:"stat,omitempty"`
+	Op    string      `json:"op,omitempty"`
+	Value float64     `json:"value,omitempty"`
+	All   []BadgeRule `json:"all,omitempty"`
+	Any   []BadgeRule `json:"any,omitempty"`
 }
 
 // UserBadge represents badges earned by a user
 type UserBadge struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `gorm:"index;not null" json:"userId"`
-	BadgeID   uint      `gorm:"index;not null" json:"badgeId"`
+	UserID    uint      `gorm:"index;not null;uniqueIndex:idx_user_badge,priority:1" json:"userId"`
+	BadgeID   uint      `gorm:"index;not null;uniqueIndex:idx_user_badge,priority:2" json:"badgeId"`
 	Badge     Badge     `gorm:"foreignKey:BadgeID" json:"badge"`
 	EarnedAt  time.Time `json:"earnedAt"`
 	CreatedAt time.Time
@@ -30,14 +62,31 @@ type UserBadge struct {
 // UserPoints tracks point history for a user
 type UserPoints struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
-	UserID       uint      `gorm:"index;not null" json:"userId"`
+	UserID       uint      `gorm:"index;not null;uniqueIndex:idx_user_points_event,priority:1" json:"userId"`
 	Points       int       `gorm:"not null" json:"points"`
 	Reason       string    `gorm:"size:255;not null" json:"reason"`
-	ActivityType string    `gorm:"size:50;not null" json:"activityType"` // e.g., "budget_created", "transaction_added"
+	ActivityType string    `gorm:"size:50;not null" json:"activityType"`                           // e.g., "budget_created", "transaction_added"
+	EventKey     *string   `gorm:"size:100;uniqueIndex:idx_user_points_event,priority:2" json:"-"` // the event that earned the points; null for manual grants
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time
 }
 
+// Gamification event types. Each earns the user points once per occurrence.
+const (
+	EventTransactionAdded             = "transaction_added"
+	EventBudgetCreated                = "budget_created"
+	EventBudgetPeriodClosedUnderLimit = "budget_period_closed_under_limit"
+	EventProfileCompleted             = "profile_completed"
+)
+
+// GamificationEvent is something the user did that gamification reacts to.
+type GamificationEvent struct {
+	UserID uint
+	Type   string
+	Key    string // identifies the occurrence, e.g. "transaction:42"; an occurrence never earns points twice
+	Reason string // shown in the points history
+}
+
 // GamificationSummary is the user's gamification status response
 type GamificationSummary struct {
 	TotalPoints       int          `json:"totalPoints"`
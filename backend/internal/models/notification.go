@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification types, by the part of the backend that posts them.
+const (
+	NotificationTypeBudget       = "budget"
+	NotificationTypeGamification = "gamification"
+	NotificationTypeSecurity     = "security"
+	NotificationTypeAnomaly      = "anomaly"
+)
+
+// Notification severities.
+const (
+	NotificationSeverityInfo     = "info"
+	NotificationSeverityWarning  = "warning"
+	NotificationSeverityCritical = "critical"
+)
+
+// Notification is a message in a user's in-app inbox.
+type Notification struct {
+	ID       uint            `gorm:"primaryKey" json:"id"`
+	UserID   uint            `gorm:"not null;index:idx_notification_inbox,priority:1;type:int unsigned" json:"userId"`
+	Type     string          `gorm:"size:30;not null;index" json:"type"`
+	Severity string          `gorm:"size:20;not null;default:'info'" json:"severity"`
+	Title    string          `gorm:"size:255;not null" json:"title"`
+	Message  string          `gorm:"type:text" json:"message"`
+	Payload  json.RawMessage `gorm:"type:json" json:"payload,omitempty"` // details for the client, e.g. the budget or badge
+
+	ReadAt     *time.Time `json:"readAt"`     // null => unread
+	ArchivedAt *time.Time `json:"archivedAt"` // null => in the inbox
+
+	CreatedAt time.Time `gorm:"index:idx_notification_inbox,priority:2" json:"createdAt"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// Stream event types pushed live to a user's open notification streams.
+const (
+	StreamEventNotification    = "notification"     // a notification was posted to the inbox
+	StreamEventImportCompleted = "import_completed" // a statement import was committed
+)
+
+// StreamEvent is an event pushed live to a user's open notification streams. It is serialized whole so a
+// shared backend can carry it between server replicas.
+type StreamEvent struct {
+	UserID uint            `json:"userId"`
+	Type   string          `json:"type"`
+	Data   json.RawMessage `json:"data"`
+}
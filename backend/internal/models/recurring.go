@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Recurrence frequencies for recurring transactions.
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// RecurringTransaction is a template that the recurring worker turns into real
+// Transaction rows on schedule. Occurrence n falls on StartDate + n*Interval units.
+type RecurringTransaction struct {
+	ID          uint    `gorm:"primaryKey"`
+	UserID      uint    `gorm:"not null;index;type:int unsigned"`
+	CategoryID  *uint   `gorm:"index;type:int unsigned"` // null => uncategorized
+	Type        string  `gorm:"size:20;not null;default:'expense'"`
+	Amount      float64 `gorm:"type:decimal(10,2);not null"`
+	Description string  `gorm:"type:text"`
+
+	Frequency string     `gorm:"size:20;not null"`                          // daily, weekly, monthly, yearly
+	Interval  int        `gorm:"column:repeat_interval;not null;default:1"` // every N frequency units
+	StartDate time.Time  `gorm:"type:date;not null"`                        // first occurrence
+	EndDate   *time.Time `gorm:"type:date"`                                 // null => no end date
+	Count     *int       `gorm:"column:max_occurrences;type:int"`           // null => unlimited occurrences
+
+	// Schedule state, advanced by the worker in the same DB transaction that inserts the occurrence
+	OccurrencesGenerated int       `gorm:"not null;default:0"`
+	NextOccurrence       time.Time `gorm:"type:date;not null;index"`
+	Active               bool      `gorm:"not null;default:true;index"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Description match modes for category rules.
+const (
+	RuleMatchContains = "contains"
+	RuleMatchRegex    = "regex"
+)
+
+// CategoryRule assigns CategoryID to new uncategorized transactions that meet all of its conditions.
+// Empty conditions match anything; when several rules match, the highest Priority wins (oldest rule on ties).
+type CategoryRule struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;index;type:int unsigned"`
+	CategoryID uint   `gorm:"not null;index;type:int unsigned"`
+	Name       string `gorm:"size:100"`
+	Priority   int    `gorm:"not null;default:0"`
+	Active     bool   `gorm:"not null"` // set from the request; a column default would replace false on insert
+
+	DescriptionMatch   string   `gorm:"size:20"`  // "" => any description, else contains or regex
+	DescriptionPattern string   `gorm:"size:255"` // case-insensitive
+	MinAmount          *float64 `gorm:"type:decimal(10,2)"`
+	MaxAmount          *float64 `gorm:"type:decimal(10,2)"`
+	Weekdays           string   `gorm:"size:30"` // comma-separated day names (mon..sun); "" => any day
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scenario change kinds.
+const (
+	ScenarioChangeCancelRecurring = "cancel_recurring" // drop a recurring transaction, e.g. cancel a subscription
+	ScenarioChangeScaleCategory   = "scale_category"   // spend Percent more (or less, when negative) in a category
+	ScenarioChangeAddRecurring    = "add_recurring"    // a new monthly expense of Amount, e.g. a car payment
+)
+
+// Scenario is a saved set of hypothetical changes to simulate against the forecast. Simulating never
+// touches real transactions.
+type Scenario struct {
+	ID          uint             `gorm:"primaryKey"`
+	UserID      uint             `gorm:"not null;index;type:int unsigned"`
+	Name        string           `gorm:"size:100;not null"`
+	Description string           `gorm:"type:text"`
+	Changes     []ScenarioChange `gorm:"constraint:OnDelete:CASCADE"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// ScenarioChange is one hypothetical change of a scenario. It applies to the forecast months from
+// StartDate's month through EndDate's month.
+type ScenarioChange struct {
+	ID                     uint       `gorm:"primaryKey"`
+	ScenarioID             uint       `gorm:"not null;index"`
+	Kind                   string     `gorm:"size:30;not null"`
+	RecurringTransactionID *uint      `gorm:"type:int unsigned"`            // cancel_recurring: the one to cancel
+	CategoryID             *uint      `gorm:"type:int unsigned"`            // scale_category: the category; add_recurring: optional
+	Percent                float64    `gorm:"type:decimal(6,2);default:0"`  // scale_category: -20 cuts the category by a fifth
+	Amount                 float64    `gorm:"type:decimal(10,2);default:0"` // add_recurring: the monthly amount
+	Description            string     `gorm:"size:255"`
+	StartDate              *time.Time `gorm:"type:date"` // null => from the first forecast month
+	EndDate                *time.Time `gorm:"type:date"` // null => no end
+}
+
+// BudgetOutcome is the forecast spending of a budget period against its limit.
+type BudgetOutcome struct {
+	BudgetID       uint    `json:"budgetId"`
+	CategoryID     *uint   `json:"categoryId"` // null => global
+	StartDate      string  `json:"startDate"`
+	EndDate        string  `json:"endDate"`
+	Limit          float64 `json:"limit"` // limit amount plus rollover
+	ProjectedSpend float64 `json:"projectedSpend"`
+	Remaining      float64 `json:"remaining"` // negative when over the limit
+	Exceeded       bool    `json:"exceeded"`
+}
+
+// SimulationOutcome is a forecast together with the budget outcomes it leads to.
+type SimulationOutcome struct {
+	TotalForecast     []ForecastPoint    `json:"totalForecast"`
+	TotalSpend        float64            `json:"totalSpend"` // sum of the forecast months
+	CategoryForecasts []CategoryForecast `json:"categoryForecasts"`
+	Budgets           []BudgetOutcome    `json:"budgets"`
+	BudgetsExceeded   int                `json:"budgetsExceeded"`
+}
+
+// ScenarioOutcome is the outcome of one scenario, compared with the baseline.
+type ScenarioOutcome struct {
+	ScenarioID *uint             `json:"scenarioId"` // null for changes posted with the request
+	Name       string            `json:"name"`
+	Outcome    SimulationOutcome `json:"outcome"`
+	Difference float64           `json:"difference"` // TotalSpend minus the baseline's; negative is a saving
+}
+
+// SimulationResponse puts each scenario next to the baseline forecast.
+type SimulationResponse struct {
+	Model     string            `json:"model"` // forecast model of the baseline total
+	Baseline  SimulationOutcome `json:"baseline"`
+	Scenarios []ScenarioOutcome `json:"scenarios"`
+}
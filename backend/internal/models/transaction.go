@@ -6,14 +6,49 @@ import (
 	"gorm.io/gorm"
 )
 
+// Transaction types. Amount is always stored as a positive number; the type
+// decides whether it counts as money going out or coming in.
+const (
+	TransactionTypeExpense  = "expense"
+	TransactionTypeIncome   = "income"
+	TransactionTypeRefund   = "refund"
+	TransactionTypeTransfer = "transfer"
+)
+
 type Transaction struct {
 	ID              uint      `gorm:"primaryKey"`
-	UserID          uint      `gorm:"not null;index;type:int unsigned"`
+	UserID          uint      `gorm:"not null;index;type:int unsigned;uniqueIndex:idx_external_transaction,priority:1"`
 	CategoryID      *uint     `gorm:"index;type:int unsigned"` // null => uncategorized
+	Type            string    `gorm:"size:20;not null;default:'expense';index"`
 	Amount          float64   `gorm:"type:decimal(10,2);not null"`
 	Description     string    `gorm:"type:text"`
-	TransactionDate time.Time `gorm:"type:date;not null;index"` // store only date if you want day-level precision
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-	DeletedAt       gorm.DeletedAt `gorm:"index"`
+	TransactionDate time.Time `gorm:"type:date;not null;index;uniqueIndex:idx_recurring_occurrence,priority:2"` // store only date if you want day-level precision
+	// Full timestamp, when the time of day is known. Budgets and reports still go by TransactionDate;
+	// OccurredAt only feeds time-of-day analytics.
+	OccurredAt *time.Time `gorm:"type:datetime"`
+
+	// Set when the row was materialized from a RecurringTransaction; the unique index
+	// guarantees an occurrence is never created twice.
+	RecurringTransactionID *uint `gorm:"type:int unsigned;uniqueIndex:idx_recurring_occurrence,priority:1"`
+
+	// Bank-assigned ID (OFX FITID) for imported rows and the account it belongs to, as FITIDs are only
+	// unique within an account. The unique index keeps a re-import from loading a row twice, even if
+	// the first copy was deleted since.
+	ExternalAccount string  `gorm:"size:64;not null;uniqueIndex:idx_external_transaction,priority:2"`
+	ExternalID      *string `gorm:"size:255;uniqueIndex:idx_external_transaction,priority:3"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// CashFlowSummary totals a set of transactions by type.
+// NetExpenses is expenses minus refunds; NetCashFlow is income minus NetExpenses.
+type CashFlowSummary struct {
+	Income      float64 `json:"income"`
+	Expenses    float64 `json:"expenses"`
+	Refunds     float64 `json:"refunds"`
+	Transfers   float64 `json:"transfers"`
+	NetExpenses float64 `json:"netExpenses"`
+	NetCashFlow float64 `json:"netCashFlow"`
 }
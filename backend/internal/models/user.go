@@ -17,6 +17,9 @@ type User struct {
 	ResetToken       string         `gorm:"column:reset_token"`
 	ResetTokenExpiry time.Time      `gorm:"column:reset_token_expiry"`
 
+	// Admins may manage the badge catalog. Granted in the database only; no endpoint sets it.
+	IsAdmin bool `gorm:"not null;default:false"`
+
 	// Profile extension fields
 	FirstName            string `gorm:"size:50"`
 	LastName             string `gorm:"size:50"`
@@ -25,6 +25,14 @@ func SetupRoutes(router *gin.Engine, logger *zap.Logger, jwtSecret string) {
 		auth.POST("/reset-password", handlers.ResetPasswordHandler)
 	}
 
+	// Live notification streams (JWT required, also accepted in the access_token query parameter)
+	streams := router.Group("/api/v1/notifications")
+	streams.Use(middlewares.StreamAuthMiddleware())
+	{
+		streams.GET("/stream", handlers.StreamNotifications)
+		streams.GET("/ws", handlers.NotificationWebSocket)
+	}
+
 	// Protected endpoints (JWT required)
 	protected := router.Group("/api/v1")
 	protected.Use(middlewares.AuthMiddleware())
@@ -37,26 +45,78 @@ func SetupRoutes(router *gin.Engine, logger *zap.Logger, jwtSecret string) {
 		// Budget endpoints
 		protected.POST("/budgets", handlers.CreateBudget)
 		protected.GET("/budgets", handlers.GetBudgets)
+		protected.GET("/budgets/projections", handlers.GetBudgetProjections)
+		protected.GET("/budgets/:id/history", handlers.GetBudgetHistory)
+		protected.GET("/budgets/:id/projection", handlers.GetBudgetProjection)
 		protected.PUT("/budgets/:id", handlers.UpdateBudget)
 		protected.DELETE("/budgets/:id", handlers.DeleteBudget)
 
 		// Category endpoints
 		protected.POST("/categories", handlers.CreateCategory)
 		protected.GET("/categories", handlers.GetCategories)
+		protected.PUT("/categories/:id", handlers.UpdateCategory)
+		protected.POST("/categories/:id/merge", handlers.MergeCategory)
 		protected.DELETE("/categories/:id", handlers.DeleteCategory)
 
+		// Category rule endpoints
+		protected.POST("/category-rules", handlers.CreateCategoryRule)
+		protected.GET("/category-rules", handlers.GetCategoryRules)
+		protected.POST("/category-rules/test", handlers.TestCategoryRule)
+		protected.POST("/category-rules/apply", handlers.ApplyCategoryRules)
+		protected.PUT("/category-rules/:id", handlers.UpdateCategoryRule)
+		protected.DELETE("/category-rules/:id", handlers.DeleteCategoryRule)
+
 		// Transaction endpoints
 		protected.POST("/transactions", handlers.CreateTransaction)
 		protected.GET("/transactions", handlers.GetTransactions)
+		protected.GET("/transactions/summary", handlers.GetCashFlowSummary)
+		protected.GET("/transactions/export", handlers.ExportTransactions)
+		protected.POST("/transactions/import", handlers.ImportTransactions)
+		protected.POST("/transactions/import/commit", handlers.CommitImport)
 		protected.PUT("/transactions/:id", handlers.UpdateTransaction)
 		protected.DELETE("/transactions/:id", handlers.DeleteTransaction)
 
-		// Future feature endpoints (stub implementations)
-		protected.GET("/features/gamification", handlers.GamificationHandler)
+		// Recurring transaction endpoints
+		protected.POST("/recurring-transactions", handlers.CreateRecurringTransaction)
+		protected.GET("/recurring-transactions", handlers.GetRecurringTransactions)
+		protected.PUT("/recurring-transactions/:id", handlers.UpdateRecurringTransaction)
+		protected.DELETE("/recurring-transactions/:id", handlers.DeleteRecurringTransaction)
+
+		// What-if scenario endpoints
+		protected.POST("/scenarios", handlers.CreateScenario)
+		protected.GET("/scenarios", handlers.GetScenarios)
+		protected.POST("/scenarios/simulate", handlers.SimulateScenarios)
+		protected.PUT("/scenarios/:id", handlers.UpdateScenario)
+		protected.DELETE("/scenarios/:id", handlers.DeleteScenario)
+
+		// Spending analytics
 		protected.GET("/features/analytics", handlers.AnalyticsHandler)
-		protected.GET("/features/notifications", handlers.NotificationHandler)
+		protected.GET("/features/analytics/anomalies", handlers.GetSpendingAnomalies)
+		protected.GET("/features/analytics/savings", handlers.GetSavingsSeries)
+		protected.GET("/features/analytics/heatmap", handlers.GetSpendingHeatmap)
+
+		// Notification inbox
+		protected.GET("/notifications", handlers.GetNotifications)
+		protected.GET("/notifications/unread-count", handlers.GetUnreadNotificationCount)
+		protected.POST("/notifications/read-all", handlers.MarkAllNotificationsRead)
+		protected.POST("/notifications/:id/read", handlers.MarkNotificationRead)
+		protected.POST("/notifications/:id/archive", handlers.ArchiveNotification)
+		protected.DELETE("/notifications/:id", handlers.DeleteNotification)
+
+		// Gamification status
+		protected.GET("/features/gamification", handlers.GamificationHandler)
 
 		// Forecasting feature
 		protected.POST("/forecast/expenses", handlers.ForecastExpensesHandler)
+		protected.POST("/forecast/backtest", handlers.BacktestForecastHandler)
+		protected.GET("/forecast/cashflow", handlers.CashFlowForecastHandler)
+
+		// Badge catalog management (admins only)
+		admin := protected.Group("/admin")
+		admin.Use(middlewares.AdminMiddleware())
+		{
+			admin.POST("/badges", handlers.CreateBadge)
+			admin.PUT("/badges/:id", handlers.UpdateBadge)
+		}
 	}
 }
@@ -73,8 +73,8 @@ run_tests "internal/handlers_test" "TestForecastExpensesHandler"
 # Gamification feature tests
 run_tests "internal/handlers_test" "TestGamification|TestCalculateLevel|TestCalculatePointsToNextLevel|TestGetLevelTitle"
 
-# Stub endpoint tests
-run_tests "internal/handlers_test" "TestAnalyticsHandler|TestNotificationHandler"
+# Analytics and notification tests
+run_tests "internal/handlers_test" "TestAnalyticsHandler|TestNotification"
 
 # Print summary
 echo -e "\n======================================================================================"
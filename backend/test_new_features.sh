@@ -17,9 +17,9 @@ go test -v ./internal/handlers_test -run TestCalculatePointsToNextLevel
 go test -v ./internal/handlers_test -run TestGetLevelTitle
 go test -v ./internal/handlers_test -run TestGamificationHandlerWithNilDB
 
-# Test stub endpoints
-echo "Testing Stub Endpoints..."
+# Test analytics and notifications
+echo "Testing Analytics and Notifications..."
 go test -v ./internal/handlers_test -run TestAnalyticsHandler
-go test -v ./internal/handlers_test -run TestNotificationHandler
+go test -v ./internal/handlers_test -run TestNotification
 
 echo "All tests completed!" 
\ No newline at end of file
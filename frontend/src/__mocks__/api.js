@@ -73,6 +73,8 @@ export const getTransactions = jest.fn().mockResolvedValue({
   ]
 });
 
+export const getAllTransactions = jest.fn((...args) => getTransactions(...args));
+
 export const createTransaction = jest.fn().mockResolvedValue({
   data: { id: 3, amount: 25, category: 'Entertainment', date: '2023-01-03' }
 });
@@ -1,5 +1,5 @@
 import { createContext, useState, useEffect, useCallback } from 'react';
-import { getBudgets, getCategories, getTransactions } from '../utils/api';
+import { getBudgets, getCategories, getAllTransactions } from '../utils/api';
 
 export const AuthContext = createContext(null);
 
@@ -23,7 +23,7 @@ export const AuthProvider = ({ children }) => {
             const [budRes, catRes, txRes] = await Promise.all([
                 getBudgets(token),
                 getCategories(token),
-                getTransactions(token)
+                getAllTransactions(token)
             ]);
             
             setDashboardData({
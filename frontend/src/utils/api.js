@@ -98,6 +98,20 @@ export const getTransactions = async (token, params = {}) => {
   });
 };
 
+// GET /transactions returns one page at a time; this follows nextCursor until every matching
+// transaction is loaded and returns them in the shape of a single page.
+export const getAllTransactions = async (token, params = {}) => {
+  const transactions = [];
+  let cursor = null;
+  let res;
+  do {
+    res = await getTransactions(token, cursor ? { ...params, limit: 500, cursor } : { ...params, limit: 500 });
+    transactions.push(...(res.data.transactions || []));
+    cursor = res.data.nextCursor;
+  } while (cursor);
+  return { ...res, data: { ...res.data, transactions, nextCursor: null } };
+};
+
 export const createTransaction = async (token, txData) => {
   return await api.post("/transactions", txData, {
     headers: { Authorization: `Bearer ${token}` },